@@ -578,18 +578,51 @@ func setDotCoreConfig(ctx *cli.Context, tomlCfg ctoml.CoreConfig, cfg *dot.CoreC
 		cfg.GrandpaAuthority = false
 	}
 
-	switch tomlCfg.WasmInterpreter {
-	case wasmer.Name:
-		cfg.WasmInterpreter = wasmer.Name
-	case wasmtime.Name:
-		cfg.WasmInterpreter = wasmtime.Name
-	case life.Name:
-		cfg.WasmInterpreter = life.Name
+	cfg.WasmInterpreter = tomlCfg.WasmInterpreter
+
+	// check --wasm-interpreter flag and update node configuration
+	if interpreter := ctx.GlobalString(WasmInterpreterFlag.Name); interpreter != "" {
+		cfg.WasmInterpreter = interpreter
+	}
+
+	switch cfg.WasmInterpreter {
+	case wasmer.Name, wasmtime.Name, life.Name:
 	case "":
 		cfg.WasmInterpreter = gssmr.DefaultWasmInterpreter
 	default:
+		logger.Warn("invalid wasm interpreter", "wasm-interpreter", cfg.WasmInterpreter, "defaulting to", gssmr.DefaultWasmInterpreter)
 		cfg.WasmInterpreter = gssmr.DefaultWasmInterpreter
-		logger.Warn("invalid wasm interpreter set in config", "defaulting to", gssmr.DefaultWasmInterpreter)
+	}
+
+	cfg.OffchainWorker = tomlCfg.OffchainWorker
+
+	// check --offchain-worker flag and update node configuration
+	if ocw := ctx.GlobalString(OffchainWorkerFlag.Name); ocw != "" {
+		cfg.OffchainWorker = ocw
+	}
+
+	cfg.OffchainIndexing = tomlCfg.OffchainIndexing
+
+	// check --offchain-indexing flag and update node configuration
+	if indexing := ctx.GlobalBool(OffchainIndexingFlag.Name); indexing {
+		cfg.OffchainIndexing = true
+	} else if ctx.IsSet(OffchainIndexingFlag.Name) && !indexing {
+		cfg.OffchainIndexing = false
+	}
+
+	cfg.TxPoolReadyLimit = tomlCfg.TxPoolReadyLimit
+	cfg.TxPoolReadyKBytes = tomlCfg.TxPoolReadyKBytes
+	cfg.TxPoolFutureLimit = tomlCfg.TxPoolFutureLimit
+	cfg.TxPoolFutureKBytes = tomlCfg.TxPoolFutureKBytes
+	cfg.TxPoolJournal = tomlCfg.TxPoolJournal
+
+	switch cfg.OffchainWorker {
+	case dot.OffchainWorkerAlways, dot.OffchainWorkerNever, dot.OffchainWorkerWhenValidating:
+	case "":
+		cfg.OffchainWorker = dot.OffchainWorkerWhenValidating
+	default:
+		logger.Warn("invalid offchain worker option", "offchain-worker", cfg.OffchainWorker, "defaulting to", dot.OffchainWorkerWhenValidating)
+		cfg.OffchainWorker = dot.OffchainWorkerWhenValidating
 	}
 
 	logger.Debug(
@@ -598,6 +631,13 @@ func setDotCoreConfig(ctx *cli.Context, tomlCfg ctoml.CoreConfig, cfg *dot.CoreC
 		"grandpa-authority", cfg.GrandpaAuthority,
 		"epoch-length", cfg.EpochLength,
 		"wasm-interpreter", cfg.WasmInterpreter,
+		"offchain-worker", cfg.OffchainWorker,
+		"offchain-indexing", cfg.OffchainIndexing,
+		"txpool-ready-limit", cfg.TxPoolReadyLimit,
+		"txpool-ready-kbytes", cfg.TxPoolReadyKBytes,
+		"txpool-future-limit", cfg.TxPoolFutureLimit,
+		"txpool-future-kbytes", cfg.TxPoolFutureKBytes,
+		"txpool-journal", cfg.TxPoolJournal,
 	)
 }
 
@@ -669,6 +709,7 @@ func setDotRPCConfig(ctx *cli.Context, tomlCfg ctoml.RPCConfig, cfg *dot.RPCConf
 	cfg.WSPort = tomlCfg.WSPort
 	cfg.WS = tomlCfg.WS
 	cfg.WSExternal = tomlCfg.WSExternal
+	cfg.Unsafe = tomlCfg.Unsafe
 
 	// check --rpc flag and update node configuration
 	if enabled := ctx.GlobalBool(RPCEnabledFlag.Name); enabled || cfg.Enabled {
@@ -719,6 +760,12 @@ func setDotRPCConfig(ctx *cli.Context, tomlCfg ctoml.RPCConfig, cfg *dot.RPCConf
 		cfg.WSExternal = false
 	}
 
+	if unsafe := ctx.GlobalBool(RPCUnsafeFlag.Name); unsafe {
+		cfg.Unsafe = true
+	} else if ctx.IsSet(RPCUnsafeFlag.Name) && !unsafe {
+		cfg.Unsafe = false
+	}
+
 	// format rpc modules
 	if len(cfg.Modules) == 0 {
 		cfg.Modules = []string(nil)
@@ -734,6 +781,7 @@ func setDotRPCConfig(ctx *cli.Context, tomlCfg ctoml.RPCConfig, cfg *dot.RPCConf
 		"ws", cfg.WS,
 		"ws external", cfg.WSExternal,
 		"wsport", cfg.WSPort,
+		"unsafe", cfg.Unsafe,
 	)
 }
 
@@ -25,6 +25,7 @@ import (
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/genesis"
+	"github.com/ChainSafe/gossamer/lib/runtime/wasmtime"
 	"github.com/ChainSafe/gossamer/lib/utils"
 
 	log "github.com/ChainSafe/log15"
@@ -332,6 +333,7 @@ func TestCoreConfigFromFlags(t *testing.T) {
 				BabeAuthority:    true,
 				GrandpaAuthority: true,
 				WasmInterpreter:  gssmr.DefaultWasmInterpreter,
+				OffchainWorker:   dot.OffchainWorkerWhenValidating,
 			},
 		},
 		{
@@ -343,6 +345,68 @@ func TestCoreConfigFromFlags(t *testing.T) {
 				BabeAuthority:    false,
 				GrandpaAuthority: false,
 				WasmInterpreter:  gssmr.DefaultWasmInterpreter,
+				OffchainWorker:   dot.OffchainWorkerWhenValidating,
+			},
+		},
+		{
+			"Test gossamer --offchain-worker",
+			[]string{"config", "roles", "offchain-worker"},
+			[]interface{}{testCfgFile.Name(), "0", "always"},
+			dot.CoreConfig{
+				Roles:            0,
+				BabeAuthority:    false,
+				GrandpaAuthority: false,
+				WasmInterpreter:  gssmr.DefaultWasmInterpreter,
+				OffchainWorker:   dot.OffchainWorkerAlways,
+			},
+		},
+		{
+			"Test gossamer --offchain-indexing",
+			[]string{"config", "offchain-indexing"},
+			[]interface{}{testCfgFile.Name(), true},
+			dot.CoreConfig{
+				Roles:            4,
+				BabeAuthority:    true,
+				GrandpaAuthority: true,
+				WasmInterpreter:  gssmr.DefaultWasmInterpreter,
+				OffchainWorker:   dot.OffchainWorkerWhenValidating,
+				OffchainIndexing: true,
+			},
+		},
+		{
+			"Test gossamer --wasm-interpreter",
+			[]string{"config", "wasm-interpreter"},
+			[]interface{}{testCfgFile.Name(), wasmtime.Name},
+			dot.CoreConfig{
+				Roles:            4,
+				BabeAuthority:    true,
+				GrandpaAuthority: true,
+				WasmInterpreter:  wasmtime.Name,
+				OffchainWorker:   dot.OffchainWorkerWhenValidating,
+			},
+		},
+		{
+			"Test gossamer --wasm-interpreter invalid",
+			[]string{"config", "wasm-interpreter"},
+			[]interface{}{testCfgFile.Name(), "wasm3"},
+			dot.CoreConfig{
+				Roles:            4,
+				BabeAuthority:    true,
+				GrandpaAuthority: true,
+				WasmInterpreter:  gssmr.DefaultWasmInterpreter,
+				OffchainWorker:   dot.OffchainWorkerWhenValidating,
+			},
+		},
+		{
+			"Test gossamer --offchain-worker invalid",
+			[]string{"config", "offchain-worker"},
+			[]interface{}{testCfgFile.Name(), "sometimes"},
+			dot.CoreConfig{
+				Roles:            4,
+				BabeAuthority:    true,
+				GrandpaAuthority: true,
+				WasmInterpreter:  gssmr.DefaultWasmInterpreter,
+				OffchainWorker:   dot.OffchainWorkerWhenValidating,
 			},
 		},
 	}
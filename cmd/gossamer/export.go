@@ -112,6 +112,14 @@ func dotConfigToToml(dcfg *dot.Config) *ctoml.Config {
 		GrandpaAuthority: dcfg.Core.GrandpaAuthority,
 		EpochLength:      dcfg.Core.EpochLength,
 		SlotDuration:     dcfg.Core.SlotDuration,
+		OffchainWorker:   dcfg.Core.OffchainWorker,
+		OffchainIndexing: dcfg.Core.OffchainIndexing,
+
+		TxPoolReadyLimit:   dcfg.Core.TxPoolReadyLimit,
+		TxPoolReadyKBytes:  dcfg.Core.TxPoolReadyKBytes,
+		TxPoolFutureLimit:  dcfg.Core.TxPoolFutureLimit,
+		TxPoolFutureKBytes: dcfg.Core.TxPoolFutureKBytes,
+		TxPoolJournal:      dcfg.Core.TxPoolJournal,
 	}
 
 	cfg.Network = ctoml.NetworkConfig{
@@ -131,6 +139,7 @@ func dotConfigToToml(dcfg *dot.Config) *ctoml.Config {
 		WSPort:     dcfg.RPC.WSPort,
 		WS:         dcfg.RPC.WS,
 		WSExternal: dcfg.RPC.WSExternal,
+		Unsafe:     dcfg.RPC.Unsafe,
 	}
 
 	return cfg
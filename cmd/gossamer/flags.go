@@ -43,6 +43,21 @@ var (
 		Name:  "roles",
 		Usage: "Roles of the gossamer node",
 	}
+	// OffchainWorkerFlag sets when the runtime's offchain workers are run
+	OffchainWorkerFlag = cli.StringFlag{
+		Name:  "offchain-worker",
+		Usage: "When to run the runtime's offchain workers: always, never or when-validating (default: when-validating)",
+	}
+	// OffchainIndexingFlag enables offchain indexing
+	OffchainIndexingFlag = cli.BoolFlag{
+		Name:  "offchain-indexing",
+		Usage: "Enable offchain indexing, which lets the runtime write to the offchain database during block import",
+	}
+	// WasmInterpreterFlag sets the wasm interpreter used to run the runtime
+	WasmInterpreterFlag = cli.StringFlag{
+		Name:  "wasm-interpreter",
+		Usage: "Wasm interpreter used to run the runtime: wasmer, wasmtime or life (default: wasmer)",
+	}
 	// RewindFlag rewinds the head of the chain to the given block number. Useful for development
 	RewindFlag = cli.IntFlag{
 		Name:  "rewind",
@@ -218,6 +233,11 @@ var (
 		Name:  "ws-external",
 		Usage: "Enable external websocket connections",
 	}
+	// RPCUnsafeFlag Enable unsafe RPC methods
+	RPCUnsafeFlag = cli.BoolFlag{
+		Name:  "rpc-unsafe",
+		Usage: "Enable unsafe RPC methods, such as those of the offchain and engine modules",
+	}
 )
 
 // Account management flags
@@ -315,6 +335,9 @@ var (
 		BootnodesFlag,
 		ProtocolFlag,
 		RolesFlag,
+		OffchainWorkerFlag,
+		OffchainIndexingFlag,
+		WasmInterpreterFlag,
 		NoBootstrapFlag,
 		NoMDNSFlag,
 
@@ -327,6 +350,7 @@ var (
 		WSFlag,
 		WSExternalFlag,
 		WSPortFlag,
+		RPCUnsafeFlag,
 
 		// metrics flag
 		PublishMetricsFlag,
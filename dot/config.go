@@ -27,6 +27,16 @@ import (
 	log "github.com/ChainSafe/log15"
 )
 
+// Options for when the runtime's offchain workers are run
+const (
+	// OffchainWorkerAlways runs the offchain workers regardless of the node's role
+	OffchainWorkerAlways = "always"
+	// OffchainWorkerNever disables the offchain workers
+	OffchainWorkerNever = "never"
+	// OffchainWorkerWhenValidating runs the offchain workers only if the node is an authority
+	OffchainWorkerWhenValidating = "when-validating"
+)
+
 // TODO: create separate types for toml config and internal config, needed since we don't want to expose all
 // the internal config options, also type conversions might be needed from toml -> internal types
 
@@ -97,6 +107,19 @@ type CoreConfig struct {
 	SlotDuration     uint64
 	EpochLength      uint64
 	WasmInterpreter  string
+	OffchainWorker   string
+	OffchainIndexing bool
+
+	// TxPoolReadyLimit and TxPoolFutureLimit are the maximum number of ready and future transactions in the
+	// transaction queue, and TxPoolReadyKBytes and TxPoolFutureKBytes their maximum total size. The defaults are used
+	// for the limits that are 0.
+	TxPoolReadyLimit   uint32
+	TxPoolReadyKBytes  uint32
+	TxPoolFutureLimit  uint32
+	TxPoolFutureKBytes uint32
+
+	// TxPoolJournal enables the journal of the pooled transactions, which are restored after a restart
+	TxPoolJournal bool
 }
 
 // RPCConfig is to marshal/unmarshal toml RPC config vars
@@ -109,6 +132,7 @@ type RPCConfig struct {
 	WSPort     uint32
 	WS         bool
 	WSExternal bool
+	Unsafe     bool
 }
 
 // StateConfig is the config for the State service
@@ -159,6 +183,7 @@ func GssmrConfig() *Config {
 			BabeAuthority:    gssmr.DefaultBabeAuthority,
 			GrandpaAuthority: gssmr.DefaultGrandpaAuthority,
 			WasmInterpreter:  gssmr.DefaultWasmInterpreter,
+			OffchainWorker:   OffchainWorkerWhenValidating,
 		},
 		Network: NetworkConfig{
 			Port:        gssmr.DefaultNetworkPort,
@@ -205,6 +230,7 @@ func KusamaConfig() *Config {
 		Core: CoreConfig{
 			Roles:           kusama.DefaultRoles,
 			WasmInterpreter: kusama.DefaultWasmInterpreter,
+			OffchainWorker:  OffchainWorkerWhenValidating,
 		},
 		Network: NetworkConfig{
 			Port:        kusama.DefaultNetworkPort,
@@ -250,6 +276,7 @@ func PolkadotConfig() *Config {
 		Core: CoreConfig{
 			Roles:           polkadot.DefaultRoles,
 			WasmInterpreter: polkadot.DefaultWasmInterpreter,
+			OffchainWorker:  OffchainWorkerWhenValidating,
 		},
 		Network: NetworkConfig{
 			Port:        polkadot.DefaultNetworkPort,
@@ -298,6 +325,7 @@ func DevConfig() *Config {
 			BabeAuthority:    dev.DefaultBabeAuthority,
 			GrandpaAuthority: dev.DefaultGrandpaAuthority,
 			WasmInterpreter:  dev.DefaultWasmInterpreter,
+			OffchainWorker:   OffchainWorkerWhenValidating,
 		},
 		Network: NetworkConfig{
 			Port:        dev.DefaultNetworkPort,
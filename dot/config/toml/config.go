@@ -79,6 +79,14 @@ type CoreConfig struct {
 	SlotDuration     uint64 `toml:"slot-duration,omitempty"`
 	EpochLength      uint64 `toml:"epoch-length,omitempty"`
 	WasmInterpreter  string `toml:"wasm-interpreter,omitempty"`
+	OffchainWorker   string `toml:"offchain-worker,omitempty"`
+	OffchainIndexing bool   `toml:"offchain-indexing,omitempty"`
+
+	TxPoolReadyLimit   uint32 `toml:"txpool-ready-limit,omitempty"`
+	TxPoolReadyKBytes  uint32 `toml:"txpool-ready-kbytes,omitempty"`
+	TxPoolFutureLimit  uint32 `toml:"txpool-future-limit,omitempty"`
+	TxPoolFutureKBytes uint32 `toml:"txpool-future-kbytes,omitempty"`
+	TxPoolJournal      bool   `toml:"txpool-journal,omitempty"`
 }
 
 // RPCConfig is to marshal/unmarshal toml RPC config vars
@@ -91,4 +99,5 @@ type RPCConfig struct {
 	WSPort     uint32   `toml:"ws-port,omitempty"`
 	WS         bool     `toml:"ws,omitempty"`
 	WSExternal bool     `toml:"ws-external,omitempty"`
+	Unsafe     bool     `toml:"unsafe,omitempty"`
 }
@@ -42,6 +42,10 @@ var ErrNilVerifier = errors.New("cannot have nil Verifier")
 // ErrNilRuntime is returned when trying to instantiate a Service or Syncer without a runtime
 var ErrNilRuntime = errors.New("cannot have nil runtime")
 
+// ErrNilRuntimeRegistry is returned when trying to instantiate a Service that runs offchain workers without a
+// runtime registry
+var ErrNilRuntimeRegistry = errors.New("cannot have nil runtime registry")
+
 // ErrNilBlockProducer is returned when trying to instantiate a block producing Service without a block producer
 var ErrNilBlockProducer = errors.New("cannot have nil BlockProducer")
 
@@ -47,6 +47,7 @@ type BlockState interface {
 	RegisterFinalizedChannel(ch chan<- *types.FinalisationInfo) (byte, error)
 	UnregisterFinalizedChannel(id byte)
 	HighestCommonAncestor(a, b common.Hash) (common.Hash, error)
+	IsDescendantOf(parent, child common.Hash) (bool, error)
 	SubChain(start, end common.Hash) ([]common.Hash, error)
 	GetBlockBody(hash common.Hash) (*types.Body, error)
 }
@@ -57,15 +58,24 @@ type StorageState interface {
 	LoadCodeHash(root *common.Hash) (common.Hash, error)
 	TrieState(root *common.Hash) (*rtstorage.TrieState, error)
 	GetStateRootFromBlock(bhash *common.Hash) (*common.Hash, error)
+	GenerateTrieProof(stateRoot common.Hash, keys [][]byte) ([][]byte, error)
 }
 
 // TransactionState is the interface for transaction state methods
 type TransactionState interface {
 	Push(vt *transaction.ValidTransaction) (common.Hash, error)
 	AddToPool(vt *transaction.ValidTransaction) common.Hash
-	RemoveExtrinsic(ext types.Extrinsic)
+	RemoveIncludedExtrinsics(exts []types.Extrinsic)
+	PruneTags(tags [][]byte)
+	Exists(hash common.Hash) bool
+	RemoveExpired(number uint64) []*transaction.ValidTransaction
 	RemoveExtrinsicFromPool(ext types.Extrinsic)
 	PendingInPool() []*transaction.ValidTransaction
+	Pending() []*transaction.ValidTransaction
+	RemoveInvalid(ext types.Extrinsic)
+	UpdateValidity(ext types.Extrinsic, validity *transaction.Validity) error
+	NotifyStatus(n *transaction.StatusNotification)
+	LoadJournal() ([]*transaction.ValidTransaction, error)
 }
 
 // BlockProducer is the interface that a block production service must implement
@@ -82,6 +92,7 @@ type Verifier interface {
 // Network is the interface for the network service
 type Network interface {
 	SendMessage(network.NotificationsMessage)
+	Peers() []common.PeerInfo
 }
 
 // EpochState is the interface for state.EpochState
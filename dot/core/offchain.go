@@ -0,0 +1,63 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+)
+
+// defaultMaxOffchainWorkers is the number of offchain workers that can run at once if no limit is configured
+const defaultMaxOffchainWorkers = 4
+
+// startOffchainWorker runs the runtime's offchain worker for the given block in the background if offchain workers
+// are enabled and the block is the best block. If the maximum number of offchain workers are already running, the
+// block is skipped.
+func (s *Service) startOffchainWorker(header *types.Header) {
+	if !s.offchainWorkers || header.Hash() != s.blockState.BestBlockHash() {
+		return
+	}
+
+	select {
+	case s.offchainWorkerSem <- struct{}{}:
+	default:
+		logger.Debug("too many offchain workers running, skipping block", "number", header.Number, "hash", header.Hash())
+		return
+	}
+
+	go func() {
+		defer func() { <-s.offchainWorkerSem }()
+
+		if err := s.runOffchainWorker(header); err != nil {
+			logger.Warn("failed to run offchain worker", "number", header.Number, "hash", header.Hash(), "error", err)
+		}
+	}()
+}
+
+// runOffchainWorker runs the offchain worker at the state of the given block. It runs on an instance from the runtime
+// registry, so that it doesn't block the main runtime instance, and any storage changes it makes are discarded.
+func (s *Service) runOffchainWorker(header *types.Header) error {
+	ts, err := s.storageState.TrieState(&header.StateRoot)
+	if err != nil {
+		return err
+	}
+
+	logger.Trace("running offchain worker", "number", header.Number, "hash", header.Hash())
+	return s.runtimes.Call(ts, func(rt runtime.Instance) error {
+		return rt.OffchainWorker(header)
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockOffchainRuntime is a runtime instance that reports the blocks its offchain worker is run for
+type mockOffchainRuntime struct {
+	runtime.Instance
+	headers chan *types.Header
+}
+
+func (rt *mockOffchainRuntime) OffchainWorker(header *types.Header) error {
+	rt.headers <- header
+	return nil
+}
+
+func (rt *mockOffchainRuntime) Stop() {}
+
+func (rt *mockOffchainRuntime) SetContextStorage(_ runtime.Storage) {}
+
+func TestService_StartOffchainWorker(t *testing.T) {
+	headers := make(chan *types.Header)
+	var code []byte
+
+	cfg := &Config{
+		OffchainWorkers:    true,
+		MaxOffchainWorkers: 1,
+		Runtimes: runtime.NewRegistry(0, func(c []byte, _ runtime.Storage) (runtime.Instance, error) {
+			code = c
+			return &mockOffchainRuntime{headers: headers}, nil
+		}),
+	}
+	s := NewTestService(t, cfg)
+
+	best, err := s.blockState.BestBlockHeader()
+	require.NoError(t, err)
+
+	s.startOffchainWorker(best)
+
+	// the limit of offchain workers is reached, so the block is skipped
+	s.startOffchainWorker(best)
+
+	select {
+	case header := <-headers:
+		require.Equal(t, best.Hash(), header.Hash())
+	case <-time.After(testMessageTimeout):
+		t.Fatal("offchain worker wasn't run")
+	}
+
+	select {
+	case <-headers:
+		t.Fatal("offchain worker shouldn't have run")
+	case <-time.After(testMessageTimeout):
+	}
+
+	ts, err := s.storageState.TrieState(&best.StateRoot)
+	require.NoError(t, err)
+	require.Equal(t, ts.LoadCode(), code)
+}
+
+func TestService_StartOffchainWorker_Disabled(t *testing.T) {
+	s := NewTestService(t, nil)
+
+	best, err := s.blockState.BestBlockHeader()
+	require.NoError(t, err)
+
+	// no runtime registry is needed if the offchain workers are disabled
+	s.startOffchainWorker(best)
+	require.Empty(t, s.offchainWorkerSem)
+
+	_, err = NewService(&Config{
+		Keystore:        s.keys,
+		BlockState:      s.blockState,
+		StorageState:    s.storageState,
+		Runtime:         s.rt,
+		OffchainWorkers: true,
+	})
+	require.Equal(t, ErrNilRuntimeRegistry, err)
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+)
+
+// revalidationBatchSize is the number of pending transactions that are revalidated against the same best block state
+const revalidationBatchSize = 64
+
+// requestRevalidation schedules a revalidation of the pending transactions. Requests made while one is already
+// scheduled are merged into it.
+func (s *Service) requestRevalidation() {
+	select {
+	case s.revalidateCh <- struct{}{}:
+	default:
+	}
+}
+
+// revalidateTransactions revalidates the pending transactions each time it is requested, until the context is done
+func (s *Service) revalidateTransactions(ctx context.Context) {
+	for {
+		select {
+		case <-s.revalidateCh:
+			if err := s.revalidate(ctx); err != nil {
+				logger.Warn("failed to revalidate transactions", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// revalidate validates the pending transactions in batches against the state of the best block, removes the ones
+// that are no longer valid and updates the validity of the others, so that the transactions whose required tags are
+// now provided by the chain are promoted. Each batch uses the best block at the time it starts, so that a revalidation
+// following a new block doesn't have to wait for the current one to finish.
+func (s *Service) revalidate(ctx context.Context) error {
+	txs := s.transactionState.Pending()
+	for start := 0; start < len(txs); start += revalidationBatchSize {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		end := start + revalidationBatchSize
+		if end > len(txs) {
+			end = len(txs)
+		}
+
+		root, err := s.blockState.BestBlockStateRoot()
+		if err != nil {
+			return err
+		}
+
+		for _, tx := range txs[start:end] {
+			validity, err := s.validateTransaction(root, tx.Extrinsic)
+			switch {
+			case err == nil:
+				s.updateValidity(tx, validity)
+			case errors.Is(err, runtime.ErrUnknownTransaction):
+				// transactions whose validity can't be determined at this state are kept
+			default:
+				logger.Debug("removing invalid transaction", "extrinsic", tx.Extrinsic, "error", err)
+				s.transactionState.RemoveInvalid(tx.Extrinsic)
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateValidity replaces the validity of the pending transaction with its new validity if it has changed. The
+// transaction is dropped if it now conflicts with a queued transaction with a higher priority.
+func (s *Service) updateValidity(tx *transaction.ValidTransaction, validity *transaction.Validity) {
+	if reflect.DeepEqual(tx.Validity, validity) {
+		return
+	}
+
+	err := s.transactionState.UpdateValidity(tx.Extrinsic, validity)
+	if errors.Is(err, transaction.ErrTooLowPriority) {
+		logger.Debug("dropping revalidated transaction", "extrinsic", tx.Extrinsic, "error", err)
+		s.transactionState.NotifyStatus(&transaction.StatusNotification{
+			Hash:   tx.Extrinsic.Hash(),
+			Status: transaction.Dropped,
+		})
+	}
+}
+
+// replayTransactionJournal adds the valid transactions of the transaction journal back to the pool. They are
+// revalidated against the state of the best block, since it may have changed since they were journaled.
+func (s *Service) replayTransactionJournal() error {
+	txs, err := s.transactionState.LoadJournal()
+	if err != nil {
+		return err
+	}
+
+	if len(txs) == 0 {
+		return nil
+	}
+
+	root, err := s.blockState.BestBlockStateRoot()
+	if err != nil {
+		return err
+	}
+
+	var restored int
+	for _, tx := range txs {
+		validity, err := s.validateTransaction(root, tx.Extrinsic)
+		switch {
+		case err == nil:
+			tx.Validity = validity
+		case errors.Is(err, runtime.ErrUnknownTransaction):
+			// the journaled validity is kept, as for the pending transactions that are revalidated
+		default:
+			logger.Debug("dropping invalid journaled transaction", "extrinsic", tx.Extrinsic, "error", err)
+			continue
+		}
+
+		if !s.isBlockProducer {
+			s.transactionState.AddToPool(tx)
+			restored++
+			continue
+		}
+
+		// a block producer can include the restored transactions in its next block
+		if _, err = s.transactionState.Push(tx); err != nil {
+			logger.Debug("dropping journaled transaction", "extrinsic", tx.Extrinsic, "error", err)
+			continue
+		}
+		restored++
+	}
+
+	logger.Info("restored transactions from journal", "restored", restored, "journaled", len(txs))
+	return nil
+}
+
+// providedTags returns the tags provided by the extrinsics, which are learnt by validating them at the state of the
+// parent of the block that includes them
+func (s *Service) providedTags(parent common.Hash, exts []types.Extrinsic) [][]byte {
+	root, err := s.storageState.GetStateRootFromBlock(&parent)
+	if err != nil {
+		logger.Debug("failed to get parent state root of included extrinsics", "parent", parent, "error", err)
+		return nil
+	}
+
+	var tags [][]byte
+	for _, ext := range exts {
+		// inherents aren't valid transactions, and provide no tags
+		validity, err := s.validateTransaction(*root, ext)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, validity.Provides...)
+	}
+
+	return tags
+}
+
+// validateTransaction validates the extrinsic as an external transaction at the state with the given root. Any
+// storage changes made by the runtime are discarded.
+func (s *Service) validateTransaction(root common.Hash, ext types.Extrinsic) (*transaction.Validity, error) {
+	ts, err := s.storageState.TrieState(&root)
+	if err != nil {
+		return nil, err
+	}
+
+	externalExt := types.Extrinsic(append([]byte{byte(types.TxnExternal)}, ext...))
+
+	var validity *transaction.Validity
+	err = s.callRuntime(ts, func(rt runtime.Instance) error {
+		validity, err = rt.ValidateTransaction(externalExt)
+		return err
+	})
+	return validity, err
+}
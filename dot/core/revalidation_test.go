@@ -0,0 +1,237 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/babe"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockValidationRuntime is a runtime instance that returns the configured validation error or validity for an
+// extrinsic
+type mockValidationRuntime struct {
+	runtime.Instance
+	errs     map[string]error
+	validity map[string]*transaction.Validity
+}
+
+func (rt *mockValidationRuntime) ValidateTransaction(e types.Extrinsic) (*transaction.Validity, error) {
+	// the extrinsic is prefixed with its source
+	if err := rt.errs[string(e[1:])]; err != nil {
+		return nil, err
+	}
+	if validity := rt.validity[string(e[1:])]; validity != nil {
+		return validity, nil
+	}
+	return &transaction.Validity{Priority: 1}, nil
+}
+
+func (rt *mockValidationRuntime) Stop() {}
+
+func (rt *mockValidationRuntime) SetContextStorage(_ runtime.Storage) {}
+
+func TestService_RevalidateTransactions(t *testing.T) {
+	errs := map[string]error{
+		"b": runtime.ErrInvalidTransaction,
+		"c": runtime.ErrUnknownTransaction,
+	}
+
+	cfg := &Config{
+		Runtimes: runtime.NewRegistry(0, func(_ []byte, _ runtime.Storage) (runtime.Instance, error) {
+			return &mockValidationRuntime{errs: errs}, nil
+		}),
+	}
+	s := NewTestService(t, cfg)
+	ts := s.transactionState.(*state.TransactionState)
+
+	txs := []*transaction.ValidTransaction{
+		{
+			Extrinsic: []byte("a"),
+			Validity:  &transaction.Validity{Priority: 1},
+		},
+		{
+			Extrinsic: []byte("b"),
+			Validity:  &transaction.Validity{Priority: 1},
+		},
+		{
+			Extrinsic: []byte("c"),
+			Validity:  &transaction.Validity{Priority: 1},
+		},
+	}
+	_, err := ts.Push(txs[0])
+	require.NoError(t, err)
+	ts.AddToPool(txs[1])
+	ts.AddToPool(txs[2])
+
+	statusCh := make(chan *transaction.StatusNotification, 1)
+	ts.RegisterStatusChannel(txs[1].Extrinsic.Hash(), statusCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.revalidateTransactions(ctx)
+	s.requestRevalidation()
+
+	select {
+	case n := <-statusCh:
+		require.Equal(t, &transaction.StatusNotification{
+			Hash:   txs[1].Extrinsic.Hash(),
+			Status: transaction.Invalid,
+		}, n)
+	case <-time.After(testMessageTimeout):
+		t.Fatal("invalid transaction wasn't removed")
+	}
+
+	require.Equal(t, []*transaction.ValidTransaction{txs[0], txs[2]}, ts.Pending())
+}
+
+// newTestReplayService returns a service whose transaction state has a journal of the transactions "a", "b" and "c"
+// with the given validity. "b" is invalid and the validity of "c" is unknown when they are replayed.
+func newTestReplayService(t *testing.T, journaled *transaction.Validity) (*Service, *state.TransactionState) {
+	errs := map[string]error{
+		"b": runtime.ErrInvalidTransaction,
+		"c": runtime.ErrUnknownTransaction,
+	}
+
+	cfg := &Config{
+		Runtimes: runtime.NewRegistry(0, func(_ []byte, _ runtime.Storage) (runtime.Instance, error) {
+			return &mockValidationRuntime{errs: errs}, nil
+		}),
+	}
+	s := NewTestService(t, cfg)
+
+	// journal the transactions before the restart
+	db := state.NewInMemoryDB(t)
+	prev := state.NewTransactionState()
+	require.NoError(t, prev.EnableJournal(db))
+
+	for _, ext := range []string{"a", "b", "c"} {
+		prev.AddToPool(transaction.NewValidTransaction([]byte(ext), journaled))
+	}
+
+	ts := state.NewTransactionState()
+	require.NoError(t, ts.EnableJournal(db))
+	s.transactionState = ts
+	return s, ts
+}
+
+func TestService_ReplayTransactionJournal(t *testing.T) {
+	journaled := transaction.NewValidity(7, [][]byte{}, [][]byte{}, 0, true)
+	s, ts := newTestReplayService(t, journaled)
+
+	err := s.replayTransactionJournal()
+	require.NoError(t, err)
+
+	// the valid transaction is revalidated, and the transaction whose validity is unknown keeps its journaled validity
+	require.ElementsMatch(t, []*transaction.ValidTransaction{
+		transaction.NewValidTransaction([]byte("a"), &transaction.Validity{Priority: 1}),
+		transaction.NewValidTransaction([]byte("c"), journaled),
+	}, ts.PendingInPool())
+}
+
+func TestService_ReplayTransactionJournal_BlockProducer(t *testing.T) {
+	journaled := transaction.NewValidity(7, [][]byte{}, [][]byte{}, 0, true)
+	s, ts := newTestReplayService(t, journaled)
+	s.isBlockProducer = true
+
+	err := s.replayTransactionJournal()
+	require.NoError(t, err)
+
+	// the restored transactions are pushed straight to the queue, ordered by priority
+	require.Empty(t, ts.PendingInPool())
+	require.Equal(t, transaction.NewValidTransaction([]byte("c"), journaled), ts.Pop())
+	require.Equal(t, transaction.NewValidTransaction([]byte("a"), &transaction.Validity{Priority: 1}), ts.Pop())
+	require.Nil(t, ts.Pop())
+}
+
+func TestService_MaintainTransactionPool_UnknownProvider(t *testing.T) {
+	provider := &transaction.ValidTransaction{
+		Extrinsic: encodeExtrinsic(t, []byte("provider")),
+		Validity:  transaction.NewValidity(1, [][]byte{}, [][]byte{{1}}, 0, true),
+	}
+	dependent := &transaction.ValidTransaction{
+		Extrinsic: encodeExtrinsic(t, []byte("dependent")),
+		Validity:  transaction.NewValidity(1, [][]byte{{1}}, [][]byte{{2}}, 0, true),
+	}
+
+	validity := map[string]*transaction.Validity{
+		string(provider.Extrinsic): provider.Validity,
+	}
+	cfg := &Config{
+		Runtimes: runtime.NewRegistry(0, func(_ []byte, _ runtime.Storage) (runtime.Instance, error) {
+			return &mockValidationRuntime{validity: validity}, nil
+		}),
+	}
+	s := NewTestService(t, cfg)
+	ts := s.transactionState.(*state.TransactionState)
+
+	_, err := ts.Push(dependent)
+	require.NoError(t, err)
+	require.Nil(t, ts.Peek())
+
+	// the provider was never in the queue, so the tags it provides are learnt by validating it
+	body, err := babe.ExtrinsicsToBody(nil, []*transaction.ValidTransaction{provider})
+	require.NoError(t, err)
+
+	err = s.maintainTransactionPool(&types.Block{
+		Header: &types.Header{
+			ParentHash: s.blockState.BestBlockHash(),
+			Number:     big.NewInt(1),
+		},
+		Body: body,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, dependent, ts.Pop())
+}
+
+func TestService_RevalidateTransactions_Promotes(t *testing.T) {
+	tx := &transaction.ValidTransaction{
+		Extrinsic: []byte("a"),
+		Validity:  transaction.NewValidity(1, [][]byte{{1}}, [][]byte{{2}}, 0, true),
+	}
+
+	// at the state of the best block, the tag the transaction required is provided by the chain
+	validity := map[string]*transaction.Validity{
+		"a": transaction.NewValidity(1, [][]byte{}, [][]byte{{2}}, 0, true),
+	}
+	cfg := &Config{
+		Runtimes: runtime.NewRegistry(0, func(_ []byte, _ runtime.Storage) (runtime.Instance, error) {
+			return &mockValidationRuntime{validity: validity}, nil
+		}),
+	}
+	s := NewTestService(t, cfg)
+	ts := s.transactionState.(*state.TransactionState)
+
+	_, err := ts.Push(tx)
+	require.NoError(t, err)
+	require.Nil(t, ts.Peek())
+
+	err = s.revalidate(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, transaction.NewValidTransaction(tx.Extrinsic, validity["a"]), ts.Pop())
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/ChainSafe/gossamer/lib/crypto"
 	"github.com/ChainSafe/gossamer/lib/keystore"
 	"github.com/ChainSafe/gossamer/lib/runtime"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/ChainSafe/gossamer/lib/scale"
 	"github.com/ChainSafe/gossamer/lib/services"
 	"github.com/ChainSafe/gossamer/lib/transaction"
@@ -54,6 +55,9 @@ type Service struct {
 	rt       runtime.Instance
 	codeHash common.Hash
 
+	// Runtime instances for the runtime code at any block
+	runtimes *runtime.Registry
+
 	// Block production variables
 	blockProducer   BlockProducer
 	isBlockProducer bool
@@ -64,6 +68,10 @@ type Service struct {
 	// Keystore
 	keys *keystore.GlobalKeystore
 
+	// Offchain workers
+	offchainWorkers   bool
+	offchainWorkerSem chan struct{} // limits the number of offchain workers running at once
+
 	// Channels and interfaces for inter-process communication
 	blkRec <-chan types.Block // receive blocks from BABE session
 	net    Network
@@ -71,6 +79,16 @@ type Service struct {
 	blockAddCh   chan *types.Block // receive blocks added to blocktree
 	blockAddChID byte
 
+	finalisedCh   chan *types.FinalisationInfo // receive finalised blocks
+	finalisedChID byte
+
+	// Transaction pool maintenance
+	bestHash     common.Hash   // best block hash when the last imported block was handled, used to detect re-orgs
+	revalidateCh chan struct{} // requests a revalidation of the pending transactions
+
+	// Extrinsics included in the blocks that aren't finalised yet, which are watched until the blocks are finalised
+	included map[common.Hash]*includedBlock
+
 	// State variables
 	lock *sync.Mutex // channel lock
 }
@@ -89,6 +107,15 @@ type Config struct {
 	IsBlockProducer  bool
 	Verifier         Verifier
 
+	// Runtimes provides the runtime instances used for calls at the state of a given block. If it is nil, Runtime is
+	// used for all calls.
+	Runtimes *runtime.Registry
+
+	// OffchainWorkers enables running the runtime's offchain worker for each new best block, on a runtime instance
+	// from Runtimes. At most MaxOffchainWorkers offchain workers run at once.
+	OffchainWorkers    bool
+	MaxOffchainWorkers int
+
 	NewBlocks chan types.Block // only used for testing purposes
 }
 
@@ -115,6 +142,10 @@ func NewService(cfg *Config) (*Service, error) {
 		return nil, ErrNilBlockProducer
 	}
 
+	if cfg.OffchainWorkers && cfg.Runtimes == nil {
+		return nil, ErrNilRuntimeRegistry
+	}
+
 	h := log.StreamHandler(os.Stdout, log.TerminalFormat())
 	h = log.CallerFileHandler(h)
 	logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
@@ -135,26 +166,45 @@ func NewService(cfg *Config) (*Service, error) {
 		return nil, err
 	}
 
+	finalisedCh := make(chan *types.FinalisationInfo, 16)
+	finalisedID, err := cfg.BlockState.RegisterFinalizedChannel(finalisedCh)
+	if err != nil {
+		return nil, err
+	}
+
+	maxOffchainWorkers := cfg.MaxOffchainWorkers
+	if maxOffchainWorkers <= 0 {
+		maxOffchainWorkers = defaultMaxOffchainWorkers
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	srv := &Service{
-		ctx:              ctx,
-		cancel:           cancel,
-		rt:               cfg.Runtime,
-		codeHash:         codeHash,
-		keys:             cfg.Keystore,
-		blkRec:           cfg.NewBlocks,
-		blockState:       cfg.BlockState,
-		epochState:       cfg.EpochState,
-		storageState:     cfg.StorageState,
-		transactionState: cfg.TransactionState,
-		net:              cfg.Network,
-		isBlockProducer:  cfg.IsBlockProducer,
-		blockProducer:    cfg.BlockProducer,
-		verifier:         cfg.Verifier,
-		lock:             &sync.Mutex{},
-		blockAddCh:       blockAddCh,
-		blockAddChID:     id,
+		ctx:               ctx,
+		cancel:            cancel,
+		rt:                cfg.Runtime,
+		codeHash:          codeHash,
+		runtimes:          cfg.Runtimes,
+		keys:              cfg.Keystore,
+		blkRec:            cfg.NewBlocks,
+		blockState:        cfg.BlockState,
+		epochState:        cfg.EpochState,
+		storageState:      cfg.StorageState,
+		transactionState:  cfg.TransactionState,
+		net:               cfg.Network,
+		isBlockProducer:   cfg.IsBlockProducer,
+		blockProducer:     cfg.BlockProducer,
+		verifier:          cfg.Verifier,
+		lock:              &sync.Mutex{},
+		blockAddCh:        blockAddCh,
+		blockAddChID:      id,
+		finalisedCh:       finalisedCh,
+		finalisedChID:     finalisedID,
+		bestHash:          cfg.BlockState.BestBlockHash(),
+		revalidateCh:      make(chan struct{}, 1),
+		included:          make(map[common.Hash]*includedBlock),
+		offchainWorkers:   cfg.OffchainWorkers,
+		offchainWorkerSem: make(chan struct{}, maxOffchainWorkers),
 	}
 
 	if cfg.NewBlocks != nil {
@@ -163,6 +213,11 @@ func NewService(cfg *Config) (*Service, error) {
 		srv.blkRec = cfg.BlockProducer.GetBlockChannel()
 	}
 
+	// restore the transactions pooled before the node was restarted, before block production starts
+	if err = srv.replayTransactionJournal(); err != nil {
+		return nil, err
+	}
+
 	return srv, nil
 }
 
@@ -179,6 +234,9 @@ func (s *Service) Start() error {
 	// start handling imported blocks
 	go s.handleBlocks(s.ctx)
 
+	// start revalidating pending transactions after blocks are imported
+	go s.revalidateTransactions(s.ctx)
+
 	return nil
 }
 
@@ -191,6 +249,9 @@ func (s *Service) Stop() error {
 	s.blockState.UnregisterImportedChannel(s.blockAddChID)
 	close(s.blockAddCh)
 
+	s.blockState.UnregisterFinalizedChannel(s.finalisedChID)
+	close(s.finalisedCh)
+
 	return nil
 }
 
@@ -210,8 +271,6 @@ func (s *Service) StorageRoot() (common.Hash, error) {
 
 func (s *Service) handleBlocks(ctx context.Context) {
 	for {
-		//prev := s.blockState.BestBlockHash()
-
 		select {
 		case block := <-s.blockAddCh:
 			if block == nil {
@@ -222,14 +281,28 @@ func (s *Service) handleBlocks(ctx context.Context) {
 				logger.Warn("failed to handle epoch for block", "block", block.Header.Hash(), "error", err)
 			}
 
-			// TODO: add inherent check
-			// if err := s.handleChainReorg(prev, block.Header.Hash()); err != nil {
-			// 	logger.Warn("failed to re-add transactions to chain upon re-org", "error", err)
-			// }
+			if best := s.blockState.BestBlockHash(); best != s.bestHash {
+				if err := s.handleChainReorg(s.bestHash, best); err != nil {
+					logger.Warn("failed to re-add transactions to chain upon re-org", "error", err)
+				}
+				s.bestHash = best
+			}
 
 			if err := s.maintainTransactionPool(block); err != nil {
 				logger.Warn("failed to maintain transaction pool", "error", err)
 			}
+			if err := s.notifyIncluded(block); err != nil {
+				logger.Warn("failed to notify included transactions", "block", block.Header.Hash(), "error", err)
+			}
+			s.requestRevalidation()
+
+			s.startOffchainWorker(block.Header)
+		case info := <-s.finalisedCh:
+			if info == nil || info.Header == nil {
+				continue
+			}
+
+			s.notifyFinalised(info.Header)
 		case <-ctx.Done():
 			return
 		}
@@ -304,8 +377,9 @@ func (s *Service) handleReceivedBlock(block *types.Block) (err error) {
 }
 
 // handleChainReorg checks if there is a chain re-org (ie. new chain head is on a different chain than the
-// previous chain head). If there is a re-org, it moves the transactions that were included on the previous
-// chain back into the transaction pool.
+// previous chain head). If there is a re-org, it notifies that the transactions that were included on the previous
+// chain were retracted, moves the ones that are still valid at the new chain head back into the transaction pool and
+// requests a revalidation of the pending transactions.
 func (s *Service) handleChainReorg(prev, curr common.Hash) error {
 	ancestor, err := s.blockState.HighestCommonAncestor(prev, curr)
 	if err != nil {
@@ -328,8 +402,16 @@ func (s *Service) handleChainReorg(prev, curr common.Hash) error {
 		subchain = subchain[1:]
 	}
 
+	root, err := s.blockState.BestBlockStateRoot()
+	if err != nil {
+		return err
+	}
+	defer s.requestRevalidation()
+
 	// for each block in the previous chain, re-add its extrinsics back into the pool
 	for _, hash := range subchain {
+		delete(s.included, hash)
+
 		body, err := s.blockState.GetBlockBody(hash)
 		if err != nil {
 			continue
@@ -361,8 +443,13 @@ func (s *Service) handleChainReorg(prev, curr common.Hash) error {
 				return err
 			}
 
-			externalExt := types.Extrinsic(append([]byte{byte(types.TxnExternal)}, encExt...))
-			txv, err := s.rt.ValidateTransaction(externalExt)
+			s.transactionState.NotifyStatus(&transaction.StatusNotification{
+				Hash:   types.Extrinsic(encExt).Hash(),
+				Status: transaction.Retracted,
+				Block:  hash,
+			})
+
+			txv, err := s.validateTransaction(root, encExt)
 			if err != nil {
 				logger.Debug("failed to validate transaction", "error", err, "extrinsic", ext)
 				continue
@@ -376,34 +463,41 @@ func (s *Service) handleChainReorg(prev, curr common.Hash) error {
 	return nil
 }
 
-// maintainTransactionPool removes any transactions that were included in the new block, revalidates the transactions in the pool,
-// and moves them to the queue if valid.
+// maintainTransactionPool removes any transactions that were included in the new block or expired, and moves the
+// transactions in the pool to the queue. The pending transactions are revalidated afterwards in the background.
 // See https://github.com/paritytech/substrate/blob/74804b5649eccfb83c90aec87bdca58e5d5c8789/client/transaction-pool/src/lib.rs#L545
 func (s *Service) maintainTransactionPool(block *types.Block) error {
-	exts, err := block.Body.AsExtrinsics()
+	// the pooled extrinsics are SCALE encoded, as submitted
+	exts, err := block.Body.AsEncodedExtrinsics()
 	if err != nil {
 		return err
 	}
 
-	// remove extrinsics included in a block
+	// the tags provided by the included extrinsics that weren't pending are unknown to the queue
+	var unknown []types.Extrinsic
 	for _, ext := range exts {
-		s.transactionState.RemoveExtrinsic(ext)
+		if !s.transactionState.Exists(ext.Hash()) {
+			unknown = append(unknown, ext)
+		}
 	}
 
-	// re-validate transactions in the pool and move them to the queue
-	txs := s.transactionState.PendingInPool()
-	for _, tx := range txs {
-		// TODO: re-add this on update to v0.8
+	// remove extrinsics included in a block
+	s.transactionState.RemoveIncludedExtrinsics(exts)
 
-		// val, err := s.rt.ValidateTransaction(tx.Extrinsic)
-		// if err != nil {
-		// 	// failed to validate tx, remove it from the pool or queue
-		// 	s.transactionState.RemoveExtrinsic(ext)
-		// 	continue
-		// }
+	if len(unknown) > 0 && block.Header != nil {
+		s.transactionState.PruneTags(s.providedTags(block.Header.ParentHash, unknown))
+	}
 
-		// tx = transaction.NewValidTransaction(tx.Extrinsic, val)
+	// remove transactions whose longevity has run out
+	if block.Header != nil {
+		for _, tx := range s.transactionState.RemoveExpired(block.Header.Number.Uint64()) {
+			logger.Trace("removed expired transaction", "extrinsic", tx.Extrinsic)
+		}
+	}
 
+	// move the transactions in the pool to the queue
+	txs := s.transactionState.PendingInPool()
+	for _, tx := range txs {
 		h, err := s.transactionState.Push(tx)
 		if err != nil && err == transaction.ErrTransactionExists {
 			// transaction is already in queue, remove it from the pool
@@ -411,6 +505,17 @@ func (s *Service) maintainTransactionPool(block *types.Block) error {
 			continue
 		}
 
+		if err == transaction.ErrTooLowPriority {
+			// a transaction providing the same tags with a higher priority is already in the queue
+			logger.Debug("dropping transaction", "hash", h, "error", err)
+			s.transactionState.RemoveExtrinsicFromPool(tx.Extrinsic)
+			s.transactionState.NotifyStatus(&transaction.StatusNotification{
+				Hash:   h,
+				Status: transaction.Dropped,
+			})
+			continue
+		}
+
 		s.transactionState.RemoveExtrinsicFromPool(tx.Extrinsic)
 		logger.Trace("moved transaction to queue", "hash", h)
 	}
@@ -447,8 +552,12 @@ func (s *Service) GetRuntimeVersion(bhash *common.Hash) (runtime.Version, error)
 		return nil, err
 	}
 
-	s.rt.SetContextStorage(ts)
-	return s.rt.Version()
+	var version runtime.Version
+	err = s.callRuntime(ts, func(rt runtime.Instance) error {
+		version, err = rt.Version()
+		return err
+	})
+	return version, err
 }
 
 // IsBlockProducer returns true if node is a block producer
@@ -479,6 +588,18 @@ func (s *Service) HandleSubmittedExtrinsic(ext types.Extrinsic) error {
 	// broadcast transaction
 	msg := &network.TransactionMessage{Extrinsics: []types.Extrinsic{ext}}
 	s.net.SendMessage(msg)
+
+	peers := s.net.Peers()
+	ids := make([]string, len(peers))
+	for i, p := range peers {
+		ids[i] = p.PeerID
+	}
+
+	s.transactionState.NotifyStatus(&transaction.StatusNotification{
+		Hash:   ext.Hash(),
+		Status: transaction.Broadcast,
+		Peers:  ids,
+	})
 	return nil
 }
 
@@ -501,6 +622,73 @@ func (s *Service) GetMetadata(bhash *common.Hash) ([]byte, error) {
 		return nil, err
 	}
 
-	s.rt.SetContextStorage(ts)
-	return s.rt.Metadata()
+	var metadata []byte
+	err = s.callRuntime(ts, func(rt runtime.Instance) error {
+		metadata, err = rt.Metadata()
+		return err
+	})
+	return metadata, err
+}
+
+// CallRuntime executes the given runtime method with the given data at the state of the given block.
+// If no block hash is provided, the call is made at the best block.
+func (s *Service) CallRuntime(bhash *common.Hash, method string, data []byte) ([]byte, error) {
+	var stateRootHash *common.Hash
+	if bhash != nil {
+		var err error
+		stateRootHash, err = s.storageState.GetStateRootFromBlock(bhash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ts, err := s.storageState.TrieState(stateRootHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []byte
+	err = s.callRuntime(ts, func(rt runtime.Instance) error {
+		ret, err = rt.Exec(method, data)
+		return err
+	})
+	return ret, err
+}
+
+// CallWithProof executes the given runtime call at the state of the given block and returns a proof of
+// the storage read during the call, which can be used by a light client to re-execute the call.
+func (s *Service) CallWithProof(bhash common.Hash, method string, data []byte) ([][]byte, error) {
+	stateRoot, err := s.storageState.GetStateRootFromBlock(&bhash)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := s.storageState.TrieState(stateRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := rtstorage.NewTrieStateRecorder(ts)
+	err = s.callRuntime(rec, func(rt runtime.Instance) error {
+		_, err := rt.Exec(method, data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the runtime code is needed to re-execute the call
+	keys := append(rec.Keys(), common.CodeKey)
+	return s.storageState.GenerateTrieProof(*stateRoot, keys)
+}
+
+// callRuntime calls fn with the runtime instance for the runtime code in the given storage, with the storage set as
+// its context storage
+func (s *Service) callRuntime(ts runtime.Storage, fn func(rt runtime.Instance) error) error {
+	if s.runtimes == nil {
+		s.rt.SetContextStorage(ts)
+		return fn(s.rt)
+	}
+
+	return s.runtimes.Call(ts, fn)
 }
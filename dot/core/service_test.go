@@ -27,11 +27,13 @@ import (
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/sync"
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/babe"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/keystore"
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	"github.com/ChainSafe/gossamer/lib/runtime/extrinsic"
 	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
+	"github.com/ChainSafe/gossamer/lib/scale"
 	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/ChainSafe/gossamer/lib/utils"
 	log "github.com/ChainSafe/log15"
@@ -208,11 +210,20 @@ func TestHandleChainReorg_WithReorg_Trans(t *testing.T) {
 	err = bs.AddBlock(block41)
 	require.NoError(t, err)
 
+	statusCh := make(chan *transaction.StatusNotification, 1)
+	s.transactionState.(*state.TransactionState).RegisterStatusChannel(ext.Hash(), statusCh)
+
 	err = s.handleChainReorg(block41.Header.Hash(), block5.Header.Hash())
 	require.NoError(t, err)
 
 	pending := s.transactionState.(*state.TransactionState).Pending()
 	require.Equal(t, 1, len(pending))
+
+	require.Equal(t, &transaction.StatusNotification{
+		Hash:   ext.Hash(),
+		Status: transaction.Retracted,
+		Block:  block41.Header.Hash(),
+	}, <-statusCh)
 }
 
 func TestHandleChainReorg_WithReorg_NoTransactions(t *testing.T) {
@@ -360,11 +371,11 @@ func TestMaintainTransactionPool_EmptyBlock(t *testing.T) {
 func TestMaintainTransactionPool_BlockWithExtrinsics(t *testing.T) {
 	txs := []*transaction.ValidTransaction{
 		{
-			Extrinsic: []byte("a"),
+			Extrinsic: encodeExtrinsic(t, []byte("a")),
 			Validity:  &transaction.Validity{Priority: 1},
 		},
 		{
-			Extrinsic: []byte("b"),
+			Extrinsic: encodeExtrinsic(t, []byte("b")),
 			Validity:  &transaction.Validity{Priority: 4},
 		},
 	}
@@ -381,7 +392,7 @@ func TestMaintainTransactionPool_BlockWithExtrinsics(t *testing.T) {
 		transactionState: ts,
 	}
 
-	body, err := types.NewBodyFromExtrinsics([]types.Extrinsic{txs[0].Extrinsic})
+	body, err := babe.ExtrinsicsToBody(nil, txs[:1])
 	require.NoError(t, err)
 
 	err = s.maintainTransactionPool(&types.Block{
@@ -401,6 +412,46 @@ func TestMaintainTransactionPool_BlockWithExtrinsics(t *testing.T) {
 	require.Equal(t, res[0], txs[1])
 }
 
+func TestMaintainTransactionPool_PromotesDependents(t *testing.T) {
+	provider := &transaction.ValidTransaction{
+		Extrinsic: encodeExtrinsic(t, []byte("provider")),
+		Validity:  transaction.NewValidity(1, [][]byte{}, [][]byte{{1}}, 0, true),
+	}
+	dependent := &transaction.ValidTransaction{
+		Extrinsic: encodeExtrinsic(t, []byte("dependent")),
+		Validity:  transaction.NewValidity(1, [][]byte{{1}}, [][]byte{{2}}, 0, true),
+	}
+
+	ts := state.NewTransactionState()
+	_, err := ts.Push(provider)
+	require.NoError(t, err)
+	_, err = ts.Push(dependent)
+	require.NoError(t, err)
+
+	s := &Service{
+		transactionState: ts,
+	}
+
+	// the provider is included in a block built by another authority, so it's never popped from the queue
+	body, err := babe.ExtrinsicsToBody(nil, []*transaction.ValidTransaction{provider})
+	require.NoError(t, err)
+
+	err = s.maintainTransactionPool(&types.Block{
+		Body: body,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, dependent, ts.Pop())
+	require.Nil(t, ts.Pop())
+}
+
+// encodeExtrinsic returns the SCALE encoding of the extrinsic, as it's submitted to the transaction pool
+func encodeExtrinsic(t *testing.T, ext []byte) types.Extrinsic {
+	enc, err := scale.Encode(ext)
+	require.NoError(t, err)
+	return enc
+}
+
 func TestService_GetRuntimeVersion(t *testing.T) {
 	s := NewTestService(t, nil)
 	rtExpected, err := s.rt.Version()
@@ -96,6 +96,10 @@ func (n *mockNetwork) SendMessage(m network.NotificationsMessage) {
 	n.Message = m
 }
 
+func (n *mockNetwork) Peers() []common.PeerInfo {
+	return nil
+}
+
 // NewTestService creates a new test core service
 func NewTestService(t *testing.T, cfg *Config) *Service {
 	if cfg == nil {
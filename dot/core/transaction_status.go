@@ -0,0 +1,127 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+)
+
+// finalityTimeout is the number of blocks after which the extrinsics included in a block that still isn't finalised
+// aren't watched anymore
+const finalityTimeout = 512
+
+// includedBlock holds the hashes of the extrinsics included in a block that isn't finalised yet
+type includedBlock struct {
+	number uint64
+	exts   []common.Hash
+}
+
+// notifyIncluded notifies that the extrinsics of the imported block were included in it, and watches them until the
+// block is finalised or retracted. The extrinsics of the blocks that weren't finalised within finalityTimeout blocks
+// aren't watched anymore.
+func (s *Service) notifyIncluded(block *types.Block) error {
+	if block.Header == nil || block.Body == nil {
+		return nil
+	}
+
+	// the extrinsics are watched in their encoded form, which is the form they are submitted in
+	exts, err := block.Body.AsEncodedExtrinsics()
+	if err != nil {
+		return err
+	}
+
+	hash := block.Header.Hash()
+	number := block.Header.Number.Uint64()
+
+	for h, ib := range s.included {
+		if ib.number+finalityTimeout <= number {
+			s.notifyExtrinsics(transaction.FinalityTimeout, h, ib.exts)
+			delete(s.included, h)
+		}
+	}
+
+	if len(exts) == 0 {
+		return nil
+	}
+
+	hashes := make([]common.Hash, len(exts))
+	for i, ext := range exts {
+		hashes[i] = ext.Hash()
+	}
+
+	s.included[hash] = &includedBlock{
+		number: number,
+		exts:   hashes,
+	}
+	s.notifyExtrinsics(transaction.InBlock, hash, hashes)
+	return nil
+}
+
+// notifyFinalised notifies that the extrinsics included in the finalised block and its unfinalised ancestors are
+// finalised. The blocks on other forks up to the finalised block number can't be finalised, so their extrinsics that
+// weren't finalised are notified of the finality timeout and aren't watched anymore.
+func (s *Service) notifyFinalised(header *types.Header) {
+	finalised := header.Hash()
+	number := header.Number.Uint64()
+
+	finalisedExts := make(map[common.Hash]struct{})
+	pruned := make(map[common.Hash]*includedBlock)
+
+	for hash, ib := range s.included {
+		if ib.number > number {
+			continue
+		}
+		delete(s.included, hash)
+
+		if hash != finalised {
+			isAncestor, err := s.blockState.IsDescendantOf(hash, finalised)
+			if err != nil || !isAncestor {
+				pruned[hash] = ib
+				continue
+			}
+		}
+
+		s.notifyExtrinsics(transaction.Finalized, hash, ib.exts)
+		for _, ext := range ib.exts {
+			finalisedExts[ext] = struct{}{}
+		}
+	}
+
+	for hash, ib := range pruned {
+		exts := []common.Hash{}
+		for _, ext := range ib.exts {
+			if _, has := finalisedExts[ext]; !has {
+				exts = append(exts, ext)
+			}
+		}
+
+		s.notifyExtrinsics(transaction.FinalityTimeout, hash, exts)
+	}
+}
+
+// notifyExtrinsics notifies the status of the extrinsics with the given hashes, which refers to the given block
+func (s *Service) notifyExtrinsics(status transaction.Status, block common.Hash, exts []common.Hash) {
+	for _, ext := range exts {
+		s.transactionState.NotifyStatus(&transaction.StatusNotification{
+			Hash:   ext,
+			Status: status,
+			Block:  block,
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_NotifyIncludedAndFinalised(t *testing.T) {
+	ts := state.NewTransactionState()
+	s := &Service{
+		transactionState: ts,
+		included:         make(map[common.Hash]*includedBlock),
+	}
+
+	body, err := types.NewBodyFromExtrinsics([]types.Extrinsic{{1, 2, 3}})
+	require.NoError(t, err)
+	exts, err := body.AsEncodedExtrinsics()
+	require.NoError(t, err)
+	hash := exts[0].Hash()
+
+	statusCh := make(chan *transaction.StatusNotification, 4)
+	ts.RegisterStatusChannel(hash, statusCh)
+
+	block1 := &types.Block{
+		Header: &types.Header{Number: big.NewInt(1)},
+		Body:   body,
+	}
+	block2 := &types.Block{
+		Header: &types.Header{Number: big.NewInt(2)},
+		Body:   body,
+	}
+
+	for _, block := range []*types.Block{block1, block2} {
+		err = s.notifyIncluded(block)
+		require.NoError(t, err)
+		require.Equal(t, &transaction.StatusNotification{
+			Hash:   hash,
+			Status: transaction.InBlock,
+			Block:  block.Header.Hash(),
+		}, <-statusCh)
+	}
+
+	s.notifyFinalised(block1.Header)
+	require.Equal(t, &transaction.StatusNotification{
+		Hash:   hash,
+		Status: transaction.Finalized,
+		Block:  block1.Header.Hash(),
+	}, <-statusCh)
+	require.Len(t, s.included, 1)
+
+	// block2 isn't finalised within finalityTimeout blocks
+	err = s.notifyIncluded(&types.Block{
+		Header: &types.Header{Number: big.NewInt(2 + finalityTimeout)},
+		Body:   types.NewBody([]byte{}),
+	})
+	require.NoError(t, err)
+	require.Equal(t, &transaction.StatusNotification{
+		Hash:   hash,
+		Status: transaction.FinalityTimeout,
+		Block:  block2.Header.Hash(),
+	}, <-statusCh)
+	require.Empty(t, s.included)
+}
+
+func TestService_NotifyFinalised_PrunedFork(t *testing.T) {
+	s := NewTestService(t, nil)
+	bs := s.blockState.(*state.BlockState)
+	state.AddBlocksToStateWithFixedBranches(t, bs, 3, map[int]int{1: 1}, 0)
+
+	leaves := bs.Leaves()
+	require.Len(t, leaves, 2)
+	finalised, err := bs.GetHeader(leaves[0])
+	require.NoError(t, err)
+	pruned, err := bs.GetHeader(leaves[1])
+	require.NoError(t, err)
+
+	// the fork that isn't finalised must be at or below the finalised block number
+	if pruned.Number.Cmp(finalised.Number) > 0 {
+		finalised, pruned = pruned, finalised
+	}
+
+	// the first extrinsic is included in both forks, the second only in the fork that isn't finalised
+	exts := []common.Hash{{1}, {2}}
+	s.included = map[common.Hash]*includedBlock{
+		finalised.Hash(): {number: finalised.Number.Uint64(), exts: exts[:1]},
+		pruned.Hash():    {number: pruned.Number.Uint64(), exts: exts},
+	}
+
+	ts := s.transactionState.(*state.TransactionState)
+	statusChs := make([]chan *transaction.StatusNotification, len(exts))
+	for i, ext := range exts {
+		statusChs[i] = make(chan *transaction.StatusNotification, 2)
+		ts.RegisterStatusChannel(ext, statusChs[i])
+	}
+
+	s.notifyFinalised(finalised)
+	require.Equal(t, &transaction.StatusNotification{
+		Hash:   exts[0],
+		Status: transaction.Finalized,
+		Block:  finalised.Hash(),
+	}, <-statusChs[0])
+	require.Equal(t, &transaction.StatusNotification{
+		Hash:   exts[1],
+		Status: transaction.FinalityTimeout,
+		Block:  pruned.Hash(),
+	}, <-statusChs[1])
+	require.Empty(t, s.included)
+
+	select {
+	case n := <-statusChs[0]:
+		t.Fatalf("unexpected notification %v", n)
+	case <-time.After(testMessageTimeout):
+	}
+}
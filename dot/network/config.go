@@ -67,6 +67,7 @@ type Config struct {
 
 	// Service interfaces
 	BlockState         BlockState
+	StorageState       StorageState
 	Syncer             Syncer
 	TransactionHandler TransactionHandler
 
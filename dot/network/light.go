@@ -1,11 +1,17 @@
 package network
 
 import (
+	"container/list"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math/big"
+	"sync"
 
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/common/optional"
 	"github.com/ChainSafe/gossamer/lib/scale"
+	"github.com/ChainSafe/gossamer/lib/trie"
 )
 
 // Pair is a pair of arbitrary bytes.
@@ -175,7 +181,7 @@ type RemoteReadResponse struct {
 // RemoteHeaderResponse ...
 type RemoteHeaderResponse struct {
 	Header []*optional.Header
-	proof  []byte
+	Proof  []byte
 }
 
 // RemoteChangesResponse ...
@@ -259,21 +265,216 @@ func (rr *RemoteReadResponse) String() string {
 
 // String formats a RemoteHeaderResponse as a string
 func (rh *RemoteHeaderResponse) String() string {
-	return fmt.Sprintf("Header =%s Proof =%s", rh.Header, string(rh.proof))
+	return fmt.Sprintf("Header =%s Proof =%s", rh.Header, string(rh.Proof))
 }
 
-func remoteCallResp(req *RemoteCallRequest) (*RemoteCallResponse, error) {
-	return &RemoteCallResponse{}, nil
+// chtSize is the number of blocks covered by a single canonical hash trie (CHT)
+const chtSize = 2048
+
+// maxCachedCHTs is the number of CHTs kept in memory, so that they aren't rebuilt for every remote header request
+const maxCachedCHTs = 4
+
+// chtCache holds the most recently used CHTs, keyed by their index. CHTs are only built for finalised blocks, so a
+// cached CHT never changes. Its zero value is an empty cache.
+type chtCache struct {
+	sync.Mutex
+	entries map[uint64]*list.Element
+	lru     list.List
+}
+
+type chtCacheEntry struct {
+	index uint64
+	cht   *trie.Trie
+}
+
+var errNoStorageState = errors.New("light request received but storage state is not set")
+
+func (s *Service) remoteCallResp(req *RemoteCallRequest) (*RemoteCallResponse, error) {
+	if s.callHandler == nil {
+		return nil, errors.New("light call request received but remote call handler is not set")
+	}
+
+	proof, err := s.callHandler.CallWithProof(common.BytesToHash(req.Block), req.Method, req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := scale.Encode(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteCallResponse{
+		Proof: enc,
+	}, nil
 }
-func remoteChangeResp(req *RemoteChangesRequest) (*RemoteChangesResponse, error) {
+
+// remoteChangeResp always returns an empty response, since changes tries are not supported.
+func (s *Service) remoteChangeResp(req *RemoteChangesRequest) (*RemoteChangesResponse, error) {
 	return &RemoteChangesResponse{}, nil
 }
-func remoteHeaderResp(req *RemoteHeaderRequest) (*RemoteHeaderResponse, error) {
-	return &RemoteHeaderResponse{}, nil
+
+func (s *Service) remoteHeaderResp(req *RemoteHeaderRequest) (*RemoteHeaderResponse, error) {
+	num, err := decodeBlockNumber(req.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := s.blockState.GetHashByNumber(num)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := s.blockState.GetHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := s.chtProof(num.Uint64())
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteHeaderResponse{
+		Header: []*optional.Header{header.AsOptional()},
+		Proof:  proof,
+	}, nil
 }
-func remoteReadChildResp(req *RemoteReadChildRequest) (*RemoteReadResponse, error) {
-	return &RemoteReadResponse{}, nil
+
+func (s *Service) remoteReadChildResp(req *RemoteReadChildRequest) (*RemoteReadResponse, error) {
+	if s.storageState == nil {
+		return nil, errNoStorageState
+	}
+
+	bhash := common.BytesToHash(req.Block)
+	stateRoot, err := s.storageState.GetStateRootFromBlock(&bhash)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := s.storageState.GenerateChildTrieProof(*stateRoot, req.StorageKey, req.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := scale.Encode(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteReadResponse{
+		Proof: enc,
+	}, nil
 }
-func remoteReadResp(req *RemoteReadRequest) (*RemoteReadResponse, error) {
-	return &RemoteReadResponse{}, nil
+
+func (s *Service) remoteReadResp(req *RemoteReadRequest) (*RemoteReadResponse, error) {
+	if s.storageState == nil {
+		return nil, errNoStorageState
+	}
+
+	bhash := common.BytesToHash(req.Block)
+	stateRoot, err := s.storageState.GetStateRootFromBlock(&bhash)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := s.storageState.GenerateTrieProof(*stateRoot, req.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := scale.Encode(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteReadResponse{
+		Proof: enc,
+	}, nil
+}
+
+// chtProof returns the SCALE-encoded proof of the given block's hash in the canonical hash trie (CHT) that covers it.
+// A CHT maps the encoded number of each block in a range of chtSize blocks to the block's hash. Since a CHT can only
+// be built once every block in its range is finalised, no proof is returned for blocks in the latest, incomplete range.
+func (s *Service) chtProof(num uint64) ([]byte, error) {
+	// the genesis block is not covered by any CHT
+	if num == 0 {
+		return nil, nil
+	}
+
+	finalised, err := s.blockState.GetFinalizedHeader(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	index := (num - 1) / chtSize
+	if (index+1)*chtSize > finalised.Number.Uint64() {
+		return nil, nil
+	}
+
+	// the lock is held while the proof is generated, as generating it isn't safe for concurrent use of the trie
+	s.chts.Lock()
+	defer s.chts.Unlock()
+
+	cht, err := s.getCHT(index)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := cht.GenerateProof([][]byte{encodeCHTKey(num)})
+	if err != nil {
+		return nil, err
+	}
+
+	return scale.Encode(proof)
+}
+
+// getCHT returns the CHT with the given index from the cache, or builds it if it isn't cached. The cache must be
+// locked by the caller.
+func (s *Service) getCHT(index uint64) (*trie.Trie, error) {
+	if e, has := s.chts.entries[index]; has {
+		s.chts.lru.MoveToFront(e)
+		return e.Value.(*chtCacheEntry).cht, nil
+	}
+
+	cht := trie.NewEmptyTrie()
+	start := index*chtSize + 1
+	for i := start; i < start+chtSize; i++ {
+		hash, err := s.blockState.GetHashByNumber(new(big.Int).SetUint64(i))
+		if err != nil {
+			return nil, err
+		}
+
+		cht.Put(encodeCHTKey(i), hash[:])
+	}
+
+	if s.chts.entries == nil {
+		s.chts.entries = make(map[uint64]*list.Element)
+	}
+
+	s.chts.entries[index] = s.chts.lru.PushFront(&chtCacheEntry{index: index, cht: cht})
+	if s.chts.lru.Len() > maxCachedCHTs {
+		oldest := s.chts.lru.Remove(s.chts.lru.Back()).(*chtCacheEntry)
+		delete(s.chts.entries, oldest.index)
+	}
+
+	return cht, nil
+}
+
+// encodeCHTKey returns the key of a block in a CHT, which is its SCALE-encoded 32-bit block number
+func encodeCHTKey(num uint64) []byte {
+	key := make([]byte, 4)
+	binary.LittleEndian.PutUint32(key, uint32(num))
+	return key
+}
+
+// decodeBlockNumber decodes a SCALE-encoded fixed-width block number, as sent in a RemoteHeaderRequest
+func decodeBlockNumber(in []byte) (*big.Int, error) {
+	if len(in) == 0 || len(in) > 8 {
+		return nil, fmt.Errorf("invalid block number encoding 0x%x", in)
+	}
+
+	buf := make([]byte, 8)
+	copy(buf, in)
+	return new(big.Int).SetUint64(binary.LittleEndian.Uint64(buf)), nil
 }
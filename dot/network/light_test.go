@@ -1,9 +1,13 @@
 package network
 
 import (
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/scale"
+	"github.com/ChainSafe/gossamer/lib/trie"
 	"github.com/ChainSafe/gossamer/lib/utils"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/stretchr/testify/require"
@@ -116,3 +120,140 @@ func TestHandleLightMessage_Response(t *testing.T) {
 	err = s.handleLightMsg(stream, msg)
 	require.Error(t, err, expectedErr, msg.String())
 }
+
+type mockStorageState struct {
+	proof [][]byte
+}
+
+func (m *mockStorageState) GetStateRootFromBlock(_ *common.Hash) (*common.Hash, error) {
+	return &common.Hash{}, nil
+}
+
+func (m *mockStorageState) GenerateTrieProof(_ common.Hash, _ [][]byte) ([][]byte, error) {
+	return m.proof, nil
+}
+
+func (m *mockStorageState) GenerateChildTrieProof(_ common.Hash, _ []byte, _ [][]byte) ([][]byte, error) {
+	return m.proof, nil
+}
+
+type mockCallHandler struct {
+	proof [][]byte
+}
+
+func (m *mockCallHandler) CallWithProof(_ common.Hash, _ string, _ []byte) ([][]byte, error) {
+	return m.proof, nil
+}
+
+func TestRemoteReadResp(t *testing.T) {
+	proof := [][]byte{{1, 2, 3}, {4, 5, 6}}
+	s := &Service{
+		storageState: &mockStorageState{proof: proof},
+		callHandler:  &mockCallHandler{proof: proof},
+	}
+
+	expected, err := scale.Encode(proof)
+	require.NoError(t, err)
+
+	readResp, err := s.remoteReadResp(&RemoteReadRequest{
+		Keys: [][]byte{{1}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, readResp.Proof)
+
+	readResp, err = s.remoteReadChildResp(&RemoteReadChildRequest{
+		StorageKey: []byte("child"),
+		Keys:       [][]byte{{1}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, readResp.Proof)
+
+	callResp, err := s.remoteCallResp(&RemoteCallRequest{
+		Method: "Core_version",
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, callResp.Proof)
+}
+
+func TestRemoteHeaderResp(t *testing.T) {
+	bs := newMockBlockState(big.NewInt(chtSize * 2))
+	s := &Service{
+		blockState: bs,
+	}
+
+	resp, err := s.remoteHeaderResp(&RemoteHeaderRequest{
+		Block: encodeCHTKey(7),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Header))
+	require.True(t, resp.Header[0].Exists())
+
+	dec, err := scale.Decode(resp.Proof, [][]byte{})
+	require.NoError(t, err)
+
+	// the mock block state returns the zero hash for every block number
+	cht := trie.NewEmptyTrie()
+	for i := uint64(1); i <= chtSize; i++ {
+		cht.Put(encodeCHTKey(i), common.Hash{}.ToBytes())
+	}
+
+	val, err := trie.VerifyProof(cht.MustHash(), encodeCHTKey(7), dec.([][]byte))
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}.ToBytes(), val)
+
+	// the CHT covering blocks past the finalised block is incomplete, so there is no proof
+	resp, err = s.remoteHeaderResp(&RemoteHeaderRequest{
+		Block: encodeCHTKey(chtSize*2 + 1),
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp.Proof)
+
+	_, err = s.remoteHeaderResp(&RemoteHeaderRequest{})
+	require.Error(t, err)
+}
+
+// hashLookupsBlockState counts the block hashes looked up by number
+type hashLookupsBlockState struct {
+	*MockBlockState
+	lookups int
+}
+
+func (bs *hashLookupsBlockState) GetHashByNumber(num *big.Int) (common.Hash, error) {
+	bs.lookups++
+	return bs.MockBlockState.GetHashByNumber(num)
+}
+
+func TestRemoteHeaderResp_CachedCHT(t *testing.T) {
+	bs := &hashLookupsBlockState{MockBlockState: newMockBlockState(big.NewInt(chtSize * (maxCachedCHTs + 1)))}
+	s := &Service{
+		blockState: bs,
+	}
+
+	req := &RemoteHeaderRequest{
+		Block: encodeCHTKey(7),
+	}
+
+	// the CHT is built for the first request, and the block's own hash is looked up for every request
+	resp, err := s.remoteHeaderResp(req)
+	require.NoError(t, err)
+	require.Equal(t, chtSize+1, bs.lookups)
+
+	cached, err := s.remoteHeaderResp(req)
+	require.NoError(t, err)
+	require.Equal(t, chtSize+2, bs.lookups)
+	require.Equal(t, resp.Proof, cached.Proof)
+
+	// once more CHTs are used than can be cached, the least recently used one is rebuilt
+	for i := uint64(1); i <= maxCachedCHTs; i++ {
+		_, err = s.remoteHeaderResp(&RemoteHeaderRequest{
+			Block: encodeCHTKey(i*chtSize + 1),
+		})
+		require.NoError(t, err)
+	}
+	require.Equal(t, maxCachedCHTs, s.chts.lru.Len())
+
+	bs.lookups = 0
+	_, err = s.remoteHeaderResp(req)
+	require.NoError(t, err)
+	require.Equal(t, chtSize+1, bs.lookups)
+}
@@ -80,11 +80,14 @@ type Service struct {
 
 	lightRequest   map[peer.ID]struct{} // set if we have sent a light request message to the given peer
 	lightRequestMu sync.RWMutex
+	chts           chtCache // CHTs used to respond to remote header requests
 
 	// Service interfaces
 	blockState         BlockState
+	storageState       StorageState
 	syncer             Syncer
 	transactionHandler TransactionHandler
+	callHandler        RemoteCallHandler
 
 	// Configuration options
 	noBootstrap bool
@@ -154,6 +157,7 @@ func NewService(cfg *Config) (*Service, error) {
 		mdns:                   newMDNS(host),
 		gossip:                 newGossip(),
 		blockState:             cfg.BlockState,
+		storageState:           cfg.StorageState,
 		transactionHandler:     cfg.TransactionHandler,
 		noBootstrap:            cfg.NoBootstrap,
 		noMDNS:                 cfg.NoMDNS,
@@ -180,6 +184,11 @@ func (s *Service) SetTransactionHandler(handler TransactionHandler) {
 	s.transactionHandler = handler
 }
 
+// SetRemoteCallHandler sets the RemoteCallHandler used by the light sub-protocol
+func (s *Service) SetRemoteCallHandler(handler RemoteCallHandler) {
+	s.callHandler = handler
+}
+
 // Start starts the network service
 func (s *Service) Start() error {
 	if s.syncer == nil {
@@ -589,15 +598,15 @@ func (s *Service) handleLightMsg(stream libp2pnetwork.Stream, msg Message) error
 	var err error
 	switch {
 	case lr.RmtCallRequest != nil:
-		resp.RmtCallResponse, err = remoteCallResp(lr.RmtCallRequest)
+		resp.RmtCallResponse, err = s.remoteCallResp(lr.RmtCallRequest)
 	case lr.RmtHeaderRequest != nil:
-		resp.RmtHeaderResponse, err = remoteHeaderResp(lr.RmtHeaderRequest)
+		resp.RmtHeaderResponse, err = s.remoteHeaderResp(lr.RmtHeaderRequest)
 	case lr.RmtChangesRequest != nil:
-		resp.RmtChangeResponse, err = remoteChangeResp(lr.RmtChangesRequest)
+		resp.RmtChangeResponse, err = s.remoteChangeResp(lr.RmtChangesRequest)
 	case lr.RmtReadRequest != nil:
-		resp.RmtReadResponse, err = remoteReadResp(lr.RmtReadRequest)
+		resp.RmtReadResponse, err = s.remoteReadResp(lr.RmtReadRequest)
 	case lr.RmtReadChildRequest != nil:
-		resp.RmtReadResponse, err = remoteReadChildResp(lr.RmtReadChildRequest)
+		resp.RmtReadResponse, err = s.remoteReadChildResp(lr.RmtReadChildRequest)
 	default:
 		logger.Warn("ignoring LightRequest without request data")
 		return nil
@@ -608,8 +617,7 @@ func (s *Service) handleLightMsg(stream libp2pnetwork.Stream, msg Message) error
 		return err
 	}
 
-	// TODO(arijit): Remove once we implement the internal APIs. Added to increase code coverage.
-	logger.Debug("LightResponse", "msg", resp.String())
+	logger.Trace("sending LightResponse", "peer", stream.Conn().RemotePeer(), "msg", resp.String())
 
 	err = s.host.writeToStream(stream, &resp)
 	if err != nil {
@@ -31,6 +31,14 @@ type BlockState interface {
 	HasBlockBody(common.Hash) (bool, error)
 	GetFinalizedHeader(round, setID uint64) (*types.Header, error)
 	GetHashByNumber(num *big.Int) (common.Hash, error)
+	GetHeader(common.Hash) (*types.Header, error)
+}
+
+// StorageState is the interface used by the light sub-protocol to generate storage proofs
+type StorageState interface {
+	GetStateRootFromBlock(bhash *common.Hash) (*common.Hash, error)
+	GenerateTrieProof(stateRoot common.Hash, keys [][]byte) ([][]byte, error)
+	GenerateChildTrieProof(stateRoot common.Hash, keyToChild []byte, keys [][]byte) ([][]byte, error)
 }
 
 // Syncer is implemented by the syncing service
@@ -57,3 +65,9 @@ type Syncer interface {
 type TransactionHandler interface {
 	HandleTransactionMessage(*TransactionMessage) error
 }
+
+// RemoteCallHandler is the interface used by the light sub-protocol to execute runtime calls
+type RemoteCallHandler interface {
+	// CallWithProof executes a runtime call at the given block and returns a proof of the storage read during the call
+	CallWithProof(bhash common.Hash, method string, data []byte) ([][]byte, error)
+}
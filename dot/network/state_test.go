@@ -79,3 +79,7 @@ func (mbs *MockBlockState) GetFinalizedHeader(_, _ uint64) (*types.Header, error
 func (mbs *MockBlockState) GetHashByNumber(_ *big.Int) (common.Hash, error) {
 	return common.Hash{}, nil
 }
+
+func (mbs *MockBlockState) GetHeader(_ common.Hash) (*types.Header, error) {
+	return mbs.BestBlockHeader()
+}
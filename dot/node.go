@@ -256,6 +256,9 @@ func NewNode(cfg *Config, ks *keystore.GlobalKeystore, stopFunc func()) (*Node,
 		return nil, err
 	}
 
+	// runtime instances for calls at the state of any block, shared by block import, RPC and offchain workers
+	runtimes := createRuntimeRegistry(cfg, stateSrvc, ks, networkSrvc, rt)
+
 	ver, err := createBlockVerifier(stateSrvc)
 	if err != nil {
 		return nil, err
@@ -283,7 +286,7 @@ func NewNode(cfg *Config, ks *keystore.GlobalKeystore, stopFunc func()) (*Node,
 	nodeSrvcs = append(nodeSrvcs, fg)
 
 	// Syncer
-	syncer, err := createSyncService(cfg, stateSrvc, bp, fg, dh, ver, rt)
+	syncer, err := createSyncService(cfg, stateSrvc, bp, fg, dh, ver, rt, runtimes)
 	if err != nil {
 		return nil, err
 	}
@@ -291,7 +294,7 @@ func NewNode(cfg *Config, ks *keystore.GlobalKeystore, stopFunc func()) (*Node,
 	// Core Service
 
 	// create core service and append core service to node services
-	coreSrvc, err := createCoreService(cfg, bp, ver, rt, ks, stateSrvc, networkSrvc)
+	coreSrvc, err := createCoreService(cfg, bp, ver, rt, runtimes, ks, stateSrvc, networkSrvc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create core service: %s", err)
 	}
@@ -300,6 +303,7 @@ func NewNode(cfg *Config, ks *keystore.GlobalKeystore, stopFunc func()) (*Node,
 	if networkSrvc != nil {
 		networkSrvc.SetSyncer(syncer)
 		networkSrvc.SetTransactionHandler(coreSrvc)
+		networkSrvc.SetRemoteCallHandler(coreSrvc)
 	}
 
 	// System Service
@@ -316,7 +320,7 @@ func NewNode(cfg *Config, ks *keystore.GlobalKeystore, stopFunc func()) (*Node,
 	// check if rpc service is enabled
 	if enabled := cfg.RPC.Enabled; enabled {
 		// create rpc service and append rpc service to node services
-		rpcSrvc := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, bp, rt, sysSrvc)
+		rpcSrvc := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, bp, rt, sysSrvc, ks.Babe)
 		nodeSrvcs = append(nodeSrvcs, rpcSrvc)
 	} else {
 		// do not create or append rpc service if rpc service is not enabled
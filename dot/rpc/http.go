@@ -22,9 +22,12 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/ChainSafe/gossamer/dot/rpc/json2"
 	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/dot/rpc/subscription"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/runtime"
 	log "github.com/ChainSafe/log15"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -50,6 +53,7 @@ type HTTPServerConfig struct {
 	BlockProducerAPI    modules.BlockProducerAPI
 	RuntimeAPI          modules.RuntimeAPI
 	TransactionQueueAPI modules.TransactionStateAPI
+	GrandpaStateAPI     modules.GrandpaStateAPI
 	RPCAPI              modules.RPCAPI
 	SystemAPI           modules.SystemAPI
 	External            bool
@@ -59,6 +63,9 @@ type HTTPServerConfig struct {
 	WSExternal          bool
 	WSPort              uint32
 	Modules             []string
+	RPCUnsafe           bool
+	NodeStorage         *runtime.NodeStorage
+	BabeKeystore        keystore.Keystore
 }
 
 var logger log.Logger
@@ -101,11 +108,37 @@ func (h *HTTPServer) RegisterModules(mods []string) {
 		case "grandpa":
 			srvc = modules.NewGrandpaModule(h.serverConfig.BlockAPI)
 		case "state":
-			srvc = modules.NewStateModule(h.serverConfig.NetworkAPI, h.serverConfig.StorageAPI, h.serverConfig.CoreAPI)
+			srvc = modules.NewStateModule(h.serverConfig.NetworkAPI, h.serverConfig.StorageAPI, h.serverConfig.CoreAPI, h.serverConfig.BlockAPI)
 		case "rpc":
 			srvc = modules.NewRPCModule(h.serverConfig.RPCAPI)
 		case "dev":
 			srvc = modules.NewDevModule(h.serverConfig.BlockProducerAPI, h.serverConfig.NetworkAPI)
+		case "payment":
+			srvc = modules.NewPaymentModule(h.serverConfig.CoreAPI)
+		case "babe":
+			// the babe module reveals the slots the node's keys can author blocks in, so it must be explicitly allowed
+			if !h.serverConfig.RPCUnsafe {
+				h.logger.Warn("Not enabling rpc module, unsafe rpc methods are disabled", "module", mod)
+				continue
+			}
+			srvc = modules.NewBabeModule(h.serverConfig.BlockProducerAPI, h.serverConfig.BabeKeystore)
+		case "contracts":
+			srvc = modules.NewContractsModule(h.serverConfig.CoreAPI)
+		case "engine":
+			// the engine module seals and finalises blocks on demand, so it must be explicitly allowed
+			if !h.serverConfig.RPCUnsafe {
+				h.logger.Warn("Not enabling rpc module, unsafe rpc methods are disabled", "module", mod)
+				continue
+			}
+			srvc = modules.NewEngineModule(h.serverConfig.BlockProducerAPI, h.serverConfig.BlockAPI,
+				h.serverConfig.TransactionQueueAPI, h.serverConfig.GrandpaStateAPI)
+		case "offchain":
+			// the offchain module gives access to the node's offchain storage, so it must be explicitly allowed
+			if !h.serverConfig.RPCUnsafe {
+				h.logger.Warn("Not enabling rpc module, unsafe rpc methods are disabled", "module", mod)
+				continue
+			}
+			srvc = modules.NewOffchainModule(h.serverConfig.NodeStorage)
 		default:
 			h.logger.Warn("Unrecognised module", "module", mod)
 			continue
@@ -131,7 +164,7 @@ func (h *HTTPServer) Start() error {
 
 	h.logger.Info("Starting HTTP Server...", "host", h.serverConfig.Host, "port", h.serverConfig.RPCPort)
 	r := mux.NewRouter()
-	r.Handle("/", h.rpcServer)
+	r.Handle("/", json2.NewBatchHandler(h.rpcServer))
 
 	validate := validator.New()
 	// Add custom validator for `common.Hash`
@@ -175,13 +208,15 @@ func (h *HTTPServer) Stop() error {
 	if h.serverConfig.WS {
 		// close all channels and websocket connections
 		for _, conn := range h.wsConns {
-			for _, sub := range conn.Subscriptions {
+			for _, sub := range conn.Listeners() {
 				switch v := sub.(type) {
 				case *subscription.StorageObserver:
 					h.serverConfig.StorageAPI.UnregisterStorageObserver(v)
 				case *subscription.BlockListener:
 					h.serverConfig.BlockAPI.UnregisterImportedChannel(v.ChanID)
 					close(v.Channel)
+				case *subscription.ExtrinsicSubmitListener:
+					v.Stop()
 				}
 			}
 
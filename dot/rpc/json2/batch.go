@@ -0,0 +1,175 @@
+// Copyright 2020 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package json2
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// IsBatch returns true if the given request body is a JSON-RPC batch, ie. a JSON array
+func IsBatch(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// BatchHandler wraps an http.Handler that serves single JSON-RPC requests so that it also serves batches.
+// Each request in a batch is passed to the wrapped handler in turn, and the responses are written as an array
+// in the same order. Notifications don't have a response, and if a batch only contains notifications,
+// nothing is written.
+type BatchHandler struct {
+	next http.Handler
+}
+
+// NewBatchHandler returns a BatchHandler wrapping the given handler
+func NewBatchHandler(next http.Handler) *BatchHandler {
+	return &BatchHandler{
+		next: next,
+	}
+}
+
+// ServeHTTP serves the request, handling it as a batch if its body is a JSON array
+func (h *BatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		writeErrorResponse(w, json2.E_PARSE, err.Error())
+		return
+	}
+
+	if !IsBatch(body) {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	var reqs []json.RawMessage
+	if err = json.Unmarshal(body, &reqs); err != nil {
+		writeErrorResponse(w, json2.E_PARSE, err.Error())
+		return
+	}
+
+	if len(reqs) == 0 {
+		writeErrorResponse(w, json2.E_INVALID_REQ, "empty batch")
+		return
+	}
+
+	responses := []json.RawMessage{}
+	for _, req := range reqs {
+		res, err := h.serveSingle(r, req)
+		if err != nil {
+			writeErrorResponse(w, json2.E_INTERNAL, err.Error())
+			return
+		}
+
+		if len(res) > 0 {
+			responses = append(responses, res)
+		}
+	}
+
+	// a batch of notifications has no response
+	if len(responses) == 0 {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(responses)
+}
+
+// serveSingle passes a single request from a batch to the wrapped handler and returns its response
+func (h *BatchHandler) serveSingle(r *http.Request, req json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(req)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return json.Marshal(&serverResponse{
+			Version: version,
+			Error: &json2.Error{
+				Code:    json2.E_INVALID_REQ,
+				Message: "invalid request",
+			},
+		})
+	}
+
+	single, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+
+	single.Header = r.Header.Clone()
+	single.Header.Del("Content-Length")
+	single.RemoteAddr = r.RemoteAddr
+	single.Host = r.Host
+
+	buf := newResponseBuffer()
+	h.next.ServeHTTP(buf, single)
+
+	res := bytes.TrimSpace(buf.body.Bytes())
+	if len(res) == 0 || json.Valid(res) {
+		return res, nil
+	}
+
+	// the wrapped handler wrote a plain text error, eg. for an unsupported content type
+	return json.Marshal(&serverResponse{
+		Version: version,
+		Error: &json2.Error{
+			Code:    json2.E_SERVER,
+			Message: string(res),
+		},
+	})
+}
+
+func writeErrorResponse(w http.ResponseWriter, code json2.ErrorCode, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(&serverResponse{
+		Version: version,
+		Error: &json2.Error{
+			Code:    code,
+			Message: msg,
+		},
+	})
+}
+
+// responseBuffer is an http.ResponseWriter that keeps the response of a single request in a batch in memory
+type responseBuffer struct {
+	header http.Header
+	body   *bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{
+		header: make(http.Header),
+		body:   new(bytes.Buffer),
+	}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) WriteHeader(int) {}
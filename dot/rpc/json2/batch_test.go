@@ -0,0 +1,110 @@
+// Copyright 2020 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package json2
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/rpc/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type EchoRequest struct {
+	Message string
+}
+
+type testService struct{}
+
+func (s *testService) Echo(r *http.Request, req *EchoRequest, res *string) error {
+	if req.Message == "" {
+		return errors.New("empty message")
+	}
+
+	*res = req.Message
+	return nil
+}
+
+func newTestBatchHandler(t *testing.T) *BatchHandler {
+	server := rpc.NewServer()
+	server.RegisterCodec(NewCodec(), "application/json")
+	err := server.RegisterService(new(testService), "test")
+	require.NoError(t, err)
+	return NewBatchHandler(server)
+}
+
+func serveTestRequest(h http.Handler, body string) string {
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Body.String()
+}
+
+func TestBatchHandler_Single(t *testing.T) {
+	h := newTestBatchHandler(t)
+
+	res := serveTestRequest(h, `{"jsonrpc":"2.0","method":"test.Echo","params":["noot"],"id":1}`)
+	require.Equal(t, `{"jsonrpc":"2.0","result":"noot","id":1}`+"\n", res)
+}
+
+func TestBatchHandler_Batch(t *testing.T) {
+	h := newTestBatchHandler(t)
+
+	testCases := []struct {
+		description string
+		body        string
+		expected    string
+	}{
+		{
+			description: "ordered responses",
+			body:        `[{"jsonrpc":"2.0","method":"test.Echo","params":["a"],"id":1},{"jsonrpc":"2.0","method":"test.Echo","params":["b"],"id":"2"}]`,
+			expected:    `[{"jsonrpc":"2.0","result":"a","id":1},{"jsonrpc":"2.0","result":"b","id":"2"}]` + "\n",
+		},
+		{
+			description: "per-entry errors",
+			body:        `[{"jsonrpc":"2.0","method":"test.Echo","params":[""],"id":1},1,{"jsonrpc":"2.0","method":"test.Echo","params":["b"],"id":2}]`,
+			expected:    `[{"jsonrpc":"2.0","error":{"code":-32000,"message":"empty message","data":null},"id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request","data":null},"id":null},{"jsonrpc":"2.0","result":"b","id":2}]` + "\n",
+		},
+		{
+			description: "notifications have no response",
+			body:        `[{"jsonrpc":"2.0","method":"test.Echo","params":["a"]},{"jsonrpc":"2.0","method":"test.Echo","params":["b"],"id":2}]`,
+			expected:    `[{"jsonrpc":"2.0","result":"b","id":2}]` + "\n",
+		},
+		{
+			description: "batch of notifications",
+			body:        `[{"jsonrpc":"2.0","method":"test.Echo","params":["a"]}]`,
+			expected:    "",
+		},
+		{
+			description: "empty batch",
+			body:        `[]`,
+			expected:    `{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch","data":null},"id":null}` + "\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			res := serveTestRequest(h, test.body)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
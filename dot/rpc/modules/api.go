@@ -7,6 +7,7 @@ import (
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	"github.com/ChainSafe/gossamer/lib/transaction"
 )
@@ -18,6 +19,7 @@ type StorageAPI interface {
 	Entries(root *common.Hash) (map[string][]byte, error)
 	GetStateRootFromBlock(bhash *common.Hash) (*common.Hash, error)
 	GetKeysWithPrefix(root *common.Hash, prefix []byte) ([][]byte, error)
+	GenerateTrieProof(stateRoot common.Hash, keys [][]byte) ([][]byte, error)
 	RegisterStorageObserver(observer state.Observer)
 	UnregisterStorageObserver(observer state.Observer)
 }
@@ -36,6 +38,14 @@ type BlockAPI interface {
 	RegisterFinalizedChannel(ch chan<- *types.FinalisationInfo) (byte, error)
 	UnregisterFinalizedChannel(id byte)
 	SubChain(start, end common.Hash) ([]common.Hash, error)
+	SetFinalizedHash(hash common.Hash, round, setID uint64) error
+	GetRound() (uint64, error)
+	IsDescendantOf(parent, child common.Hash) (bool, error)
+}
+
+// GrandpaStateAPI is the interface for the grandpa state
+type GrandpaStateAPI interface {
+	GetCurrentSetID() (uint64, error)
 }
 
 // NetworkAPI interface for network state methods
@@ -55,6 +65,8 @@ type BlockProducerAPI interface {
 	Resume() error
 	EpochLength() uint64
 	SlotDuration() uint64
+	CreateBlock(parent *common.Hash) (*types.Block, error)
+	EpochAuthorship(keypairs []*sr25519.Keypair) (map[common.Address]*types.EpochAuthorship, error)
 }
 
 // TransactionStateAPI ...
@@ -63,6 +75,8 @@ type TransactionStateAPI interface {
 	Pop() *transaction.ValidTransaction
 	Peek() *transaction.ValidTransaction
 	Pending() []*transaction.ValidTransaction
+	RegisterStatusChannel(hash common.Hash, ch chan<- *transaction.StatusNotification) uint64
+	UnregisterStatusChannel(id uint64)
 }
 
 // CoreAPI is the interface for the core methods
@@ -73,6 +87,7 @@ type CoreAPI interface {
 	IsBlockProducer() bool
 	HandleSubmittedExtrinsic(types.Extrinsic) error
 	GetMetadata(bhash *common.Hash) ([]byte, error)
+	CallRuntime(bhash *common.Hash, method string, data []byte) ([]byte, error)
 }
 
 // RPCAPI is the interface for methods related to RPC service
@@ -301,3 +301,7 @@ func setupAuthModule(t *testing.T, txq *state.TransactionState) *AuthorModule {
 type mockNetwork struct{}
 
 func (n *mockNetwork) SendMessage(_ network.NotificationsMessage) {}
+
+func (n *mockNetwork) Peers() []common.PeerInfo {
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+)
+
+// EpochAuthorshipResponse maps the address of each of our BABE authority keys to the slots it can author in the current epoch
+type EpochAuthorshipResponse map[common.Address]*types.EpochAuthorship
+
+// BabeModule is an RPC module providing access to BABE related data
+type BabeModule struct {
+	blockProducerAPI BlockProducerAPI
+	keystore         keystore.Keystore
+}
+
+// NewBabeModule creates a new BABE module.
+func NewBabeModule(bp BlockProducerAPI, ks keystore.Keystore) *BabeModule {
+	return &BabeModule{
+		blockProducerAPI: bp,
+		keystore:         ks,
+	}
+}
+
+// EpochAuthorship returns the primary, secondary and secondary VRF slots that each of the keys in our BABE keystore
+// can author in the current epoch
+func (m *BabeModule) EpochAuthorship(r *http.Request, req *EmptyRequest, res *EpochAuthorshipResponse) error {
+	if m.blockProducerAPI == nil {
+		return errors.New("not a block producer")
+	}
+
+	var kps []*sr25519.Keypair
+	if m.keystore != nil {
+		for _, kp := range m.keystore.Keypairs() {
+			if skp, ok := kp.(*sr25519.Keypair); ok {
+				kps = append(kps, skp)
+			}
+		}
+	}
+
+	authorship, err := m.blockProducerAPI.EpochAuthorship(kps)
+	if err != nil {
+		return err
+	}
+
+	*res = authorship
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBabeModule_EpochAuthorship(t *testing.T) {
+	kr, err := keystore.NewSr25519Keyring()
+	require.NoError(t, err)
+
+	ks := keystore.NewBasicKeystore("babe", crypto.Sr25519Type)
+	ks.Insert(kr.Alice())
+
+	m := NewBabeModule(&mockBlockProducer{}, ks)
+
+	var res EpochAuthorshipResponse
+	err = m.EpochAuthorship(nil, &EmptyRequest{}, &res)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, []uint64{0}, res[kr.Alice().Public().Address()].Primary)
+}
+
+func TestBabeModule_EpochAuthorship_NotBlockProducer(t *testing.T) {
+	m := NewBabeModule(nil, nil)
+
+	var res EpochAuthorshipResponse
+	err := m.EpochAuthorship(nil, &EmptyRequest{}, &res)
+	require.Error(t, err)
+}
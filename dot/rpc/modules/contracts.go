@@ -0,0 +1,148 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/scale"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+var (
+	// ErrContractDoesntExist is returned when there is no contract at the given address
+	ErrContractDoesntExist = errors.New("contract doesn't exist")
+	// ErrContractIsTombstone is returned when the contract at the given address has been evicted
+	ErrContractIsTombstone = errors.New("contract is a tombstone")
+)
+
+// ContractsGetStorageRequest represents the request to get a value from a contract's storage
+type ContractsGetStorageRequest struct {
+	// ss58 or hex encoded contract account id
+	Address string `validate:"required"`
+	// hex encoded 32 byte storage key
+	Key string `validate:"required"`
+	// hex optional block hash indicating the state
+	Block *common.Hash
+}
+
+// ContractsGetStorageResponse is the hex encoded value stored at a contract's storage key, or nil if there is none
+type ContractsGetStorageResponse interface{}
+
+// ContractsModule is an RPC module providing access to the contracts pallet
+type ContractsModule struct {
+	coreAPI CoreAPI
+}
+
+// NewContractsModule creates a new contracts module.
+func NewContractsModule(coreAPI CoreAPI) *ContractsModule {
+	return &ContractsModule{
+		coreAPI: coreAPI,
+	}
+}
+
+// GetStorage returns the value under the given key in the storage of the contract at the given address. The
+// storage lives in the contract's child trie, which the runtime resolves using ContractsApi_get_storage.
+func (m *ContractsModule) GetStorage(r *http.Request, req *ContractsGetStorageRequest, res *ContractsGetStorageResponse) error {
+	address, err := decodeAccountID(req.Address)
+	if err != nil {
+		return err
+	}
+
+	key, err := common.HexToBytes(req.Key)
+	if err != nil {
+		return err
+	}
+
+	if len(key) != 32 {
+		return fmt.Errorf("invalid storage key length: %d", len(key))
+	}
+
+	ret, err := m.coreAPI.CallRuntime(req.Block, runtime.ContractsAPIGetStorage, append(address, key...))
+	if err != nil {
+		return err
+	}
+
+	val, err := decodeGetStorageResult(ret)
+	if err != nil {
+		return err
+	}
+
+	if val != nil {
+		*res = common.BytesToHex(val)
+	}
+	return nil
+}
+
+// decodeAccountID decodes an ss58 or hex encoded account id
+func decodeAccountID(address string) ([]byte, error) {
+	if strings.HasPrefix(address, "0x") {
+		id, err := common.HexToBytes(address)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(id) != 32 {
+			return nil, fmt.Errorf("invalid account id length: %d", len(id))
+		}
+		return id, nil
+	}
+
+	// an ss58 address consists of the address type, the 32 byte account id and a 2 byte checksum
+	if len(base58.Decode(address)) != 35 {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+
+	return crypto.PublicAddressToByteArray(common.Address(address)), nil
+}
+
+// decodeGetStorageResult decodes the SCALE encoded Result<Option<Vec<u8>>, ContractAccessError> returned by
+// ContractsApi_get_storage
+func decodeGetStorageResult(in []byte) ([]byte, error) {
+	if len(in) < 2 {
+		return nil, errors.New("invalid get storage result")
+	}
+
+	if in[0] == 1 {
+		switch in[1] {
+		case 0:
+			return nil, ErrContractDoesntExist
+		case 1:
+			return nil, ErrContractIsTombstone
+		default:
+			return nil, fmt.Errorf("invalid contract access error: %d", in[1])
+		}
+	}
+
+	if in[1] == 0 {
+		return nil, nil
+	}
+
+	val, err := scale.Decode(in[2:], []byte{})
+	if err != nil {
+		return nil, err
+	}
+
+	return val.([]byte), nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/keystore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeAccountID(t *testing.T) {
+	kr, err := keystore.NewSr25519Keyring()
+	require.NoError(t, err)
+
+	pub := kr.Alice().Public()
+
+	id, err := decodeAccountID(string(pub.Address()))
+	require.NoError(t, err)
+	require.Equal(t, pub.Encode(), id)
+
+	id, err = decodeAccountID(pub.Hex())
+	require.NoError(t, err)
+	require.Equal(t, pub.Encode(), id)
+
+	_, err = decodeAccountID("0x0102")
+	require.Error(t, err)
+
+	_, err = decodeAccountID("5Grw")
+	require.Error(t, err)
+}
+
+func TestDecodeGetStorageResult(t *testing.T) {
+	testCases := []struct {
+		description string
+		enc         []byte
+		expected    []byte
+		err         error
+	}{
+		{
+			description: "value",
+			enc:         []byte{0, 1, 8, 0xab, 0xcd},
+			expected:    []byte{0xab, 0xcd},
+		},
+		{
+			description: "no value",
+			enc:         []byte{0, 0},
+		},
+		{
+			description: "contract doesn't exist",
+			enc:         []byte{1, 0},
+			err:         ErrContractDoesntExist,
+		},
+		{
+			description: "contract is tombstone",
+			enc:         []byte{1, 1},
+			err:         ErrContractIsTombstone,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			val, err := decodeGetStorageResult(test.enc)
+			require.Equal(t, test.err, err)
+			require.Equal(t, test.expected, val)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ChainSafe/gossamer/lib/blocktree"
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// ErrEmptyTransactionPool is returned when creating a block that isn't allowed to be empty, but there are no transactions
+var ErrEmptyTransactionPool = errors.New("transaction pool is empty, cannot create empty block")
+
+// ErrNotFinalizedDescendant is returned when finalizing a block that doesn't descend from the latest finalised block
+var ErrNotFinalizedDescendant = errors.New("block is not a descendant of the latest finalised block")
+
+// EngineCreateBlockRequest represents the request format for engine_createBlock
+type EngineCreateBlockRequest struct {
+	CreateEmpty bool
+	Finalize    bool
+	ParentHash  *common.Hash
+}
+
+// EngineFinalizeBlockRequest represents the request format for engine_finalizeBlock
+type EngineFinalizeBlockRequest struct {
+	Hash          common.Hash `validate:"required"`
+	Justification *string
+}
+
+// EngineImportedAux contains information about a block that was created by engine_createBlock
+type EngineImportedAux struct {
+	HeaderOnly                 bool `json:"header_only"`
+	ClearJustificationRequests bool `json:"clear_justification_requests"`
+	NeedsJustification         bool `json:"needs_justification"`
+	BadJustification           bool `json:"bad_justification"`
+	IsNewBest                  bool `json:"is_new_best"`
+}
+
+// EngineCreateBlockResponse represents the response format for engine_createBlock
+type EngineCreateBlockResponse struct {
+	Hash common.Hash       `json:"hash"`
+	Aux  EngineImportedAux `json:"aux"`
+}
+
+// EngineModule is an RPC module that creates and finalizes blocks on demand, ie. manual sealing
+type EngineModule struct {
+	blockProducerAPI BlockProducerAPI
+	blockAPI         BlockAPI
+	txStateAPI       TransactionStateAPI
+	grandpaStateAPI  GrandpaStateAPI
+}
+
+// NewEngineModule creates a new Engine module.
+func NewEngineModule(bp BlockProducerAPI, blockAPI BlockAPI, txStateAPI TransactionStateAPI,
+	grandpaStateAPI GrandpaStateAPI) *EngineModule {
+	return &EngineModule{
+		blockProducerAPI: bp,
+		blockAPI:         blockAPI,
+		txStateAPI:       txStateAPI,
+		grandpaStateAPI:  grandpaStateAPI,
+	}
+}
+
+// CreateBlock builds a block on top of the given parent, or the best block if no parent is given, and imports it.
+// The block includes the inherents and any transactions in the transaction queue.
+func (m *EngineModule) CreateBlock(r *http.Request, req *EngineCreateBlockRequest, res *EngineCreateBlockResponse) error {
+	if m.blockProducerAPI == nil {
+		return errors.New("not a block producer")
+	}
+
+	if !req.CreateEmpty && (m.txStateAPI == nil || m.txStateAPI.Peek() == nil) {
+		return ErrEmptyTransactionPool
+	}
+
+	block, err := m.blockProducerAPI.CreateBlock(req.ParentHash)
+	if err != nil {
+		return fmt.Errorf("failed to create block: %w", err)
+	}
+
+	hash := block.Header.Hash()
+	if req.Finalize {
+		err = m.finalize(hash)
+		if err != nil {
+			return fmt.Errorf("failed to finalize block: %w", err)
+		}
+	}
+
+	*res = EngineCreateBlockResponse{
+		Hash: hash,
+		Aux: EngineImportedAux{
+			IsNewBest: m.blockAPI.BestBlockHash() == hash,
+		},
+	}
+	return nil
+}
+
+// FinalizeBlock finalizes the block with the given hash. The justification is ignored, as it isn't needed to
+// finalize a block manually.
+func (m *EngineModule) FinalizeBlock(r *http.Request, req *EngineFinalizeBlockRequest, res *bool) error {
+	if _, err := m.blockAPI.GetHeader(req.Hash); err != nil {
+		return fmt.Errorf("cannot find block %s: %w", req.Hash, err)
+	}
+
+	err := m.finalize(req.Hash)
+	if err != nil {
+		return err
+	}
+
+	*res = true
+	return nil
+}
+
+// finalize sets the given block as the latest finalised block, and as the finalised block of the current GRANDPA
+// round and set. The block must descend from the latest finalised block.
+func (m *EngineModule) finalize(hash common.Hash) error {
+	finalized, err := m.blockAPI.GetFinalizedHash(0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get latest finalised block: %w", err)
+	}
+
+	// blocks that were finalised or pruned are no longer in the block tree
+	isDescendant, err := m.blockAPI.IsDescendantOf(finalized, hash)
+	if err != nil && err != blocktree.ErrEndNodeNotFound {
+		return err
+	}
+
+	if !isDescendant {
+		return fmt.Errorf("%w: %s", ErrNotFinalizedDescendant, hash)
+	}
+
+	round, err := m.blockAPI.GetRound()
+	if err != nil {
+		return fmt.Errorf("failed to get current round: %w", err)
+	}
+
+	var setID uint64
+	if m.grandpaStateAPI != nil {
+		setID, err = m.grandpaStateAPI.GetCurrentSetID()
+		if err != nil {
+			return fmt.Errorf("failed to get current set ID: %w", err)
+		}
+	}
+
+	err = m.blockAPI.SetFinalizedHash(hash, round, setID)
+	if err != nil {
+		return err
+	}
+
+	if round == 0 && setID == 0 {
+		return nil
+	}
+
+	// set latest finalised head
+	return m.blockAPI.SetFinalizedHash(hash, 0, 0)
+}
@@ -0,0 +1,182 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/state"
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockBlockProducer struct {
+	blockState *state.BlockState
+}
+
+func (bp *mockBlockProducer) Pause() error         { return nil }
+func (bp *mockBlockProducer) Resume() error        { return nil }
+func (bp *mockBlockProducer) EpochLength() uint64  { return 0 }
+func (bp *mockBlockProducer) SlotDuration() uint64 { return 0 }
+
+func (bp *mockBlockProducer) EpochAuthorship(kps []*sr25519.Keypair) (map[common.Address]*types.EpochAuthorship, error) {
+	res := make(map[common.Address]*types.EpochAuthorship)
+	for i, kp := range kps {
+		res[kp.Public().Address()] = &types.EpochAuthorship{
+			Primary: []uint64{uint64(i)},
+		}
+	}
+	return res, nil
+}
+
+func (bp *mockBlockProducer) CreateBlock(parentHash *common.Hash) (*types.Block, error) {
+	parent, err := bp.blockState.BestBlockHeader()
+	if parentHash != nil {
+		parent, err = bp.blockState.GetHeader(*parentHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block := &types.Block{
+		Header: &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     big.NewInt(0).Add(parent.Number, big.NewInt(1)),
+			Digest:     types.Digest{},
+		},
+		Body: types.NewBody([]byte{}),
+	}
+
+	return block, bp.blockState.AddBlock(block)
+}
+
+func newEngineModule(t *testing.T) (*EngineModule, *state.Service) {
+	chain := newTestStateService(t)
+	bp := &mockBlockProducer{blockState: chain.Block}
+	return NewEngineModule(bp, chain.Block, chain.Transaction, chain.Grandpa), chain
+}
+
+func TestEngineModule_CreateBlock(t *testing.T) {
+	m, chain := newEngineModule(t)
+	genesisHash := genesisHeader.Hash()
+
+	var res EngineCreateBlockResponse
+	err := m.CreateBlock(nil, &EngineCreateBlockRequest{CreateEmpty: true}, &res)
+	require.NoError(t, err)
+	require.True(t, res.Aux.IsNewBest)
+	require.Equal(t, chain.Block.BestBlockHash(), res.Hash)
+
+	finalized, err := chain.Block.GetFinalizedHash(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, genesisHash, finalized)
+
+	// build a fork on top of genesis and finalize it
+	err = m.CreateBlock(nil, &EngineCreateBlockRequest{CreateEmpty: true, Finalize: true, ParentHash: &genesisHash}, &res)
+	require.NoError(t, err)
+
+	header, err := chain.Block.GetHeader(res.Hash)
+	require.NoError(t, err)
+	require.Equal(t, genesisHash, header.ParentHash)
+
+	finalized, err = chain.Block.GetFinalizedHash(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, res.Hash, finalized)
+}
+
+func TestEngineModule_CreateBlock_EmptyPool(t *testing.T) {
+	m, chain := newEngineModule(t)
+
+	var res EngineCreateBlockResponse
+	err := m.CreateBlock(nil, &EngineCreateBlockRequest{}, &res)
+	require.Equal(t, ErrEmptyTransactionPool, err)
+
+	vtx := transaction.NewValidTransaction(types.NewExtrinsic([]byte{1, 2, 3}), transaction.NewValidity(1, [][]byte{}, [][]byte{{1}}, 1, false))
+	_, err = chain.Transaction.Push(vtx)
+	require.NoError(t, err)
+
+	err = m.CreateBlock(nil, &EngineCreateBlockRequest{}, &res)
+	require.NoError(t, err)
+}
+
+func TestEngineModule_FinalizeBlock(t *testing.T) {
+	m, chain := newEngineModule(t)
+
+	var created EngineCreateBlockResponse
+	err := m.CreateBlock(nil, &EngineCreateBlockRequest{CreateEmpty: true}, &created)
+	require.NoError(t, err)
+
+	var res bool
+	err = m.FinalizeBlock(nil, &EngineFinalizeBlockRequest{Hash: created.Hash}, &res)
+	require.NoError(t, err)
+	require.True(t, res)
+
+	finalized, err := chain.Block.GetFinalizedHash(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, created.Hash, finalized)
+
+	err = m.FinalizeBlock(nil, &EngineFinalizeBlockRequest{Hash: common.Hash{0xff}}, &res)
+	require.Error(t, err)
+}
+
+func TestEngineModule_FinalizeBlock_NotDescendant(t *testing.T) {
+	m, chain := newEngineModule(t)
+
+	var parent, child EngineCreateBlockResponse
+	err := m.CreateBlock(nil, &EngineCreateBlockRequest{CreateEmpty: true}, &parent)
+	require.NoError(t, err)
+	err = m.CreateBlock(nil, &EngineCreateBlockRequest{CreateEmpty: true, Finalize: true}, &child)
+	require.NoError(t, err)
+
+	var res bool
+	err = m.FinalizeBlock(nil, &EngineFinalizeBlockRequest{Hash: parent.Hash}, &res)
+	require.True(t, errors.Is(err, ErrNotFinalizedDescendant))
+	require.False(t, res)
+
+	finalized, err := chain.Block.GetFinalizedHash(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, child.Hash, finalized)
+}
+
+func TestEngineModule_FinalizeBlock_CurrentRound(t *testing.T) {
+	m, chain := newEngineModule(t)
+
+	err := chain.Block.SetRound(3)
+	require.NoError(t, err)
+
+	var created EngineCreateBlockResponse
+	err = m.CreateBlock(nil, &EngineCreateBlockRequest{CreateEmpty: true}, &created)
+	require.NoError(t, err)
+
+	var res bool
+	err = m.FinalizeBlock(nil, &EngineFinalizeBlockRequest{Hash: created.Hash}, &res)
+	require.NoError(t, err)
+
+	setID, err := chain.Grandpa.GetCurrentSetID()
+	require.NoError(t, err)
+
+	for _, round := range []uint64{0, 3} {
+		finalized, err := chain.Block.GetFinalizedHash(round, setID)
+		require.NoError(t, err)
+		require.Equal(t, created.Hash, finalized)
+	}
+}
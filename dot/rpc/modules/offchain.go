@@ -0,0 +1,121 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+
+	"github.com/ChainSafe/chaindb"
+)
+
+const (
+	offchainPersistent = "PERSISTENT"
+	offchainLocal      = "LOCAL"
+)
+
+// OffchainLocalStorageGet represents the request format to retrieve data from offchain storage
+type OffchainLocalStorageGet struct {
+	Kind string
+	Key  string
+}
+
+// OffchainLocalStorageSet represents the request format to store data into offchain storage
+type OffchainLocalStorageSet struct {
+	Kind  string
+	Key   string
+	Value string
+}
+
+// OffchainLocalStorageResponse is the hex encoded value stored at a key, or nil if there is none
+type OffchainLocalStorageResponse interface{}
+
+// OffchainModule defines the RPC module for Offchain methods
+type OffchainModule struct {
+	nodeStorage *runtime.NodeStorage
+}
+
+// NewOffchainModule creates a RPC module for Offchain methods
+func NewOffchainModule(ns *runtime.NodeStorage) *OffchainModule {
+	return &OffchainModule{
+		nodeStorage: ns,
+	}
+}
+
+// LocalStorageGet gets an offchain local storage value for the given storage kind and key
+func (s *OffchainModule) LocalStorageGet(r *http.Request, req *OffchainLocalStorageGet, res *OffchainLocalStorageResponse) error {
+	db, err := s.storage(req.Kind)
+	if err != nil {
+		return err
+	}
+
+	key, err := common.HexToBytes(req.Key)
+	if err != nil {
+		return err
+	}
+
+	val, err := db.Get(key)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	*res = common.BytesToHex(val)
+	return nil
+}
+
+// LocalStorageSet sets an offchain local storage value for the given storage kind and key
+func (s *OffchainModule) LocalStorageSet(r *http.Request, req *OffchainLocalStorageSet, res *OffchainLocalStorageResponse) error {
+	db, err := s.storage(req.Kind)
+	if err != nil {
+		return err
+	}
+
+	key, err := common.HexToBytes(req.Key)
+	if err != nil {
+		return err
+	}
+
+	val, err := common.HexToBytes(req.Value)
+	if err != nil {
+		return err
+	}
+
+	return db.Put(key, val)
+}
+
+// storage returns the offchain storage of the given kind, which is the same storage used by the runtime
+func (s *OffchainModule) storage(kind string) (runtime.BasicStorage, error) {
+	if s.nodeStorage == nil {
+		return nil, errors.New("offchain storage not available")
+	}
+
+	switch kind {
+	case offchainPersistent:
+		return s.nodeStorage.PersistentStorage, nil
+	case offchainLocal:
+		return s.nodeStorage.LocalStorage, nil
+	default:
+		return nil, fmt.Errorf("storage kind not found: %s", kind)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffchainModule_LocalStorage(t *testing.T) {
+	ns := &runtime.NodeStorage{
+		LocalStorage:      runtime.NewInMemoryDB(t),
+		PersistentStorage: runtime.NewInMemoryDB(t),
+	}
+	m := NewOffchainModule(ns)
+
+	key := common.BytesToHex([]byte("noot"))
+	value := common.BytesToHex([]byte("was here"))
+
+	for _, kind := range []string{offchainPersistent, offchainLocal} {
+		var res OffchainLocalStorageResponse
+		err := m.LocalStorageGet(nil, &OffchainLocalStorageGet{Kind: kind, Key: key}, &res)
+		require.NoError(t, err)
+		require.Nil(t, res)
+
+		err = m.LocalStorageSet(nil, &OffchainLocalStorageSet{Kind: kind, Key: key, Value: value}, &res)
+		require.NoError(t, err)
+
+		err = m.LocalStorageGet(nil, &OffchainLocalStorageGet{Kind: kind, Key: key}, &res)
+		require.NoError(t, err)
+		require.Equal(t, value, res)
+	}
+
+	// the value is stored in the same storage the runtime uses
+	val, err := ns.PersistentStorage.Get([]byte("noot"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("was here"), val)
+
+	err = m.LocalStorageSet(nil, &OffchainLocalStorageSet{Kind: "invalid", Key: key, Value: value}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/scale"
+)
+
+// dispatchClasses are the names of the runtime's DispatchClass variants, in order
+var dispatchClasses = []string{"normal", "operational", "mandatory"}
+
+// PaymentQueryInfoRequest represents the request to get the fee of an extrinsic in a given block
+type PaymentQueryInfoRequest struct {
+	// hex SCALE encoded extrinsic
+	Ext string `validate:"required"`
+	// hex optional block hash indicating the state
+	Hash *common.Hash
+}
+
+// PaymentQueryInfoResponse holds the dispatch info and fee of an extrinsic
+type PaymentQueryInfoResponse struct {
+	Weight     uint64 `json:"weight"`
+	Class      string `json:"class"`
+	PartialFee string `json:"partialFee"`
+}
+
+// PaymentModule holds all the RPC implementation of polkadot payment rpc api
+type PaymentModule struct {
+	coreAPI CoreAPI
+}
+
+// NewPaymentModule returns a pointer to PaymentModule
+func NewPaymentModule(coreAPI CoreAPI) *PaymentModule {
+	return &PaymentModule{
+		coreAPI: coreAPI,
+	}
+}
+
+// QueryInfo returns the weight, dispatch class and fee of the given extrinsic at the given block,
+// or at the best block if no block is given
+func (p *PaymentModule) QueryInfo(_ *http.Request, req *PaymentQueryInfoRequest, res *PaymentQueryInfoResponse) error {
+	ext, err := common.HexToBytes(req.Ext)
+	if err != nil {
+		return err
+	}
+
+	// the extrinsic is a SCALE encoded byte array
+	if _, err = scale.Decode(ext, []byte{}); err != nil {
+		return fmt.Errorf("cannot decode extrinsic: %w", err)
+	}
+
+	// TransactionPaymentApi_query_info takes the extrinsic and its encoded length
+	encLen := make([]byte, 4)
+	binary.LittleEndian.PutUint32(encLen, uint32(len(ext)))
+
+	data := append(append([]byte{}, ext...), encLen...)
+	ret, err := p.coreAPI.CallRuntime(req.Hash, runtime.TransactionPaymentAPIQueryInfo, data)
+	if err != nil {
+		return err
+	}
+
+	info, err := decodeRuntimeDispatchInfo(ret)
+	if err != nil {
+		return err
+	}
+
+	*res = *info
+	return nil
+}
+
+// decodeRuntimeDispatchInfo decodes the SCALE encoded RuntimeDispatchInfo returned by TransactionPaymentApi_query_info,
+// which consists of the weight (u64), the dispatch class (u8 enum) and the partial fee (u128)
+func decodeRuntimeDispatchInfo(in []byte) (*PaymentQueryInfoResponse, error) {
+	if len(in) != 25 {
+		return nil, fmt.Errorf("invalid dispatch info length: %d", len(in))
+	}
+
+	class := int(in[8])
+	if class >= len(dispatchClasses) {
+		return nil, errors.New("invalid dispatch class")
+	}
+
+	fee := new(big.Int).SetBytes(common.Uint128FromLEBytes(in[9:]).ToBEBytes())
+
+	return &PaymentQueryInfoResponse{
+		Weight:     binary.LittleEndian.Uint64(in[:8]),
+		Class:      dispatchClasses[class],
+		PartialFee: fee.String(),
+	}, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package modules
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRuntimeDispatchInfo(t *testing.T) {
+	// weight = 195000000, class = operational, partial fee = 2^64 + 1
+	enc := common.MustHexToBytes("0xc0769f0b000000000101000000000000000100000000000000")
+
+	info, err := decodeRuntimeDispatchInfo(enc)
+	require.NoError(t, err)
+	require.Equal(t, &PaymentQueryInfoResponse{
+		Weight:     195000000,
+		Class:      "operational",
+		PartialFee: "18446744073709551617",
+	}, info)
+
+	_, err = decodeRuntimeDispatchInfo(enc[:24])
+	require.Error(t, err)
+
+	enc[8] = 3
+	_, err = decodeRuntimeDispatchInfo(enc)
+	require.Error(t, err)
+}
+
+func TestPaymentModule_QueryInfo_InvalidExtrinsic(t *testing.T) {
+	m := NewPaymentModule(nil)
+
+	var res PaymentQueryInfoResponse
+	err := m.QueryInfo(nil, &PaymentQueryInfoRequest{Ext: "0xff"}, &res)
+	require.Error(t, err)
+}
@@ -17,8 +17,11 @@
 package modules
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 	"strings"
 
@@ -27,10 +30,16 @@ import (
 	"github.com/ChainSafe/gossamer/lib/scale"
 )
 
+// maxQueryStorageBlocks is the maximum number of blocks that can be queried by a single state_queryStorage call
+var maxQueryStorageBlocks int64 = 1000
+
+// ErrQueryStorageRangeTooLarge is returned when the block range of state_queryStorage exceeds maxQueryStorageBlocks
+var ErrQueryStorageRangeTooLarge = errors.New("block range is too large")
+
 // StateCallRequest holds json fields
 type StateCallRequest struct {
 	Method string       `json:"method"`
-	Data   []byte       `json:"data"`
+	Data   string       `json:"data"`
 	Block  *common.Hash `json:"block"`
 }
 
@@ -85,16 +94,28 @@ type StateStorageRequest struct {
 
 // StateStorageQueryRangeRequest holds json fields
 type StateStorageQueryRangeRequest struct {
-	Keys       []*common.Hash `json:"keys" validate:"required"`
-	StartBlock *common.Hash   `json:"startBlock" validate:"required"`
-	Block      *common.Hash   `json:"block"`
+	Keys       []string     `json:"keys" validate:"required"`
+	StartBlock *common.Hash `json:"startBlock" validate:"required"`
+	Block      *common.Hash `json:"block"`
+}
+
+// StateStorageQueryAtRequest holds json fields
+type StateStorageQueryAtRequest struct {
+	Keys []string     `json:"keys" validate:"required"`
+	At   *common.Hash `json:"at"`
+}
+
+// StateGetReadProofRequest holds json fields
+type StateGetReadProofRequest struct {
+	Keys []string     `json:"keys"`
+	Hash *common.Hash `json:"hash"`
 }
 
 // StateStorageKeysQuery field to store storage keys
 type StateStorageKeysQuery [][]byte
 
-// StateCallResponse holds json fields
-type StateCallResponse []byte
+// StateCallResponse is the hex encoded result of the runtime call
+type StateCallResponse string
 
 // StateKeysResponse field to store the state keys
 type StateKeysResponse [][]byte
@@ -127,17 +148,20 @@ type StateStorageKeysResponse []string
 //TODO: Determine actual type
 type StateMetadataResponse string
 
+// StateGetReadProofResponse holds the response format
+type StateGetReadProofResponse struct {
+	At    common.Hash `json:"at"`
+	Proof []string    `json:"proof"`
+}
+
 // StorageChangeSetResponse is the struct that holds the block and changes
 type StorageChangeSetResponse struct {
-	Block   *common.Hash
-	Changes []KeyValueOption
+	Block   *common.Hash     `json:"block"`
+	Changes []KeyValueOption `json:"changes"`
 }
 
-// KeyValueOption struct holds json fields
-type KeyValueOption []byte
-
-// StorageKey is the key for the storage
-type StorageKey []byte
+// KeyValueOption holds a hex encoded storage key and its value, a nil value means the key has no value
+type KeyValueOption [2]*string
 
 // StateRuntimeVersionResponse is the runtime version response
 type StateRuntimeVersionResponse struct {
@@ -155,30 +179,24 @@ type StateModule struct {
 	networkAPI NetworkAPI
 	storageAPI StorageAPI
 	coreAPI    CoreAPI
+	blockAPI   BlockAPI
 }
 
 // NewStateModule creates a new State module.
-func NewStateModule(net NetworkAPI, storage StorageAPI, core CoreAPI) *StateModule {
+func NewStateModule(net NetworkAPI, storage StorageAPI, core CoreAPI, block BlockAPI) *StateModule {
 	return &StateModule{
 		networkAPI: net,
 		storageAPI: storage,
 		coreAPI:    core,
+		blockAPI:   block,
 	}
 }
 
 // GetPairs returns the keys with prefix, leave empty to get all the keys.
 func (sm *StateModule) GetPairs(r *http.Request, req *StatePairRequest, res *StatePairResponse) error {
-	// TODO implement change storage trie so that block hash parameter works (See issue #834)
-	var (
-		stateRootHash *common.Hash
-		err           error
-	)
-
-	if req.Bhash != nil {
-		stateRootHash, err = sm.storageAPI.GetStateRootFromBlock(req.Bhash)
-		if err != nil {
-			return err
-		}
+	stateRootHash, err := sm.stateRootAt(req.Bhash)
+	if err != nil {
+		return err
 	}
 
 	if req.Prefix == nil || *req.Prefix == "" || *req.Prefix == "0x" {
@@ -207,10 +225,20 @@ func (sm *StateModule) GetPairs(r *http.Request, req *StatePairRequest, res *Sta
 	return nil
 }
 
-// Call isn't implemented properly yet.
+// Call executes the given runtime method with the given data at the state of the given block.
+// If no block hash is provided, the call is made at the best block.
 func (sm *StateModule) Call(r *http.Request, req *StateCallRequest, res *StateCallResponse) error {
-	_ = sm.networkAPI
-	_ = sm.storageAPI
+	data, err := common.HexToBytes(req.Data)
+	if err != nil {
+		return err
+	}
+
+	ret, err := sm.coreAPI.CallRuntime(req.Block, req.Method, data)
+	if err != nil {
+		return err
+	}
+
+	*res = StateCallResponse(common.BytesToHex(ret))
 	return nil
 }
 
@@ -247,7 +275,16 @@ func (sm *StateModule) GetKeysPaged(r *http.Request, req *StateStorageKeyRequest
 	if err != nil {
 		return err
 	}
-	keys, err := sm.storageAPI.GetKeysWithPrefix(req.Block, hPrefix)
+	stateRootHash, err := sm.stateRootAt(req.Block)
+	if err != nil {
+		return err
+	}
+
+	keys, err := sm.storageAPI.GetKeysWithPrefix(stateRootHash, hPrefix)
+	if err != nil {
+		return err
+	}
+
 	resCount := uint32(0)
 	for _, k := range keys {
 		fKey := fmt.Sprintf("0x%x", k)
@@ -261,12 +298,11 @@ func (sm *StateModule) GetKeysPaged(r *http.Request, req *StateStorageKeyRequest
 			resCount++
 		}
 	}
-	return err
+	return nil
 }
 
 // GetMetadata calls runtime Metadata_metadata function
 func (sm *StateModule) GetMetadata(r *http.Request, req *StateRuntimeMetadataQuery, res *StateMetadataResponse) error {
-	// TODO implement change storage trie so that block hash parameter works (See issue #834)
 	metadata, err := sm.coreAPI.GetMetadata(req.Bhash)
 	if err != nil {
 		return err
@@ -277,9 +313,43 @@ func (sm *StateModule) GetMetadata(r *http.Request, req *StateRuntimeMetadataQue
 	return err
 }
 
+// GetReadProof returns the proof of storage for the given keys at the given block.
+// If no block hash is provided, the best block is used.
+func (sm *StateModule) GetReadProof(r *http.Request, req *StateGetReadProofRequest, res *StateGetReadProofResponse) error {
+	bhash := sm.blockAPI.BestBlockHash()
+	if req.Hash != nil {
+		bhash = *req.Hash
+	}
+
+	stateRoot, err := sm.storageAPI.GetStateRootFromBlock(&bhash)
+	if err != nil {
+		return err
+	}
+
+	keys := make([][]byte, len(req.Keys))
+	for i, k := range req.Keys {
+		keys[i], err = common.HexToBytes(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	proof, err := sm.storageAPI.GenerateTrieProof(*stateRoot, keys)
+	if err != nil {
+		return err
+	}
+
+	res.At = bhash
+	res.Proof = make([]string, len(proof))
+	for i, p := range proof {
+		res.Proof[i] = common.BytesToHex(p)
+	}
+
+	return nil
+}
+
 // GetRuntimeVersion Get the runtime version at a given block.
 //  If no block hash is provided, the latest version gets returned.
-// TODO currently only returns latest version, add functionality to lookup runtime by block hash (see issue #834)
 func (sm *StateModule) GetRuntimeVersion(r *http.Request, req *StateRuntimeVersionRequest, res *StateRuntimeVersionResponse) error {
 	rtVersion, err := sm.coreAPI.GetRuntimeVersion(req.Bhash)
 	if err != nil {
@@ -299,23 +369,16 @@ func (sm *StateModule) GetRuntimeVersion(r *http.Request, req *StateRuntimeVersi
 
 // GetStorage Returns a storage entry at a specific block's state. If not block hash is provided, the latest value is returned.
 func (sm *StateModule) GetStorage(r *http.Request, req *StateStorageRequest, res *StateStorageResponse) error {
-	var (
-		item []byte
-		err  error
-	)
-
 	reqBytes, _ := common.HexToBytes(req.Key) // no need to catch error here
 
-	if req.Bhash != nil {
-		item, err = sm.storageAPI.GetStorageByBlockHash(*req.Bhash, reqBytes)
-		if err != nil {
-			return err
-		}
-	} else {
-		item, err = sm.storageAPI.GetStorage(nil, reqBytes)
-		if err != nil {
-			return err
-		}
+	stateRootHash, err := sm.stateRootAt(req.Bhash)
+	if err != nil {
+		return err
+	}
+
+	item, err := sm.storageAPI.GetStorage(stateRootHash, reqBytes)
+	if err != nil {
+		return err
 	}
 
 	if len(item) > 0 {
@@ -327,25 +390,17 @@ func (sm *StateModule) GetStorage(r *http.Request, req *StateStorageRequest, res
 
 // GetStorageHash returns the hash of a storage entry at a block's state.
 //  If no block hash is provided, the latest value is returned.
-//  TODO implement change storage trie so that block hash parameter works (See issue #834)
 func (sm *StateModule) GetStorageHash(r *http.Request, req *StateStorageHashRequest, res *StateStorageHashResponse) error {
-	var (
-		item []byte
-		err  error
-	)
-
 	reqBytes, _ := common.HexToBytes(req.Key)
 
-	if req.Bhash != nil {
-		item, err = sm.storageAPI.GetStorageByBlockHash(*req.Bhash, reqBytes)
-		if err != nil {
-			return err
-		}
-	} else {
-		item, err = sm.storageAPI.GetStorage(nil, reqBytes)
-		if err != nil {
-			return err
-		}
+	stateRootHash, err := sm.stateRootAt(req.Bhash)
+	if err != nil {
+		return err
+	}
+
+	item, err := sm.storageAPI.GetStorage(stateRootHash, reqBytes)
+	if err != nil {
+		return err
 	}
 
 	if len(item) > 0 {
@@ -357,37 +412,122 @@ func (sm *StateModule) GetStorageHash(r *http.Request, req *StateStorageHashRequ
 
 // GetStorageSize returns the size of a storage entry at a block's state.
 //  If no block hash is provided, the latest value is used.
-// TODO implement change storage trie so that block hash parameter works (See issue #834)
 func (sm *StateModule) GetStorageSize(r *http.Request, req *StateStorageSizeRequest, res *StateStorageSizeResponse) error {
-	var (
-		item []byte
-		err  error
-	)
-
 	reqBytes, _ := common.HexToBytes(req.Key)
 
-	if req.Bhash != nil {
-		item, err = sm.storageAPI.GetStorageByBlockHash(*req.Bhash, reqBytes)
+	stateRootHash, err := sm.stateRootAt(req.Bhash)
+	if err != nil {
+		return err
+	}
+
+	item, err := sm.storageAPI.GetStorage(stateRootHash, reqBytes)
+	if err != nil {
+		return err
+	}
+
+	if len(item) > 0 {
+		*res = StateStorageSizeResponse((uint64)(len(item)))
+	}
+
+	return nil
+}
+
+// QueryStorage returns the changes to the given storage keys in each block of the canonical chain from the start block
+//  up to and including the given block. If no end block is provided, the best block is used.
+//  The change set of the start block contains the value of every key, later change sets only the keys that changed.
+func (sm *StateModule) QueryStorage(r *http.Request, req *StateStorageQueryRangeRequest, res *[]StorageChangeSetResponse) error {
+	if req.StartBlock == nil {
+		return errors.New("start block hash is required")
+	}
+
+	end := sm.blockAPI.BestBlockHash()
+	if req.Block != nil {
+		end = *req.Block
+	}
+
+	keys, err := decodeStorageKeys(req.Keys)
+	if err != nil {
+		return err
+	}
+
+	startHeader, err := sm.blockAPI.GetHeader(*req.StartBlock)
+	if err != nil {
+		return err
+	}
+
+	endHeader, err := sm.blockAPI.GetHeader(end)
+	if err != nil {
+		return err
+	}
+
+	span := new(big.Int).Sub(endHeader.Number, startHeader.Number)
+	if span.Cmp(big.NewInt(maxQueryStorageBlocks)) >= 0 {
+		return ErrQueryStorageRangeTooLarge
+	}
+
+	blocks, err := sm.blockAPI.SubChain(*req.StartBlock, end)
+	if err != nil {
+		return err
+	}
+
+	*res = []StorageChangeSetResponse{}
+	lastValues := make(map[string][]byte)
+	for i, bhash := range blocks {
+		changes, err := sm.storageAt(bhash, keys)
 		if err != nil {
 			return err
 		}
-	} else {
-		item, err = sm.storageAPI.GetStorage(nil, reqBytes)
-		if err != nil {
-			return err
+
+		set := StorageChangeSetResponse{
+			Block: &blocks[i],
 		}
-	}
 
-	if len(item) > 0 {
-		*res = StateStorageSizeResponse((uint64)(len(item)))
+		for j, key := range keys {
+			last, seen := lastValues[string(key)]
+			if seen && bytes.Equal(last, changes[j]) && (last == nil) == (changes[j] == nil) {
+				continue
+			}
+
+			lastValues[string(key)] = changes[j]
+			set.Changes = append(set.Changes, newKeyValueOption(key, changes[j]))
+		}
+
+		if len(set.Changes) > 0 {
+			*res = append(*res, set)
+		}
 	}
 
 	return nil
 }
 
-// QueryStorage isn't implemented properly yet.
-func (sm *StateModule) QueryStorage(r *http.Request, req *StateStorageQueryRangeRequest, res *StorageChangeSetResponse) error {
-	// TODO implement change storage trie so that block hash parameter works (See issue #834)
+// QueryStorageAt returns the values of the given storage keys at the given block.
+//  If no block hash is provided, the best block is used.
+func (sm *StateModule) QueryStorageAt(r *http.Request, req *StateStorageQueryAtRequest, res *[]StorageChangeSetResponse) error {
+	bhash := sm.blockAPI.BestBlockHash()
+	if req.At != nil {
+		bhash = *req.At
+	}
+
+	keys, err := decodeStorageKeys(req.Keys)
+	if err != nil {
+		return err
+	}
+
+	values, err := sm.storageAt(bhash, keys)
+	if err != nil {
+		return err
+	}
+
+	set := StorageChangeSetResponse{
+		Block:   &bhash,
+		Changes: make([]KeyValueOption, len(keys)),
+	}
+
+	for i, key := range keys {
+		set.Changes[i] = newKeyValueOption(key, values[i])
+	}
+
+	*res = append(*res, set)
 	return nil
 }
 
@@ -405,6 +545,57 @@ func (sm *StateModule) SubscribeStorage(r *http.Request, req *StateStorageQueryR
 	return nil
 }
 
+// stateRootAt returns the state root of the block with the given hash.
+// If no block hash is provided, it returns nil, which the storage API treats as the best block's state root.
+func (sm *StateModule) stateRootAt(bhash *common.Hash) (*common.Hash, error) {
+	if bhash == nil {
+		return nil, nil
+	}
+
+	return sm.storageAPI.GetStateRootFromBlock(bhash)
+}
+
+// storageAt returns the values of the given keys at the state of the block with the given hash
+func (sm *StateModule) storageAt(bhash common.Hash, keys [][]byte) ([][]byte, error) {
+	stateRoot, err := sm.storageAPI.GetStateRootFromBlock(&bhash)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i], err = sm.storageAPI.GetStorage(stateRoot, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+func decodeStorageKeys(hexKeys []string) ([][]byte, error) {
+	keys := make([][]byte, len(hexKeys))
+	for i, k := range hexKeys {
+		var err error
+		keys[i], err = common.HexToBytes(k)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+func newKeyValueOption(key, value []byte) KeyValueOption {
+	k := common.BytesToHex(key)
+	if value == nil {
+		return KeyValueOption{&k, nil}
+	}
+
+	v := common.BytesToHex(value)
+	return KeyValueOption{&k, &v}
+}
+
 // ConvertAPIs runtime.APIItems to []interface
 func ConvertAPIs(in []*runtime.APIItem) []interface{} {
 	ret := make([]interface{}, 0)
@@ -17,6 +17,7 @@ package modules
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -24,8 +25,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/trie"
 	"github.com/stretchr/testify/require"
 )
 
@@ -356,7 +359,7 @@ func TestStateModule_GetMetadata(t *testing.T) {
 }
 
 func TestStateModule_GetKeysPaged(t *testing.T) {
-	sm, _, stateRootHash := setupStateModule(t)
+	sm, hash, _ := setupStateModule(t)
 
 	testCases := []struct {
 		name     string
@@ -371,7 +374,7 @@ func TestStateModule_GetKeysPaged(t *testing.T) {
 		{name: "allKeysTestBlockHash",
 			params: StateStorageKeyRequest{
 				Qty:   10,
-				Block: stateRootHash,
+				Block: hash,
 			}, expected: []string{"0x3a6b657931", "0x3a6b657932"}},
 		{name: "prefixMatchAll",
 			params: StateStorageKeyRequest{
@@ -416,6 +419,217 @@ func TestStateModule_GetKeysPaged(t *testing.T) {
 	}
 }
 
+func TestStateModule_GetReadProof(t *testing.T) {
+	sm, hash, sr := setupStateModule(t)
+
+	req := &StateGetReadProofRequest{
+		Keys: []string{"0x3a6b657931", "0x3a6b657932", "0x3a6b657933"},
+		Hash: hash,
+	}
+
+	var res StateGetReadProofResponse
+	err := sm.GetReadProof(nil, req, &res)
+	require.NoError(t, err)
+	require.Equal(t, *hash, res.At)
+	require.NotEmpty(t, res.Proof)
+
+	proof := make([][]byte, len(res.Proof))
+	for i, p := range res.Proof {
+		proof[i], err = common.HexToBytes(p)
+		require.NoError(t, err)
+	}
+
+	val, err := trie.VerifyProof(*sr, []byte(`:key1`), proof)
+	require.NoError(t, err)
+	require.Equal(t, []byte(`value1`), val)
+
+	val, err = trie.VerifyProof(*sr, []byte(`:key2`), proof)
+	require.NoError(t, err)
+	require.Equal(t, []byte(`value2`), val)
+
+	val, err = trie.VerifyProof(*sr, []byte(`:key3`), proof)
+	require.NoError(t, err)
+	require.Nil(t, val)
+
+	// latest block is used if no block hash is given
+	res = StateGetReadProofResponse{}
+	err = sm.GetReadProof(nil, &StateGetReadProofRequest{Keys: req.Keys}, &res)
+	require.NoError(t, err)
+	require.Equal(t, *hash, res.At)
+}
+
+func TestStateModule_HistoricalQueries(t *testing.T) {
+	sm, hash, _ := setupStateModule(t)
+
+	// change the storage in a new block on top of the one created by setupStateModule
+	block := addTestStateBlock(t, sm, *hash, 3, map[string][]byte{
+		":key1": []byte(`newvalue1`),
+		":key3": []byte(`value3`),
+	})
+	blockAPI := sm.blockAPI.(*state.BlockState)
+
+	var res StateStorageResponse
+	err := sm.GetStorage(nil, &StateStorageRequest{Key: "0x3a6b657931", Bhash: hash}, &res)
+	require.NoError(t, err)
+	require.Equal(t, StateStorageResponse(common.BytesToHex([]byte(`value1`))), res)
+
+	res = ""
+	err = sm.GetStorage(nil, &StateStorageRequest{Key: "0x3a6b657931"}, &res)
+	require.NoError(t, err)
+	require.Equal(t, StateStorageResponse(common.BytesToHex([]byte(`newvalue1`))), res)
+
+	var size StateStorageSizeResponse
+	err = sm.GetStorageSize(nil, &StateStorageSizeRequest{Key: "0x3a6b657931", Bhash: hash}, &size)
+	require.NoError(t, err)
+	require.Equal(t, StateStorageSizeResponse(len(`value1`)), size)
+
+	var keys StateStorageKeysResponse
+	err = sm.GetKeysPaged(nil, &StateStorageKeyRequest{Qty: 10, Block: hash}, &keys)
+	require.NoError(t, err)
+	require.Equal(t, StateStorageKeysResponse{"0x3a6b657931", "0x3a6b657932"}, keys)
+
+	var pairs StatePairResponse
+	err = sm.GetPairs(nil, &StatePairRequest{Prefix: new(string), Bhash: hash}, &pairs)
+	require.NoError(t, err)
+	require.Len(t, pairs, 2)
+
+	// a block whose state is not in the database returns an error
+	pruned := &types.Block{
+		Header: &types.Header{
+			ParentHash: block.Header.Hash(),
+			Number:     big.NewInt(4),
+			StateRoot:  common.Hash{0x1},
+		},
+		Body: types.NewBody([]byte{}),
+	}
+	err = blockAPI.AddBlock(pruned)
+	require.NoError(t, err)
+
+	prunedHash := pruned.Header.Hash()
+	err = sm.GetStorage(nil, &StateStorageRequest{Key: "0x3a6b657931", Bhash: &prunedHash}, &res)
+	require.True(t, errors.Is(err, state.ErrTrieDoesNotExist))
+}
+
+func TestStateModule_QueryStorage(t *testing.T) {
+	sm, hash, _ := setupStateModule(t)
+
+	hash3 := addTestStateBlock(t, sm, *hash, 3, map[string][]byte{":key1": []byte(`newvalue1`)}).Header.Hash()
+	hash4 := addTestStateBlock(t, sm, hash3, 4, map[string][]byte{":key3": []byte(`value3`)}).Header.Hash()
+	hash5 := addTestStateBlock(t, sm, hash4, 5, map[string][]byte{":key2": nil}).Header.Hash()
+
+	str := func(s string) *string { return &s }
+	key1, key2 := common.BytesToHex([]byte(`:key1`)), common.BytesToHex([]byte(`:key2`))
+
+	req := &StateStorageQueryRangeRequest{
+		Keys:       []string{key1, key2},
+		StartBlock: hash,
+	}
+
+	var res []StorageChangeSetResponse
+	err := sm.QueryStorage(nil, req, &res)
+	require.NoError(t, err)
+
+	// block 4 doesn't change any of the keys, so it has no change set
+	expected := []StorageChangeSetResponse{
+		{Block: hash, Changes: []KeyValueOption{
+			{&key1, str(common.BytesToHex([]byte(`value1`)))},
+			{&key2, str(common.BytesToHex([]byte(`value2`)))},
+		}},
+		{Block: &hash3, Changes: []KeyValueOption{
+			{&key1, str(common.BytesToHex([]byte(`newvalue1`)))},
+		}},
+		{Block: &hash5, Changes: []KeyValueOption{
+			{&key2, nil},
+		}},
+	}
+	require.Equal(t, expected, res)
+
+	// the range ends at the given block
+	res = nil
+	req.Block = &hash4
+	err = sm.QueryStorage(nil, req, &res)
+	require.NoError(t, err)
+	require.Equal(t, expected[:2], res)
+
+	// a query without change sets returns an empty list
+	res = nil
+	err = sm.QueryStorage(nil, &StateStorageQueryRangeRequest{Keys: []string{}, StartBlock: &hash4}, &res)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	require.Empty(t, res)
+
+	// ranges that span too many blocks are rejected
+	defer func(max int64) { maxQueryStorageBlocks = max }(maxQueryStorageBlocks)
+	maxQueryStorageBlocks = 3
+
+	res = nil
+	err = sm.QueryStorage(nil, &StateStorageQueryRangeRequest{Keys: []string{key1}, StartBlock: &hash3}, &res)
+	require.NoError(t, err)
+
+	err = sm.QueryStorage(nil, &StateStorageQueryRangeRequest{Keys: []string{key1}, StartBlock: hash}, &res)
+	require.Equal(t, ErrQueryStorageRangeTooLarge, err)
+
+	res = nil
+	err = sm.QueryStorageAt(nil, &StateStorageQueryAtRequest{Keys: []string{key1, key2}, At: &hash3}, &res)
+	require.NoError(t, err)
+	require.Equal(t, []StorageChangeSetResponse{
+		{Block: &hash3, Changes: []KeyValueOption{
+			{&key1, str(common.BytesToHex([]byte(`newvalue1`)))},
+			{&key2, str(common.BytesToHex([]byte(`value2`)))},
+		}},
+	}, res)
+}
+
+func TestStateModule_Call(t *testing.T) {
+	sm, hash, _ := setupStateModule(t)
+
+	var res StateCallResponse
+	err := sm.Call(nil, &StateCallRequest{Method: "Core_version", Data: "0x", Block: hash}, &res)
+	require.NoError(t, err)
+	require.NotEmpty(t, res)
+
+	var latest StateCallResponse
+	err = sm.Call(nil, &StateCallRequest{Method: "Core_version", Data: "0x"}, &latest)
+	require.NoError(t, err)
+	require.Equal(t, res, latest)
+}
+
+// addTestStateBlock adds a block on top of parent whose state applies the given changes to the parent's state.
+// A nil value deletes the key.
+func addTestStateBlock(t *testing.T, sm *StateModule, parent common.Hash, number int64, changes map[string][]byte) *types.Block {
+	storage := sm.storageAPI.(*state.StorageState)
+	blockState := sm.blockAPI.(*state.BlockState)
+
+	header, err := blockState.GetHeader(parent)
+	require.NoError(t, err)
+
+	ts, err := storage.TrieState(&header.StateRoot)
+	require.NoError(t, err)
+
+	for k, v := range changes {
+		if v == nil {
+			ts.Delete([]byte(k))
+			continue
+		}
+		ts.Set([]byte(k), v)
+	}
+
+	block := &types.Block{
+		Header: &types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(number),
+			StateRoot:  ts.MustRoot(),
+		},
+		Body: types.NewBody([]byte{}),
+	}
+
+	err = storage.StoreTrie(ts, block.Header)
+	require.NoError(t, err)
+	err = blockState.AddBlock(block)
+	require.NoError(t, err)
+	return block
+}
+
 func setupStateModule(t *testing.T) (*StateModule, *common.Hash, *common.Hash) {
 	// setup service
 	net := newNetworkService(t)
@@ -429,7 +643,7 @@ func setupStateModule(t *testing.T) (*StateModule, *common.Hash, *common.Hash) {
 
 	sr1, err := ts.Root()
 	require.NoError(t, err)
-	err = chain.Storage.StoreTrie(ts)
+	err = chain.Storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 
 	err = chain.Block.AddBlock(&types.Block{
@@ -444,5 +658,5 @@ func setupStateModule(t *testing.T) (*StateModule, *common.Hash, *common.Hash) {
 
 	hash, _ := chain.Block.GetBlockHash(big.NewInt(2))
 	core := newCoreService(t, chain)
-	return NewStateModule(net, chain.Storage, core), hash, &sr1
+	return NewStateModule(net, chain.Storage, core, chain.Block), hash, &sr1
 }
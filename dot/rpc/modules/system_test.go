@@ -97,6 +97,10 @@ func (s *mockBlockState) GetHashByNumber(_ *big.Int) (common.Hash, error) {
 	return common.Hash{}, nil
 }
 
+func (s *mockBlockState) GetHeader(_ common.Hash) (*types.Header, error) {
+	return genesisHeader, nil
+}
+
 type mockTransactionHandler struct{}
 
 func (h *mockTransactionHandler) HandleTransactionMessage(_ *network.TransactionMessage) error {
@@ -366,7 +370,7 @@ func setupSystemModule(t *testing.T) *SystemModule {
 	require.NoError(t, err)
 	ts.Set(aliceAcctStoKey, aliceAcctEncoded)
 
-	err = chain.Storage.StoreTrie(ts)
+	err = chain.Storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 	err = chain.Block.AddBlock(&types.Block{
 		Header: &types.Header{
@@ -16,13 +16,13 @@
 package subscription
 
 import (
-	"fmt"
-	"reflect"
+	"sync"
 
 	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/transaction"
 )
 
 // Listener interface for functions that define Listener related functions
@@ -33,17 +33,18 @@ type Listener interface {
 // WSConnAPI interface defining methors a WSConn should have
 type WSConnAPI interface {
 	safeSend(interface{})
+	removeSubscription(id uint)
 }
 
 // StorageObserver struct to hold data for observer (Observer Design Pattern)
 type StorageObserver struct {
 	id     uint
-	filter map[string][]byte
+	filter map[string]struct{}
 	wsconn WSConnAPI
 }
 
-// Change type defining key value pair representing change
-type Change [2]string
+// Change type defining key value pair representing change, a nil value means the key has no value
+type Change [2]*string
 
 // ChangeResult struct to hold change result data
 type ChangeResult struct {
@@ -62,7 +63,7 @@ func (s *StorageObserver) Update(change *state.SubscriptionResult) {
 		Changes: make([]Change, len(change.Changes)),
 	}
 	for i, v := range change.Changes {
-		changeResult.Changes[i] = Change{common.BytesToHex(v.Key), common.BytesToHex(v.Value)}
+		changeResult.Changes[i] = newChange(v.Key, v.Value)
 	}
 
 	res := newSubcriptionBaseResponseJSON()
@@ -78,10 +79,20 @@ func (s *StorageObserver) GetID() uint {
 }
 
 // GetFilter returns the filter the Observer is using
-func (s *StorageObserver) GetFilter() map[string][]byte {
+func (s *StorageObserver) GetFilter() map[string]struct{} {
 	return s.filter
 }
 
+func newChange(key, value []byte) Change {
+	k := common.BytesToHex(key)
+	if value == nil {
+		return Change{&k, nil}
+	}
+
+	v := common.BytesToHex(value)
+	return Change{&k, &v}
+}
+
 // Listen to satisfy Listener interface (but is no longer used by StorageObserver)
 func (s *StorageObserver) Listen() {}
 
@@ -140,53 +151,69 @@ func (l *BlockFinalizedListener) Listen() {
 
 // ExtrinsicSubmitListener to handle listening for extrinsic events
 type ExtrinsicSubmitListener struct {
-	wsconn    WSConnAPI
-	subID     uint
-	extrinsic types.Extrinsic
-
-	importedChan    chan *types.Block
-	importedChanID  byte
-	importedHash    common.Hash
-	finalisedChan   chan *types.FinalisationInfo
-	finalisedChanID byte
+	wsconn     WSConnAPI
+	subID      uint
+	extrinsic  types.Extrinsic
+	txStateAPI modules.TransactionStateAPI
+
+	statusChan   chan *transaction.StatusNotification
+	statusChanID uint64
+	stopped      bool
+	lock         sync.Mutex
 }
 
 // AuthorExtrinsicUpdates method name
 const AuthorExtrinsicUpdates = "author_extrinsicUpdate"
 
-// Listen implementation of Listen interface to listen for importedChan changes
+// Listen implementation of Listen interface to listen for status changes of the extrinsic in the transaction pool.
+// The subscription ends once the extrinsic reaches a final status.
 func (l *ExtrinsicSubmitListener) Listen() {
-	// listen for imported blocks with extrinsic
 	go func() {
-		for block := range l.importedChan {
-			if block == nil {
-				continue
-			}
-			bodyHasExtrinsic, err := block.Body.HasExtrinsic(l.extrinsic)
-			if err != nil {
-				fmt.Printf("error %v\n", err)
-			}
-
-			if bodyHasExtrinsic {
-				resM := make(map[string]interface{})
-				resM["inBlock"] = block.Header.Hash().String()
-
-				l.importedHash = block.Header.Hash()
-				l.wsconn.safeSend(newSubscriptionResponse(AuthorExtrinsicUpdates, l.subID, resM))
+		for n := range l.statusChan {
+			l.wsconn.safeSend(newSubscriptionResponse(AuthorExtrinsicUpdates, l.subID, extrinsicStatus(n)))
+			if n.Status.IsFinal() {
+				l.wsconn.removeSubscription(l.subID)
+				l.Stop()
 			}
 		}
 	}()
+}
 
-	// listen for finalised headers
-	go func() {
-		for info := range l.finalisedChan {
-			if reflect.DeepEqual(l.importedHash, info.Header.Hash()) {
-				resM := make(map[string]interface{})
-				resM["finalised"] = info.Header.Hash().String()
-				l.wsconn.safeSend(newSubscriptionResponse(AuthorExtrinsicUpdates, l.subID, resM))
-			}
+// Stop unregisters and closes the status channel of the listener, which ends the subscription. It returns false if
+// the listener was already stopped.
+func (l *ExtrinsicSubmitListener) Stop() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.stopped {
+		return false
+	}
+
+	l.stopped = true
+	l.txStateAPI.UnregisterStatusChannel(l.statusChanID)
+	close(l.statusChan)
+	return true
+}
+
+// extrinsicStatus returns the author_extrinsicUpdate result for the status notification, in the format of Substrate's
+// TransactionStatus
+func extrinsicStatus(n *transaction.StatusNotification) interface{} {
+	switch n.Status {
+	case transaction.Broadcast:
+		return map[string]interface{}{
+			n.Status.String(): n.Peers,
 		}
-	}()
+	case transaction.InBlock, transaction.Retracted, transaction.FinalityTimeout, transaction.Finalized:
+		return map[string]interface{}{
+			n.Status.String(): n.Block.String(),
+		}
+	case transaction.Usurped:
+		return map[string]interface{}{
+			n.Status.String(): n.Usurper.String(),
+		}
+	default:
+		return n.Status.String()
+	}
 }
 
 // RuntimeVersionListener to handle listening for Runtime Version
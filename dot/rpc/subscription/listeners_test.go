@@ -25,17 +25,23 @@ import (
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/stretchr/testify/require"
 )
 
 type MockWSConnAPI struct {
 	lastMessage BaseResponseJSON
+	removed     []uint
 }
 
 func (m *MockWSConnAPI) safeSend(msg interface{}) {
 	m.lastMessage = msg.(BaseResponseJSON)
 }
 
+func (m *MockWSConnAPI) removeSubscription(id uint) {
+	m.removed = append(m.removed, id)
+}
+
 func TestStorageObserver_Update(t *testing.T) {
 	mockConnection := &MockWSConnAPI{}
 	storageObserver := StorageObserver{
@@ -57,7 +63,8 @@ func TestStorageObserver_Update(t *testing.T) {
 		Changes: make([]Change, len(change.Changes)),
 	}
 	for i, v := range change.Changes {
-		expected.Changes[i] = Change{common.BytesToHex(v.Key), common.BytesToHex(v.Value)}
+		key, value := common.BytesToHex(v.Key), common.BytesToHex(v.Value)
+		expected.Changes[i] = Change{&key, &value}
 	}
 
 	expectedRespones := newSubcriptionBaseResponseJSON()
@@ -121,41 +128,70 @@ func TestBlockFinalizedListener_Listen(t *testing.T) {
 }
 
 func TestExtrinsicSubmitListener_Listen(t *testing.T) {
-	notifyImportedChan := make(chan *types.Block)
-	notifyFinalizedChan := make(chan *types.FinalisationInfo)
+	statusChan := make(chan *transaction.StatusNotification)
 
 	mockConnection := &MockWSConnAPI{}
-	esl := ExtrinsicSubmitListener{
-		importedChan:  notifyImportedChan,
-		finalisedChan: notifyFinalizedChan,
-		wsconn:        mockConnection,
-		extrinsic:     types.Extrinsic{1, 2, 3},
+	esl := &ExtrinsicSubmitListener{
+		statusChan: statusChan,
+		wsconn:     mockConnection,
+		extrinsic:  types.Extrinsic{1, 2, 3},
+		txStateAPI: new(MockTransactionStateAPI),
+		subID:      1,
 	}
-	header := types.NewEmptyHeader()
-	exts := []types.Extrinsic{{1, 2, 3}, {7, 8, 9, 0}, {0xa, 0xb}}
+	hash := esl.extrinsic.Hash()
+	block := common.Hash{1}
+	usurper := common.Hash{2}
 
-	body, err := types.NewBodyFromExtrinsics(exts)
-	require.NoError(t, err)
+	go esl.Listen()
 
-	block := &types.Block{
-		Header: header,
-		Body:   body,
+	for _, tc := range []struct {
+		notification *transaction.StatusNotification
+		result       interface{}
+	}{
+		{
+			notification: &transaction.StatusNotification{Hash: hash, Status: transaction.Future},
+			result:       "future",
+		},
+		{
+			notification: &transaction.StatusNotification{Hash: hash, Status: transaction.Ready},
+			result:       "ready",
+		},
+		{
+			notification: &transaction.StatusNotification{Hash: hash, Status: transaction.Broadcast, Peers: []string{"a"}},
+			result:       map[string]interface{}{"broadcast": []string{"a"}},
+		},
+		{
+			notification: &transaction.StatusNotification{Hash: hash, Status: transaction.InBlock, Block: block},
+			result:       map[string]interface{}{"inBlock": block.String()},
+		},
+		{
+			notification: &transaction.StatusNotification{Hash: hash, Status: transaction.Retracted, Block: block},
+			result:       map[string]interface{}{"retracted": block.String()},
+		},
+		{
+			notification: &transaction.StatusNotification{Hash: hash, Status: transaction.Usurped, Usurper: usurper},
+			result:       map[string]interface{}{"usurped": usurper.String()},
+		},
+	} {
+		statusChan <- tc.notification
+		time.Sleep(time.Millisecond * 10)
+		require.Equal(t, newSubscriptionResponse(AuthorExtrinsicUpdates, esl.subID, tc.result), mockConnection.lastMessage)
 	}
 
-	resImported := map[string]interface{}{"inBlock": block.Header.Hash().String()}
-	expectedImportedRespones := newSubscriptionResponse(AuthorExtrinsicUpdates, esl.subID, resImported)
-
-	go esl.Listen()
+	// the subscription ends after a final status, and is removed from the connection
+	_, ok := <-statusChan
+	require.False(t, ok)
+	require.False(t, esl.Stop())
+	require.Equal(t, []uint{1}, mockConnection.removed)
+}
 
-	notifyImportedChan <- block
-	time.Sleep(time.Millisecond * 10)
-	require.Equal(t, expectedImportedRespones, mockConnection.lastMessage)
+func TestExtrinsicStatus(t *testing.T) {
+	block := common.Hash{1}
 
-	notifyFinalizedChan <- &types.FinalisationInfo{
-		Header: header,
-	}
-	time.Sleep(time.Millisecond * 10)
-	resFinalised := map[string]interface{}{"finalised": block.Header.Hash().String()}
-	expectedFinalizedRespones := newSubscriptionResponse(AuthorExtrinsicUpdates, esl.subID, resFinalised)
-	require.Equal(t, expectedFinalizedRespones, mockConnection.lastMessage)
+	require.Equal(t, "dropped", extrinsicStatus(&transaction.StatusNotification{Status: transaction.Dropped}))
+	require.Equal(t, "invalid", extrinsicStatus(&transaction.StatusNotification{Status: transaction.Invalid}))
+	require.Equal(t, map[string]interface{}{"finalityTimeout": block.String()},
+		extrinsicStatus(&transaction.StatusNotification{Status: transaction.FinalityTimeout, Block: block}))
+	require.Equal(t, map[string]interface{}{"finalized": block.String()},
+		extrinsicStatus(&transaction.StatusNotification{Status: transaction.Finalized, Block: block}))
 }
@@ -27,10 +27,12 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/ChainSafe/gossamer/dot/rpc/json2"
 	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/dot/state"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/transaction"
 	log "github.com/ChainSafe/log15"
 	"github.com/gorilla/websocket"
 )
@@ -45,12 +47,21 @@ type WSConn struct {
 	StorageSubChannels map[int]byte
 	qtyListeners       uint
 	Subscriptions      map[uint]Listener
+	subscriptionsLock  sync.RWMutex
 	StorageAPI         modules.StorageAPI
 	BlockAPI           modules.BlockAPI
 	RuntimeAPI         modules.RuntimeAPI
 	CoreAPI            modules.CoreAPI
 	TxStateAPI         modules.TransactionStateAPI
 	RPCHost            string
+	batch              *batchResponse // set while a batch of requests is being handled
+}
+
+// batchResponse collects the responses to the requests of a batch, so they are sent as a single array in the order
+// of the requests, and the actions that must wait until the responses have been sent
+type batchResponse struct {
+	responses []interface{}
+	deferred  []func()
 }
 
 //HandleComm handles messages received on websocket connections
@@ -63,6 +74,14 @@ func (c *WSConn) HandleComm() {
 		}
 		logger.Trace("websocket received", "message", mbytes)
 
+		if json2.IsBatch(mbytes) {
+			err = c.handleBatch(mbytes)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
 		// determine if request is for subscribe method type
 		var msg map[string]interface{}
 		err = json.Unmarshal(mbytes, &msg)
@@ -72,114 +91,223 @@ func (c *WSConn) HandleComm() {
 			continue
 		}
 
-		method := msg["method"]
-		params := msg["params"]
-		logger.Debug("ws method called", "method", method, "params", params)
-
-		// if method contains subscribe, then register subscription
-		if strings.Contains(fmt.Sprintf("%s", method), "subscribe") {
-			reqid := msg["id"].(float64)
-			switch method {
-			case "chain_subscribeNewHeads", "chain_subscribeNewHead":
-				bl, err1 := c.initBlockListener(reqid)
-				if err1 != nil {
-					logger.Warn("failed to create block listener", "error", err)
-					continue
-				}
-				c.startListener(bl)
-			case "state_subscribeStorage":
-				_, err2 := c.initStorageChangeListener(reqid, params)
-				if err2 != nil {
-					logger.Warn("failed to create state change listener", "error", err2)
-					continue
-				}
-
-			case "chain_subscribeFinalizedHeads":
-				bfl, err3 := c.initBlockFinalizedListener(reqid)
-				if err3 != nil {
-					logger.Warn("failed to create block finalised", "error", err3)
-					continue
-				}
-				c.startListener(bfl)
-			case "state_subscribeRuntimeVersion":
-				rvl, err4 := c.initRuntimeVersionListener(reqid)
-				if err4 != nil {
-					logger.Warn("failed to create runtime version listener", "error", err4)
-					continue
-				}
-				c.startListener(rvl)
-			case "state_unsubscribeStorage":
-				c.unsubscribeStorageListener(reqid, params)
-
-			}
-			continue
-		}
-
-		if strings.Contains(fmt.Sprintf("%s", method), "submitAndWatchExtrinsic") {
-			reqid := msg["id"].(float64)
-			params := msg["params"]
-			el, e := c.initExtrinsicWatch(reqid, params)
-			if e != nil {
-				c.safeSendError(reqid, nil, e.Error())
-			} else {
-				c.startListener(el)
-			}
+		if c.handleSubscription(msg) {
 			continue
 		}
 
 		// handle non-subscribe calls
-		client := &http.Client{}
-		buf := &bytes.Buffer{}
-		_, err = buf.Write(mbytes)
+		err = c.forwardToRPC(mbytes)
 		if err != nil {
-			logger.Warn("failed to write message to buffer", "error", err)
 			return
 		}
+	}
+}
+
+// handleBatch handles a batch of requests. Subscription requests are handled by the websocket connection and the
+// other requests are forwarded to the rpc service, and all the responses are sent as a single array in the order of
+// the requests. Subscription notifications are only sent once the array has been sent.
+func (c *WSConn) handleBatch(mbytes []byte) error {
+	var reqs []json.RawMessage
+	err := json.Unmarshal(mbytes, &reqs)
+	if err != nil || len(reqs) == 0 {
+		logger.Warn("websocket received invalid batch request message", "error", err)
+		c.safeSendError(0, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
+		return nil
+	}
+
+	c.batch = &batchResponse{}
+	defer func() {
+		c.batch = nil
+	}()
 
-		req, err := http.NewRequest("POST", c.RPCHost, buf)
+	for _, req := range reqs {
+		var msg map[string]interface{}
+		if err = json.Unmarshal(req, &msg); err == nil && c.handleSubscription(msg) {
+			continue
+		}
+
+		// requests that aren't valid are forwarded so the rpc service responds with the appropriate error
+		res, err := c.callRPC(req)
 		if err != nil {
-			logger.Warn("failed request to rpc service", "error", err)
-			return
+			return err
 		}
 
-		req.Header.Set("Content-Type", "application/json;")
+		if res != nil {
+			c.batch.responses = append(c.batch.responses, res)
+		}
+	}
 
-		res, err := client.Do(req)
-		if err != nil {
-			logger.Warn("websocket error calling rpc", "error", err)
-			return
+	// a batch of notifications has no response
+	if len(c.batch.responses) > 0 {
+		c.safeSend(c.batch.responses)
+	}
+
+	for _, f := range c.batch.deferred {
+		f()
+	}
+
+	return nil
+}
+
+// handleSubscription handles the request if it's for a subscription method, returning true if it was handled
+func (c *WSConn) handleSubscription(msg map[string]interface{}) bool {
+	method := msg["method"]
+	params := msg["params"]
+	logger.Debug("ws method called", "method", method, "params", params)
+
+	// if method contains subscribe, then register subscription
+	if strings.Contains(fmt.Sprintf("%s", method), "subscribe") {
+		reqid, ok := c.requestID(msg)
+		if !ok {
+			return true
 		}
 
-		body, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			logger.Warn("error reading response body", "error", err)
-			return
+		switch method {
+		case "chain_subscribeNewHeads", "chain_subscribeNewHead":
+			bl, err1 := c.initBlockListener(reqid)
+			if err1 != nil {
+				logger.Warn("failed to create block listener", "error", err1)
+				c.respondError(reqid, nil, err1.Error())
+				return true
+			}
+			c.startListener(bl)
+		case "state_subscribeStorage":
+			_, err2 := c.initStorageChangeListener(reqid, params)
+			if err2 != nil {
+				logger.Warn("failed to create state change listener", "error", err2)
+				c.respondError(reqid, nil, err2.Error())
+				return true
+			}
+
+		case "chain_subscribeFinalizedHeads":
+			bfl, err3 := c.initBlockFinalizedListener(reqid)
+			if err3 != nil {
+				logger.Warn("failed to create block finalised", "error", err3)
+				c.respondError(reqid, nil, err3.Error())
+				return true
+			}
+			c.startListener(bfl)
+		case "state_subscribeRuntimeVersion":
+			rvl, err4 := c.initRuntimeVersionListener(reqid)
+			if err4 != nil {
+				logger.Warn("failed to create runtime version listener", "error", err4)
+				c.respondError(reqid, nil, err4.Error())
+				return true
+			}
+			c.startListener(rvl)
+		case "state_unsubscribeStorage":
+			c.unsubscribeStorageListener(reqid, params)
+
 		}
+		return true
+	}
 
-		err = res.Body.Close()
-		if err != nil {
-			logger.Warn("error closing response body", "error", err)
-			return
+	if strings.Contains(fmt.Sprintf("%s", method), "submitAndWatchExtrinsic") {
+		reqid, ok := c.requestID(msg)
+		if !ok {
+			return true
 		}
-		var wsSend interface{}
-		err = json.Unmarshal(body, &wsSend)
-		if err != nil {
-			logger.Warn("error unmarshal rpc response", "error", err)
-			return
+
+		el, e := c.initExtrinsicWatch(reqid, params)
+		if e != nil {
+			c.respondError(reqid, nil, e.Error())
+		} else {
+			c.startListener(el)
+		}
+		return true
+	}
+
+	if method == "author_unwatchExtrinsic" {
+		reqid, ok := c.requestID(msg)
+		if ok {
+			c.unwatchExtrinsic(reqid, params)
 		}
+		return true
+	}
+
+	return false
+}
 
-		c.safeSend(wsSend)
+// requestID returns the id of the request. If the id isn't a number, it responds with an invalid request error and
+// returns false.
+func (c *WSConn) requestID(msg map[string]interface{}) (float64, bool) {
+	id, ok := msg["id"].(float64)
+	if !ok {
+		c.respondError(0, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
 	}
+
+	return id, ok
+}
+
+// forwardToRPC sends the request to the rpc service and sends its response over the websocket connection
+func (c *WSConn) forwardToRPC(mbytes []byte) error {
+	res, err := c.callRPC(mbytes)
+	if err != nil || res == nil {
+		return err
+	}
+
+	c.safeSend(res)
+	return nil
+}
+
+// callRPC sends the request to the rpc service and returns its response, which is nil for notifications
+func (c *WSConn) callRPC(mbytes []byte) (interface{}, error) {
+	client := &http.Client{}
+	buf := &bytes.Buffer{}
+	_, err := buf.Write(mbytes)
+	if err != nil {
+		logger.Warn("failed to write message to buffer", "error", err)
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", c.RPCHost, buf)
+	if err != nil {
+		logger.Warn("failed request to rpc service", "error", err)
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json;")
+
+	res, err := client.Do(req)
+	if err != nil {
+		logger.Warn("websocket error calling rpc", "error", err)
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		logger.Warn("error reading response body", "error", err)
+		return nil, err
+	}
+
+	err = res.Body.Close()
+	if err != nil {
+		logger.Warn("error closing response body", "error", err)
+		return nil, err
+	}
+
+	// notifications don't have a response
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	var wsSend interface{}
+	err = json.Unmarshal(body, &wsSend)
+	if err != nil {
+		logger.Warn("error unmarshal rpc response", "error", err)
+		return nil, err
+	}
+
+	return wsSend, nil
 }
 
 func (c *WSConn) initStorageChangeListener(reqID float64, params interface{}) (uint, error) {
 	if c.StorageAPI == nil {
-		c.safeSendError(reqID, nil, "error StorageAPI not set")
 		return 0, fmt.Errorf("error StorageAPI not set")
 	}
 
 	myObs := &StorageObserver{
-		filter: make(map[string][]byte),
+		filter: make(map[string]struct{}),
 		wsconn: c,
 	}
 
@@ -195,10 +323,16 @@ func (c *WSConn) initStorageChangeListener(reqID float64, params interface{}) (u
 				if !ok {
 					return 0, fmt.Errorf("unknown parameter type")
 				}
-				myObs.filter[data] = []byte{}
+				if _, err := common.HexToBytes(data); err != nil {
+					return 0, err
+				}
+				myObs.filter[data] = struct{}{}
 			}
 		case string:
-			myObs.filter[p] = []byte{}
+			if _, err := common.HexToBytes(p); err != nil {
+				return 0, err
+			}
+			myObs.filter[p] = struct{}{}
 		default:
 			return 0, fmt.Errorf("unknown parameter type")
 		}
@@ -207,53 +341,82 @@ func (c *WSConn) initStorageChangeListener(reqID float64, params interface{}) (u
 	c.qtyListeners++
 	myObs.id = c.qtyListeners
 
-	c.StorageAPI.RegisterStorageObserver(myObs)
-
-	c.Subscriptions[myObs.id] = myObs
+	c.setSubscription(myObs.id, myObs)
 
 	initRes := NewSubscriptionResponseJSON(myObs.id, reqID)
-	c.safeSend(initRes)
+	c.respond(initRes)
+
+	// registering sends the initial values of the keys, so it's done after the subscription id has been sent
+	c.afterResponse(func() {
+		c.StorageAPI.RegisterStorageObserver(myObs)
+	})
 
 	return myObs.id, nil
 }
 
 func (c *WSConn) unsubscribeStorageListener(reqID float64, params interface{}) {
+	id, ok := c.subscriptionID(reqID, params)
+	if !ok {
+		return
+	}
+
+	observer, ok := c.getSubscription(id).(state.Observer)
+	if !ok {
+		initRes := newBooleanResponseJSON(false, reqID)
+		c.respond(initRes)
+		return
+	}
+
+	c.removeSubscription(id)
+	c.StorageAPI.UnregisterStorageObserver(observer)
+	c.respond(newBooleanResponseJSON(true, reqID))
+}
+
+// unwatchExtrinsic ends the author_submitAndWatchExtrinsic subscription with the id in the params
+func (c *WSConn) unwatchExtrinsic(reqID float64, params interface{}) {
+	id, ok := c.subscriptionID(reqID, params)
+	if !ok {
+		return
+	}
+
+	esl, ok := c.getSubscription(id).(*ExtrinsicSubmitListener)
+	if !ok {
+		c.respond(newBooleanResponseJSON(false, reqID))
+		return
+	}
+
+	c.removeSubscription(id)
+	c.respond(newBooleanResponseJSON(esl.Stop(), reqID))
+}
+
+// subscriptionID returns the subscription id in the params of an unsubscribe request. If the params are invalid, it
+// sends the response and returns false.
+func (c *WSConn) subscriptionID(reqID float64, params interface{}) (uint, bool) {
 	switch v := params.(type) {
 	case []interface{}:
 		if len(v) == 0 {
-			c.safeSendError(reqID, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
-			return
+			c.respondError(reqID, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
+			return 0, false
 		}
 	default:
-		c.safeSendError(reqID, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
-		return
+		c.respondError(reqID, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
+		return 0, false
 	}
 
-	var id uint
 	switch v := params.([]interface{})[0].(type) {
 	case float64:
-		id = uint(v)
+		return uint(v), true
 	case string:
 		i, err := strconv.ParseUint(v, 10, 32)
 		if err != nil {
-			c.safeSend(newBooleanResponseJSON(false, reqID))
-			return
+			c.respond(newBooleanResponseJSON(false, reqID))
+			return 0, false
 		}
-		id = uint(i)
+		return uint(i), true
 	default:
-		c.safeSendError(reqID, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
-		return
+		c.respondError(reqID, big.NewInt(InvalidRequestCode), InvalidRequestMessage)
+		return 0, false
 	}
-
-	observer, ok := c.Subscriptions[id].(state.Observer)
-	if !ok {
-		initRes := newBooleanResponseJSON(false, reqID)
-		c.safeSend(initRes)
-		return
-	}
-
-	c.StorageAPI.UnregisterStorageObserver(observer)
-	c.safeSend(newBooleanResponseJSON(true, reqID))
 }
 
 func (c *WSConn) initBlockListener(reqID float64) (uint, error) {
@@ -263,7 +426,6 @@ func (c *WSConn) initBlockListener(reqID float64) (uint, error) {
 	}
 
 	if c.BlockAPI == nil {
-		c.safeSendError(reqID, nil, "error BlockAPI not set")
 		return 0, fmt.Errorf("error BlockAPI not set")
 	}
 	chanID, err := c.BlockAPI.RegisterImportedChannel(bl.Channel)
@@ -273,10 +435,10 @@ func (c *WSConn) initBlockListener(reqID float64) (uint, error) {
 	bl.ChanID = chanID
 	c.qtyListeners++
 	bl.subID = c.qtyListeners
-	c.Subscriptions[bl.subID] = bl
+	c.setSubscription(bl.subID, bl)
 	c.BlockSubChannels[bl.subID] = chanID
 	initRes := NewSubscriptionResponseJSON(bl.subID, reqID)
-	c.safeSend(initRes)
+	c.respond(initRes)
 
 	return bl.subID, nil
 }
@@ -288,7 +450,6 @@ func (c *WSConn) initBlockFinalizedListener(reqID float64) (uint, error) {
 	}
 
 	if c.BlockAPI == nil {
-		c.safeSendError(reqID, nil, "error BlockAPI not set")
 		return 0, fmt.Errorf("error BlockAPI not set")
 	}
 	chanID, err := c.BlockAPI.RegisterFinalizedChannel(bfl.channel)
@@ -298,61 +459,56 @@ func (c *WSConn) initBlockFinalizedListener(reqID float64) (uint, error) {
 	bfl.chanID = chanID
 	c.qtyListeners++
 	bfl.subID = c.qtyListeners
-	c.Subscriptions[bfl.subID] = bfl
+	c.setSubscription(bfl.subID, bfl)
 	c.BlockSubChannels[bfl.subID] = chanID
 	initRes := NewSubscriptionResponseJSON(bfl.subID, reqID)
-	c.safeSend(initRes)
+	c.respond(initRes)
 
 	return bfl.subID, nil
 }
 
 func (c *WSConn) initExtrinsicWatch(reqID float64, params interface{}) (uint, error) {
-	pA := params.([]interface{})
-	extBytes, err := common.HexToBytes(pA[0].(string))
-	if err != nil {
-		return 0, err
+	pA, ok := params.([]interface{})
+	if !ok || len(pA) == 0 {
+		return 0, fmt.Errorf("unknown parameter type")
 	}
 
-	// listen for built blocks
-	esl := &ExtrinsicSubmitListener{
-		importedChan:  make(chan *types.Block),
-		wsconn:        c,
-		extrinsic:     types.Extrinsic(extBytes),
-		finalisedChan: make(chan *types.FinalisationInfo),
+	ext, ok := pA[0].(string)
+	if !ok {
+		return 0, fmt.Errorf("unknown parameter type")
 	}
 
-	if c.BlockAPI == nil {
-		return 0, fmt.Errorf("error BlockAPI not set")
-	}
-	esl.importedChanID, err = c.BlockAPI.RegisterImportedChannel(esl.importedChan)
+	extBytes, err := common.HexToBytes(ext)
 	if err != nil {
 		return 0, err
 	}
 
-	esl.finalisedChanID, err = c.BlockAPI.RegisterFinalizedChannel(esl.finalisedChan)
-	if err != nil {
-		return 0, err
+	if c.TxStateAPI == nil {
+		return 0, fmt.Errorf("error TransactionStateAPI not set")
 	}
 
-	c.qtyListeners++
-	esl.subID = c.qtyListeners
-	c.Subscriptions[esl.subID] = esl
-	c.BlockSubChannels[esl.subID] = esl.importedChanID
+	esl := &ExtrinsicSubmitListener{
+		wsconn:     c,
+		extrinsic:  types.Extrinsic(extBytes),
+		txStateAPI: c.TxStateAPI,
+		statusChan: make(chan *transaction.StatusNotification),
+	}
+
+	// the status channel is registered before the extrinsic is submitted, so that none of its statuses are missed
+	esl.statusChanID = c.TxStateAPI.RegisterStatusChannel(esl.extrinsic.Hash(), esl.statusChan)
 
 	err = c.CoreAPI.HandleSubmittedExtrinsic(extBytes)
 	if err != nil {
+		esl.Stop()
 		return 0, err
 	}
-	c.safeSend(NewSubscriptionResponseJSON(esl.subID, reqID))
 
-	// TODO (ed) since HandleSubmittedExtrinsic has been called we assume the extrinsic is in the tx queue
-	//  should we add a channel to tx queue so we're notified when it's in the queue (See issue #1535)
-	if c.CoreAPI.IsBlockProducer() {
-		c.safeSend(newSubscriptionResponse(AuthorExtrinsicUpdates, esl.subID, "ready"))
-	}
+	c.qtyListeners++
+	esl.subID = c.qtyListeners
+	c.setSubscription(esl.subID, esl)
+	c.respond(NewSubscriptionResponseJSON(esl.subID, reqID))
 
-	// todo (ed) determine which peer extrinsic has been broadcast to, and set status
-	return esl.subID, err
+	return esl.subID, nil
 }
 
 func (c *WSConn) initRuntimeVersionListener(reqID float64) (uint, error) {
@@ -360,14 +516,13 @@ func (c *WSConn) initRuntimeVersionListener(reqID float64) (uint, error) {
 		wsconn: c,
 	}
 	if c.CoreAPI == nil {
-		c.safeSendError(reqID, nil, "error CoreAPI not set")
 		return 0, fmt.Errorf("error CoreAPI not set")
 	}
 	c.qtyListeners++
 	rvl.subID = c.qtyListeners
-	c.Subscriptions[rvl.subID] = rvl
+	c.setSubscription(rvl.subID, rvl)
 	initRes := NewSubscriptionResponseJSON(rvl.subID, reqID)
-	c.safeSend(initRes)
+	c.respond(initRes)
 
 	return rvl.subID, nil
 }
@@ -381,7 +536,36 @@ func (c *WSConn) safeSend(msg interface{}) {
 	}
 }
 func (c *WSConn) safeSendError(reqID float64, errorCode *big.Int, message string) {
-	res := &ErrorResponseJSON{
+	c.safeSend(newErrorResponseJSON(reqID, errorCode, message))
+}
+
+// respond sends the response to a request, or collects it if the request is part of a batch
+func (c *WSConn) respond(msg interface{}) {
+	if c.batch != nil {
+		c.batch.responses = append(c.batch.responses, msg)
+		return
+	}
+
+	c.safeSend(msg)
+}
+
+func (c *WSConn) respondError(reqID float64, errorCode *big.Int, message string) {
+	c.respond(newErrorResponseJSON(reqID, errorCode, message))
+}
+
+// afterResponse calls f once the response to the request has been sent, so that the notifications of a subscription
+// never precede its subscription id
+func (c *WSConn) afterResponse(f func()) {
+	if c.batch != nil {
+		c.batch.deferred = append(c.batch.deferred, f)
+		return
+	}
+
+	f()
+}
+
+func newErrorResponseJSON(reqID float64, errorCode *big.Int, message string) *ErrorResponseJSON {
+	return &ErrorResponseJSON{
 		Jsonrpc: "2.0",
 		Error: &ErrorMessageJSON{
 			Code:    errorCode,
@@ -389,12 +573,6 @@ func (c *WSConn) safeSendError(reqID float64, errorCode *big.Int, message string
 		},
 		ID: reqID,
 	}
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	err := c.Wsconn.WriteJSON(res)
-	if err != nil {
-		logger.Debug("error sending websocket message", "error", err)
-	}
 }
 
 // ErrorResponseJSON json for error responses
@@ -411,5 +589,39 @@ type ErrorMessageJSON struct {
 }
 
 func (c *WSConn) startListener(lid uint) {
-	go c.Subscriptions[lid].Listen()
+	l := c.getSubscription(lid)
+	c.afterResponse(func() {
+		go l.Listen()
+	})
+}
+
+// Listeners returns the listeners of the active subscriptions of the connection
+func (c *WSConn) Listeners() []Listener {
+	c.subscriptionsLock.RLock()
+	defer c.subscriptionsLock.RUnlock()
+
+	listeners := make([]Listener, 0, len(c.Subscriptions))
+	for _, l := range c.Subscriptions {
+		listeners = append(listeners, l)
+	}
+	return listeners
+}
+
+func (c *WSConn) getSubscription(id uint) Listener {
+	c.subscriptionsLock.RLock()
+	defer c.subscriptionsLock.RUnlock()
+	return c.Subscriptions[id]
+}
+
+func (c *WSConn) setSubscription(id uint, l Listener) {
+	c.subscriptionsLock.Lock()
+	defer c.subscriptionsLock.Unlock()
+	c.Subscriptions[id] = l
+}
+
+// removeSubscription removes the subscription with the given id from the active subscriptions of the connection
+func (c *WSConn) removeSubscription(id uint) {
+	c.subscriptionsLock.Lock()
+	defer c.subscriptionsLock.Unlock()
+	delete(c.Subscriptions, id)
 }
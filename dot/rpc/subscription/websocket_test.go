@@ -13,6 +13,7 @@ import (
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto"
 	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +63,8 @@ func TestWSConn_HandleComm(t *testing.T) {
 	res, err := wsconn.initStorageChangeListener(1, nil)
 	require.EqualError(t, err, "error StorageAPI not set")
 	require.Equal(t, uint(0), res)
+
+	c.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"state_subscribeStorage","params":[],"id":1}`))
 	_, msg, err := c.ReadMessage()
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"jsonrpc":"2.0","error":{"code":null,"message":"error StorageAPI not set"},"id":1}`+"\n"), msg)
@@ -149,6 +152,7 @@ func TestWSConn_HandleComm(t *testing.T) {
 	_, msg, err = c.ReadMessage()
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":true,"id":7}`+"\n"), msg)
+	require.Nil(t, wsconn.getSubscription(4))
 
 	c.WriteMessage(websocket.TextMessage, []byte(`{
     "jsonrpc": "2.0",
@@ -159,6 +163,7 @@ func TestWSConn_HandleComm(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":false,"id":7}`+"\n"), msg)
 
+	// the subscription was already removed
 	c.WriteMessage(websocket.TextMessage, []byte(`{
     "jsonrpc": "2.0",
     "method": "state_unsubscribeStorage",
@@ -166,12 +171,14 @@ func TestWSConn_HandleComm(t *testing.T) {
     "id": 7}`))
 	_, msg, err = c.ReadMessage()
 	require.NoError(t, err)
-	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":true,"id":7}`+"\n"), msg)
+	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":false,"id":7}`+"\n"), msg)
 
 	// test initBlockListener
 	res, err = wsconn.initBlockListener(1)
 	require.EqualError(t, err, "error BlockAPI not set")
 	require.Equal(t, uint(0), res)
+
+	c.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"chain_subscribeNewHeads","params":[],"id":1}`))
 	_, msg, err = c.ReadMessage()
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"jsonrpc":"2.0","error":{"code":null,"message":"error BlockAPI not set"},"id":1}`+"\n"), msg)
@@ -201,6 +208,9 @@ func TestWSConn_HandleComm(t *testing.T) {
 	res, err = wsconn.initBlockFinalizedListener(1)
 	require.EqualError(t, err, "error BlockAPI not set")
 	require.Equal(t, uint(0), res)
+
+	c.WriteMessage(websocket.TextMessage,
+		[]byte(`{"jsonrpc":"2.0","method":"chain_subscribeFinalizedHeads","params":[],"id":1}`))
 	_, msg, err = c.ReadMessage()
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"jsonrpc":"2.0","error":{"code":null,"message":"error BlockAPI not set"},"id":1}`+"\n"), msg)
@@ -216,20 +226,77 @@ func TestWSConn_HandleComm(t *testing.T) {
 
 	// test initExtrinsicWatch
 	wsconn.CoreAPI = new(MockCoreAPI)
-	wsconn.BlockAPI = nil
 	res, err = wsconn.initExtrinsicWatch(0, []interface{}{"NotHex"})
 	require.EqualError(t, err, "could not byteify non 0x prefixed string")
 	require.Equal(t, uint(0), res)
 
 	res, err = wsconn.initExtrinsicWatch(0, []interface{}{"0x26aa"})
-	require.EqualError(t, err, "error BlockAPI not set")
+	require.EqualError(t, err, "error TransactionStateAPI not set")
 	require.Equal(t, uint(0), res)
 
-	wsconn.BlockAPI = new(MockBlockAPI)
+	wsconn.TxStateAPI = new(MockTransactionStateAPI)
 	res, err = wsconn.initExtrinsicWatch(0, []interface{}{"0x26aa"})
 	require.NoError(t, err)
 	require.Equal(t, uint(8), res)
+	_, msg, err = c.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":8,"id":0}`+"\n"), msg)
+
+	// test author_unwatchExtrinsic
+	c.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"author_unwatchExtrinsic","params":[8],"id":9}`))
+	_, msg, err = c.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":true,"id":9}`+"\n"), msg)
+
+	c.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"author_unwatchExtrinsic","params":[8],"id":10}`))
+	_, msg, err = c.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{"jsonrpc":"2.0","result":false,"id":10}`+"\n"), msg)
+
+	// test batch requests, the responses are sent in the order of the requests
+	c.WriteMessage(websocket.TextMessage, []byte(`[
+		{"jsonrpc":"2.0","method":"chain_subscribeNewHeads","params":[],"id":11},
+		{"jsonrpc":"2.0","method":"author_unwatchExtrinsic","params":[9],"id":12},
+		{"jsonrpc":"2.0","method":"chain_subscribeFinalizedHeads","params":[],"id":13}
+	]`))
+	_, msg, err = c.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, []byte(`[{"jsonrpc":"2.0","result":9,"id":11},{"jsonrpc":"2.0","result":false,"id":12},`+
+		`{"jsonrpc":"2.0","result":10,"id":13}]`+"\n"), msg)
+
+	// test requests without a numeric id
+	for _, req := range []string{
+		`{"jsonrpc":"2.0","method":"chain_subscribeNewHeads","params":[]}`,
+		`{"jsonrpc":"2.0","method":"author_submitAndWatchExtrinsic","params":["0x26aa"],"id":"14"}`,
+		`{"jsonrpc":"2.0","method":"author_unwatchExtrinsic","params":[9],"id":null}`,
+	} {
+		c.WriteMessage(websocket.TextMessage, []byte(req))
+		_, msg, err = c.ReadMessage()
+		require.NoError(t, err)
+		require.Equal(t, []byte(`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request"},"id":0}`+"\n"), msg)
+	}
 
+	res, err = wsconn.initExtrinsicWatch(0, "0x26aa")
+	require.EqualError(t, err, "unknown parameter type")
+	require.Equal(t, uint(0), res)
+
+	c.WriteMessage(websocket.TextMessage, []byte(`[]`))
+	_, msg, err = c.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request"},"id":0}`+"\n"), msg)
+
+	// a subscription that fails in a batch has an error response
+	wsconn.BlockAPI = nil
+	c.WriteMessage(websocket.TextMessage, []byte(`[
+		{"jsonrpc":"2.0","method":"chain_subscribeNewHeads","params":[],"id":15},
+		{"jsonrpc":"2.0","method":"state_subscribeStorage","params":[1],"id":16},
+		{"jsonrpc":"2.0","method":"author_unwatchExtrinsic","params":[9],"id":17}
+	]`))
+	_, msg, err = c.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, []byte(`[{"jsonrpc":"2.0","error":{"code":null,"message":"error BlockAPI not set"},"id":15},`+
+		`{"jsonrpc":"2.0","error":{"code":null,"message":"unknown parameter type"},"id":16},`+
+		`{"jsonrpc":"2.0","result":false,"id":17}]`+"\n"), msg)
 }
 
 type MockStorageAPI struct{}
@@ -255,6 +322,10 @@ func (m *MockStorageAPI) GetKeysWithPrefix(root *common.Hash, prefix []byte) ([]
 	return nil, nil
 }
 
+func (m *MockStorageAPI) GenerateTrieProof(stateRoot common.Hash, keys [][]byte) ([][]byte, error) {
+	return nil, nil
+}
+
 type MockBlockAPI struct {
 }
 
@@ -295,6 +366,18 @@ func (m *MockBlockAPI) SubChain(start, end common.Hash) ([]common.Hash, error) {
 	return make([]common.Hash, 0), nil
 }
 
+func (m *MockBlockAPI) SetFinalizedHash(hash common.Hash, round, setID uint64) error {
+	return nil
+}
+
+func (m *MockBlockAPI) GetRound() (uint64, error) {
+	return 0, nil
+}
+
+func (m *MockBlockAPI) IsDescendantOf(parent, child common.Hash) (bool, error) {
+	return false, nil
+}
+
 type MockCoreAPI struct{}
 
 func (m *MockCoreAPI) InsertKey(kp crypto.Keypair) {}
@@ -318,3 +401,31 @@ func (m *MockCoreAPI) HandleSubmittedExtrinsic(types.Extrinsic) error {
 func (m *MockCoreAPI) GetMetadata(bhash *common.Hash) ([]byte, error) {
 	return nil, nil
 }
+
+func (m *MockCoreAPI) CallRuntime(bhash *common.Hash, method string, data []byte) ([]byte, error) {
+	return nil, nil
+}
+
+type MockTransactionStateAPI struct{}
+
+func (m *MockTransactionStateAPI) AddToPool(vt *transaction.ValidTransaction) common.Hash {
+	return common.Hash{}
+}
+
+func (m *MockTransactionStateAPI) Pop() *transaction.ValidTransaction {
+	return nil
+}
+
+func (m *MockTransactionStateAPI) Peek() *transaction.ValidTransaction {
+	return nil
+}
+
+func (m *MockTransactionStateAPI) Pending() []*transaction.ValidTransaction {
+	return nil
+}
+
+func (m *MockTransactionStateAPI) RegisterStatusChannel(hash common.Hash, ch chan<- *transaction.StatusNotification) uint64 {
+	return 0
+}
+
+func (m *MockTransactionStateAPI) UnregisterStatusChannel(id uint64) {}
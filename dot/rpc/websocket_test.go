@@ -137,6 +137,18 @@ func (m *MockBlockAPI) SubChain(start, end common.Hash) ([]common.Hash, error) {
 	return make([]common.Hash, 0), nil
 }
 
+func (m *MockBlockAPI) SetFinalizedHash(hash common.Hash, round, setID uint64) error {
+	return nil
+}
+
+func (m *MockBlockAPI) GetRound() (uint64, error) {
+	return 0, nil
+}
+
+func (m *MockBlockAPI) IsDescendantOf(parent, child common.Hash) (bool, error) {
+	return false, nil
+}
+
 type MockStorageAPI struct{}
 
 func (m *MockStorageAPI) GetStorage(_ *common.Hash, key []byte) ([]byte, error) {
@@ -159,3 +171,6 @@ func (m *MockStorageAPI) GetStateRootFromBlock(bhash *common.Hash) (*common.Hash
 func (m *MockStorageAPI) GetKeysWithPrefix(root *common.Hash, prefix []byte) ([][]byte, error) {
 	return nil, nil
 }
+func (m *MockStorageAPI) GenerateTrieProof(stateRoot common.Hash, keys [][]byte) ([][]byte, error) {
+	return nil, nil
+}
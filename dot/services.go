@@ -41,6 +41,7 @@ import (
 	"github.com/ChainSafe/gossamer/lib/runtime/life"
 	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
 	"github.com/ChainSafe/gossamer/lib/runtime/wasmtime"
+	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/ChainSafe/gossamer/lib/utils"
 )
 
@@ -61,6 +62,15 @@ func createStateService(cfg *Config) (*state.Service, error) {
 		return nil, fmt.Errorf("failed to start state service: %s", err)
 	}
 
+	stateSrvc.Transaction.SetLimits(transactionLimits(cfg))
+
+	if cfg.Core.TxPoolJournal {
+		err = stateSrvc.Transaction.EnableJournal(stateSrvc.DB())
+		if err != nil {
+			return nil, fmt.Errorf("failed to enable transaction journal: %w", err)
+		}
+	}
+
 	if cfg.State.Rewind != 0 {
 		err = stateSrvc.Rewind(int64(cfg.State.Rewind))
 		if err != nil {
@@ -83,6 +93,25 @@ func createStateService(cfg *Config) (*state.Service, error) {
 	return stateSrvc, nil
 }
 
+// transactionLimits returns the limits of the transaction queue set in the core config, using the default for the
+// limits that aren't set
+func transactionLimits(cfg *Config) transaction.Limits {
+	limits := transaction.DefaultLimits
+	if cfg.Core.TxPoolReadyLimit != 0 {
+		limits.ReadyCount = int(cfg.Core.TxPoolReadyLimit)
+	}
+	if cfg.Core.TxPoolReadyKBytes != 0 {
+		limits.ReadyBytes = int(cfg.Core.TxPoolReadyKBytes) * 1024
+	}
+	if cfg.Core.TxPoolFutureLimit != 0 {
+		limits.FutureCount = int(cfg.Core.TxPoolFutureLimit)
+	}
+	if cfg.Core.TxPoolFutureKBytes != 0 {
+		limits.FutureBytes = int(cfg.Core.TxPoolFutureKBytes) * 1024
+	}
+	return limits
+}
+
 func createRuntime(cfg *Config, st *state.Service, ks *keystore.GlobalKeystore, net *network.Service) (runtime.Instance, error) {
 	logger.Info(
 		"creating runtime...",
@@ -107,62 +136,73 @@ func createRuntime(cfg *Config, st *state.Service, ks *keystore.GlobalKeystore,
 
 	ns := runtime.NodeStorage{
 		LocalStorage:      localStorage,
-		PersistentStorage: chaindb.NewTable(st.DB(), "offlinestorage"),
+		PersistentStorage: chaindb.NewTable(st.DB(), state.OffchainStoragePrefix),
+	}
+
+	rtCfg := runtime.InstanceConfig{
+		Storage:     ts,
+		Keystore:    ks,
+		LogLvl:      cfg.Log.RuntimeLvl,
+		NodeStorage: ns,
+		Network:     net,
+		Role:        cfg.Core.Roles,
+		Transaction: st.Transaction,
+		// writes made during block execution are buffered in the TrieState and committed when the block is imported
+		OffchainIndexing: cfg.Core.OffchainIndexing,
+	}
+
+	// create runtime executor
+	rt, err := newRuntimeInstance(cfg.Core.WasmInterpreter, code, rtCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runtime executor: %s", err)
+	}
+
+	return rt, nil
+}
+
+// createRuntimeRegistry creates the registry of runtime instances used for calls at the state of any block. The
+// instances share the node's offchain storage, keystore and transaction pool with the given runtime instance.
+func createRuntimeRegistry(cfg *Config, st *state.Service, ks *keystore.GlobalKeystore, net *network.Service, rt runtime.Instance) *runtime.Registry {
+	rtCfg := runtime.InstanceConfig{
+		Keystore:         ks,
+		LogLvl:           cfg.Log.RuntimeLvl,
+		NodeStorage:      rt.NodeStorage(),
+		Network:          net,
+		Role:             cfg.Core.Roles,
+		Transaction:      st.Transaction,
+		OffchainIndexing: cfg.Core.OffchainIndexing,
 	}
 
-	var rt runtime.Instance
-	switch cfg.Core.WasmInterpreter {
+	return runtime.NewRegistry(runtime.DefaultRegistryCapacity, func(code []byte, s runtime.Storage) (runtime.Instance, error) {
+		c := rtCfg
+		c.Storage = s
+		return newRuntimeInstance(cfg.Core.WasmInterpreter, code, c)
+	})
+}
+
+// newRuntimeInstance creates a runtime instance with the given code and configuration using the given wasm interpreter
+func newRuntimeInstance(interpreter string, code []byte, rtCfg runtime.InstanceConfig) (runtime.Instance, error) {
+	switch interpreter {
 	case wasmer.Name:
-		rtCfg := &wasmer.Config{
-			Imports: wasmer.ImportsNodeRuntime,
-		}
-		rtCfg.Storage = ts
-		rtCfg.Keystore = ks
-		rtCfg.LogLvl = cfg.Log.RuntimeLvl
-		rtCfg.NodeStorage = ns
-		rtCfg.Network = net
-		rtCfg.Role = cfg.Core.Roles
-
-		// create runtime executor
-		rt, err = wasmer.NewInstance(code, rtCfg)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create runtime executor: %s", err)
+		cfg := &wasmer.Config{
+			InstanceConfig: rtCfg,
+			Imports:        wasmer.ImportsNodeRuntime,
 		}
+		return wasmer.NewInstance(code, cfg)
 	case wasmtime.Name:
-		rtCfg := &wasmtime.Config{
-			Imports: wasmtime.ImportNodeRuntime,
-		}
-		rtCfg.Storage = ts
-		rtCfg.Keystore = ks
-		rtCfg.LogLvl = cfg.Log.RuntimeLvl
-		rtCfg.NodeStorage = ns
-		rtCfg.Network = net
-		rtCfg.Role = cfg.Core.Roles
-
-		// create runtime executor
-		rt, err = wasmtime.NewInstance(code, rtCfg)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create runtime executor: %s", err)
+		cfg := &wasmtime.Config{
+			InstanceConfig: rtCfg,
+			Imports:        wasmtime.ImportNodeRuntime,
 		}
+		return wasmtime.NewInstance(code, cfg)
 	case life.Name:
-		rtCfg := &life.Config{
-			Resolver: new(life.Resolver),
-		}
-		rtCfg.Storage = ts
-		rtCfg.Keystore = ks
-		rtCfg.LogLvl = cfg.Log.RuntimeLvl
-		rtCfg.NodeStorage = ns
-		rtCfg.Network = net
-		rtCfg.Role = cfg.Core.Roles
-
-		// create runtime executor
-		rt, err = life.NewInstance(code, rtCfg)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create runtime executor: %s", err)
+		cfg := &life.Config{
+			InstanceConfig: rtCfg,
 		}
+		return life.NewInstance(code, cfg)
+	default:
+		return nil, fmt.Errorf("unknown wasm interpreter: %s", interpreter)
 	}
-
-	return rt, nil
 }
 
 func createBABEService(cfg *Config, rt runtime.Instance, st *state.Service, ks keystore.Keystore) (*babe.Service, error) {
@@ -211,7 +251,7 @@ func createBABEService(cfg *Config, rt runtime.Instance, st *state.Service, ks k
 // Core Service
 
 // createCoreService creates the core service from the provided core configuration
-func createCoreService(cfg *Config, bp core.BlockProducer, verifier *babe.VerificationManager, rt runtime.Instance, ks *keystore.GlobalKeystore, stateSrvc *state.Service, net *network.Service) (*core.Service, error) {
+func createCoreService(cfg *Config, bp core.BlockProducer, verifier *babe.VerificationManager, rt runtime.Instance, runtimes *runtime.Registry, ks *keystore.GlobalKeystore, stateSrvc *state.Service, net *network.Service) (*core.Service, error) {
 	logger.Debug(
 		"creating core service...",
 		"authority", cfg.Core.Roles == types.AuthorityRole,
@@ -227,9 +267,11 @@ func createCoreService(cfg *Config, bp core.BlockProducer, verifier *babe.Verifi
 		BlockProducer:    bp,
 		Keystore:         ks,
 		Runtime:          rt,
+		Runtimes:         runtimes,
 		IsBlockProducer:  cfg.Core.BabeAuthority,
 		Verifier:         verifier,
 		Network:          net,
+		OffchainWorkers:  offchainWorkersEnabled(cfg),
 	}
 
 	// create new core service
@@ -242,6 +284,19 @@ func createCoreService(cfg *Config, bp core.BlockProducer, verifier *babe.Verifi
 	return coreSrvc, nil
 }
 
+// offchainWorkersEnabled returns true if the runtime's offchain workers should be run, based on the configured option
+// and the node's role
+func offchainWorkersEnabled(cfg *Config) bool {
+	switch cfg.Core.OffchainWorker {
+	case OffchainWorkerAlways:
+		return true
+	case OffchainWorkerNever:
+		return false
+	default:
+		return cfg.Core.Roles == types.AuthorityRole
+	}
+}
+
 // Network Service
 
 // createNetworkService creates a network service from the command configuration and genesis data
@@ -260,6 +315,7 @@ func createNetworkService(cfg *Config, stateSrvc *state.Service) (*network.Servi
 	networkConfig := network.Config{
 		LogLvl:          cfg.Log.NetworkLvl,
 		BlockState:      stateSrvc.Block,
+		StorageState:    stateSrvc.Storage,
 		BasePath:        cfg.Global.BasePath,
 		Roles:           cfg.Core.Roles,
 		Port:            cfg.Network.Port,
@@ -285,7 +341,7 @@ func createNetworkService(cfg *Config, stateSrvc *state.Service) (*network.Servi
 // RPC Service
 
 // createRPCService creates the RPC service from the provided core configuration
-func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Service, networkSrvc *network.Service, bp modules.BlockProducerAPI, rt runtime.Instance, sysSrvc *system.Service) *rpc.HTTPServer {
+func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Service, networkSrvc *network.Service, bp modules.BlockProducerAPI, rt runtime.Instance, sysSrvc *system.Service, ks keystore.Keystore) *rpc.HTTPServer {
 	logger.Info(
 		"creating rpc service...",
 		"host", cfg.RPC.Host,
@@ -295,9 +351,16 @@ func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Serv
 		"ws", cfg.RPC.WS,
 		"ws port", cfg.RPC.WSPort,
 		"ws external", cfg.RPC.WSExternal,
+		"unsafe", cfg.RPC.Unsafe,
 	)
 	rpcService := rpc.NewService()
 
+	var ns *runtime.NodeStorage
+	if rt != nil {
+		nodeStorage := rt.NodeStorage()
+		ns = &nodeStorage
+	}
+
 	rpcConfig := &rpc.HTTPServerConfig{
 		LogLvl:              cfg.Log.RPCLvl,
 		BlockAPI:            stateSrvc.Block,
@@ -307,6 +370,7 @@ func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Serv
 		BlockProducerAPI:    bp,
 		RuntimeAPI:          rt,
 		TransactionQueueAPI: stateSrvc.Transaction,
+		GrandpaStateAPI:     stateSrvc.Grandpa,
 		RPCAPI:              rpcService,
 		SystemAPI:           sysSrvc,
 		External:            cfg.RPC.External,
@@ -316,6 +380,9 @@ func createRPCService(cfg *Config, stateSrvc *state.Service, coreSrvc *core.Serv
 		WSExternal:          cfg.RPC.WSExternal,
 		WSPort:              cfg.RPC.WSPort,
 		Modules:             cfg.RPC.Modules,
+		RPCUnsafe:           cfg.RPC.Unsafe,
+		NodeStorage:         ns,
+		BabeKeystore:        ks,
 	}
 
 	return rpc.NewHTTPServer(rpcConfig)
@@ -376,7 +443,7 @@ func createBlockVerifier(st *state.Service) (*babe.VerificationManager, error) {
 	return ver, nil
 }
 
-func createSyncService(cfg *Config, st *state.Service, bp sync.BlockProducer, fg sync.FinalityGadget, dh *core.DigestHandler, verifier *babe.VerificationManager, rt runtime.Instance) (*sync.Service, error) {
+func createSyncService(cfg *Config, st *state.Service, bp sync.BlockProducer, fg sync.FinalityGadget, dh *core.DigestHandler, verifier *babe.VerificationManager, rt runtime.Instance, runtimes *runtime.Registry) (*sync.Service, error) {
 	syncCfg := &sync.Config{
 		LogLvl:           cfg.Log.SyncLvl,
 		BlockState:       st.Block,
@@ -386,6 +453,7 @@ func createSyncService(cfg *Config, st *state.Service, bp sync.BlockProducer, fg
 		FinalityGadget:   fg,
 		Verifier:         verifier,
 		Runtime:          rt,
+		Runtimes:         runtimes,
 		DigestHandler:    dh,
 	}
 
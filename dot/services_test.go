@@ -25,6 +25,7 @@ import (
 	"github.com/ChainSafe/gossamer/dot/network"
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/ChainSafe/gossamer/lib/utils"
 
 	"github.com/gorilla/websocket"
@@ -53,6 +54,37 @@ func TestCreateStateService(t *testing.T) {
 	require.NotNil(t, stateSrvc)
 }
 
+func TestOffchainWorkersEnabled(t *testing.T) {
+	cfg := &Config{}
+
+	for _, c := range []struct {
+		option   string
+		roles    byte
+		expected bool
+	}{
+		{OffchainWorkerAlways, types.FullNodeRole, true},
+		{OffchainWorkerNever, types.AuthorityRole, false},
+		{OffchainWorkerWhenValidating, types.FullNodeRole, false},
+		{OffchainWorkerWhenValidating, types.AuthorityRole, true},
+	} {
+		cfg.Core.OffchainWorker = c.option
+		cfg.Core.Roles = c.roles
+		require.Equal(t, c.expected, offchainWorkersEnabled(cfg), c.option)
+	}
+}
+
+func TestTransactionLimits(t *testing.T) {
+	cfg := &Config{}
+	require.Equal(t, transaction.DefaultLimits, transactionLimits(cfg))
+
+	cfg.Core.TxPoolReadyLimit = 10
+	cfg.Core.TxPoolFutureKBytes = 2
+	expected := transaction.DefaultLimits
+	expected.ReadyCount = 10
+	expected.FutureBytes = 2048
+	require.Equal(t, expected, transactionLimits(cfg))
+}
+
 // TestCreateCoreService tests the createCoreService method
 func TestCreateCoreService(t *testing.T) {
 	cfg := NewTestConfig(t)
@@ -85,7 +117,7 @@ func TestCreateCoreService(t *testing.T) {
 	rt, err := createRuntime(cfg, stateSrvc, ks, networkSrvc)
 	require.NoError(t, err)
 
-	coreSrvc, err := createCoreService(cfg, nil, nil, rt, ks, stateSrvc, networkSrvc)
+	coreSrvc, err := createCoreService(cfg, nil, nil, rt, createRuntimeRegistry(cfg, stateSrvc, ks, networkSrvc, rt), ks, stateSrvc, networkSrvc)
 	require.Nil(t, err)
 	require.NotNil(t, coreSrvc)
 }
@@ -136,7 +168,7 @@ func TestCreateSyncService(t *testing.T) {
 	ver, err := createBlockVerifier(stateSrvc)
 	require.NoError(t, err)
 
-	_, err = createSyncService(cfg, stateSrvc, nil, nil, nil, ver, rt)
+	_, err = createSyncService(cfg, stateSrvc, nil, nil, nil, ver, rt, nil)
 	require.NoError(t, err)
 }
 
@@ -196,13 +228,13 @@ func TestCreateRPCService(t *testing.T) {
 	rt, err := createRuntime(cfg, stateSrvc, ks, networkSrvc)
 	require.NoError(t, err)
 
-	coreSrvc, err := createCoreService(cfg, nil, nil, rt, ks, stateSrvc, networkSrvc)
+	coreSrvc, err := createCoreService(cfg, nil, nil, rt, createRuntimeRegistry(cfg, stateSrvc, ks, networkSrvc, rt), ks, stateSrvc, networkSrvc)
 	require.Nil(t, err)
 
 	sysSrvc, err := createSystemService(&cfg.System, stateSrvc)
 	require.NoError(t, err)
 
-	rpcSrvc := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc)
+	rpcSrvc := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc, nil)
 	require.NotNil(t, rpcSrvc)
 }
 
@@ -317,13 +349,13 @@ func TestNewWebSocketServer(t *testing.T) {
 	rt, err := createRuntime(cfg, stateSrvc, ks, networkSrvc)
 	require.NoError(t, err)
 
-	coreSrvc, err := createCoreService(cfg, nil, nil, rt, ks, stateSrvc, networkSrvc)
+	coreSrvc, err := createCoreService(cfg, nil, nil, rt, createRuntimeRegistry(cfg, stateSrvc, ks, networkSrvc, rt), ks, stateSrvc, networkSrvc)
 	require.Nil(t, err)
 
 	sysSrvc, err := createSystemService(&cfg.System, stateSrvc)
 	require.NoError(t, err)
 
-	rpcSrvc := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc)
+	rpcSrvc := createRPCService(cfg, stateSrvc, coreSrvc, networkSrvc, nil, rt, sysSrvc, nil)
 	err = rpcSrvc.Start()
 	require.Nil(t, err)
 
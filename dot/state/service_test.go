@@ -169,7 +169,7 @@ func TestService_PruneStorage(t *testing.T) {
 		err = serv.Storage.blockState.AddBlock(block)
 		require.NoError(t, err)
 
-		err = serv.Storage.StoreTrie(trieState)
+		err = serv.Storage.StoreTrie(trieState, nil)
 		require.NoError(t, err)
 
 		// Only finalise a block at height 3
@@ -187,7 +187,7 @@ func TestService_PruneStorage(t *testing.T) {
 		err = serv.Storage.blockState.AddBlock(block)
 		require.NoError(t, err)
 
-		err = serv.Storage.StoreTrie(trieState)
+		err = serv.Storage.StoreTrie(trieState, nil)
 		require.NoError(t, err)
 
 		// Store the other blocks that will be pruned.
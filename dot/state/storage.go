@@ -33,6 +33,10 @@ import (
 var storagePrefix = "storage"
 var codeKey = common.CodeKey
 
+// OffchainStoragePrefix is the key prefix of the offchain database, which holds the runtime's persistent offchain
+// storage and the data written to it with offchain indexing
+var OffchainStoragePrefix = "offlinestorage"
+
 // ErrTrieDoesNotExist is returned when attempting to interact with a trie that is not stored in the StorageState
 var ErrTrieDoesNotExist = errors.New("trie with given root does not exist")
 
@@ -45,12 +49,16 @@ type StorageState struct {
 	blockState *BlockState
 	tries      map[common.Hash]*trie.Trie // map of root -> trie
 
-	db   chaindb.Database
-	lock sync.RWMutex
+	db         chaindb.Database
+	offchainDB chaindb.Database
+	lock       sync.RWMutex
 
 	// change notifiers
 	changedLock  sync.RWMutex
 	observerList []Observer
+	notifyLock   sync.Mutex
+	notifyQueue  []*SubscriptionResult // changes of the stored blocks that observers haven't been notified of yet
+	notifying    bool                  // whether the queued changes are being notified
 
 	syncing bool
 }
@@ -72,6 +80,7 @@ func NewStorageState(db chaindb.Database, blockState *BlockState, t *trie.Trie)
 		blockState:   blockState,
 		tries:        tries,
 		db:           chaindb.NewTable(db, storagePrefix),
+		offchainDB:   chaindb.NewTable(db, OffchainStoragePrefix),
 		observerList: []Observer{},
 	}, nil
 }
@@ -94,8 +103,10 @@ func (s *StorageState) pruneKey(keyHeader *types.Header) {
 	// TODO: database pruning needs to be refactored since the trie is now stored by nodes
 }
 
-// StoreTrie stores the given trie in the StorageState and writes it to the database
-func (s *StorageState) StoreTrie(ts *rtstorage.TrieState) error {
+// StoreTrie stores the given trie in the StorageState and writes it to the database.
+// If the header of the block that produced the trie is given, storage observers are notified of the keys changed by the block.
+// Any offchain indexing writes made while producing the trie are committed to the offchain database.
+func (s *StorageState) StoreTrie(ts *rtstorage.TrieState, header *types.Header) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -115,10 +126,44 @@ func (s *StorageState) StoreTrie(ts *rtstorage.TrieState) error {
 		return err
 	}
 
-	go s.notifyAll(root)
+	if err := s.storeOffchainIndex(ts); err != nil {
+		logger.Warn("failed to write offchain index to database", "root", root, "error", err)
+		return err
+	}
+
+	if header != nil {
+		keys := ts.ChangedKeys()
+		changes := make([]KeyValue, len(keys))
+		for i, key := range keys {
+			changes[i] = KeyValue{
+				Key:   key,
+				Value: ts.Get(key),
+			}
+		}
+
+		s.queueNotification(header.Hash(), changes)
+	}
+
 	return nil
 }
 
+// storeOffchainIndex writes the offchain indexing writes made in the given TrieState to the offchain database
+func (s *StorageState) storeOffchainIndex(ts *rtstorage.TrieState) error {
+	index := ts.OffchainIndex()
+	if len(index) == 0 {
+		return nil
+	}
+
+	batch := s.offchainDB.NewBatch()
+	for k, v := range index {
+		if err := batch.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return batch.Flush()
+}
+
 // TrieState returns the TrieState for a given state root.
 // If no state root is provided, it returns the TrieState for the current chain head.
 func (s *StorageState) TrieState(root *common.Hash) (*rtstorage.TrieState, error) {
@@ -141,6 +186,9 @@ func (s *StorageState) TrieState(root *common.Hash) (*rtstorage.TrieState, error
 	if t == nil {
 		var err error
 		t, err = s.LoadFromDB(*root)
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			return nil, errTrieDoesNotExist(*root)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -197,7 +245,13 @@ func (s *StorageState) GetStorage(root *common.Hash, key []byte) ([]byte, error)
 		return val, nil
 	}
 
-	return trie.GetFromDB(s.db, *root, key)
+	val, err := trie.GetFromDB(s.db, *root, key)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		// the state at this root has either been pruned or was never stored
+		return nil, errTrieDoesNotExist(*root)
+	}
+
+	return val, err
 }
 
 // GetStorageByBlockHash returns the value at the given key at the given block hash
@@ -338,6 +392,40 @@ func (s *StorageState) GetStorageFromChild(hash *common.Hash, keyToChild, key []
 	return tr.GetFromChild(keyToChild, key)
 }
 
+// GenerateTrieProof returns the proof nodes for the given keys in the trie with the given state root
+func (s *StorageState) GenerateTrieProof(stateRoot common.Hash, keys [][]byte) ([][]byte, error) {
+	return trie.GenerateProof(s.db, stateRoot, keys)
+}
+
+// GenerateChildTrieProof returns the proof nodes for the given keys in the child trie located at keyToChild
+// in the trie with the given state root. The proof includes the nodes on the path to the child trie's root in the main trie.
+func (s *StorageState) GenerateChildTrieProof(stateRoot common.Hash, keyToChild []byte, keys [][]byte) ([][]byte, error) {
+	child, err := s.GetStorageChild(&stateRoot, keyToChild)
+	if err != nil {
+		return nil, err
+	}
+
+	if child == nil {
+		return nil, fmt.Errorf("child trie does not exist at key %s%s", trie.ChildStorageKeyPrefix, keyToChild)
+	}
+
+	childKey := append(append([]byte{}, trie.ChildStorageKeyPrefix...), keyToChild...)
+	proof, err := s.GenerateTrieProof(stateRoot, [][]byte{childKey})
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	childProof, err := child.GenerateProof(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(proof, childProof...), nil
+}
+
 // LoadCode returns the runtime code (located at :code)
 func (s *StorageState) LoadCode(hash *common.Hash) ([]byte, error) {
 	return s.GetStorage(hash, codeKey)
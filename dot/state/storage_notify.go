@@ -16,9 +16,6 @@
 package state
 
 import (
-	"fmt"
-	"reflect"
-
 	"github.com/ChainSafe/gossamer/lib/common"
 )
 
@@ -38,97 +35,139 @@ type SubscriptionResult struct {
 type Observer interface {
 	Update(result *SubscriptionResult)
 	GetID() uint
-	GetFilter() map[string][]byte
+	// GetFilter returns the hex encoded keys the observer is interested in. An empty filter matches every key.
+	GetFilter() map[string]struct{}
 }
 
 // RegisterStorageObserver to add abserver to notification list
 func (s *StorageState) RegisterStorageObserver(o Observer) {
+	s.changedLock.Lock()
 	s.observerList = append(s.observerList, o)
+	s.changedLock.Unlock()
 
-	// notifyObserver here to send storage value of current state
-	sr, err := s.blockState.BestBlockStateRoot()
-	if err != nil {
-		logger.Debug("error registering storage change channel", "error", err)
+	// an observer of specific keys is first sent their values at the best block
+	if len(o.GetFilter()) == 0 {
 		return
 	}
+
 	go func() {
-		if err := s.notifyObserver(sr, o); err != nil {
+		if err := s.notifySnapshot(o); err != nil {
 			logger.Warn("failed to notify storage subscriptions", "error", err)
 		}
 	}()
-
 }
 
 // UnregisterStorageObserver removes observer from notification list
 func (s *StorageState) UnregisterStorageObserver(o Observer) {
-	s.observerList = s.removeFromSlice(s.observerList, o)
+	s.changedLock.Lock()
+	defer s.changedLock.Unlock()
+	s.observerList = removeFromSlice(s.observerList, o)
 }
 
-func (s *StorageState) notifyAll(root common.Hash) {
-	s.changedLock.RLock()
-	defer s.changedLock.RUnlock()
-	for _, observer := range s.observerList {
-		err := s.notifyObserver(root, observer)
+// notifySnapshot sends the observer the current value of each key in its filter at the best block
+func (s *StorageState) notifySnapshot(o Observer) error {
+	bhash := s.blockState.BestBlockHash()
+	header, err := s.blockState.GetHeader(bhash)
+	if err != nil {
+		return err
+	}
+
+	subRes := &SubscriptionResult{
+		Hash: bhash,
+	}
+
+	for k := range o.GetFilter() {
+		key, err := common.HexToBytes(k)
 		if err != nil {
-			logger.Warn("failed to notify storage subscriptions", "error", err)
+			return err
 		}
+
+		value, err := s.GetStorage(&header.StateRoot, key)
+		if err != nil {
+			return err
+		}
+
+		subRes.Changes = append(subRes.Changes, KeyValue{
+			Key:   key,
+			Value: value,
+		})
 	}
+
+	o.Update(subRes)
+	return nil
 }
 
-func (s *StorageState) notifyObserver(root common.Hash, o Observer) error {
-	t, err := s.TrieState(&root)
-	if err != nil {
-		return err
+// queueNotification queues the keys changed in the block with the given hash to be sent to every observer. The
+// changes of each block are sent in the order the blocks were stored, without blocking the caller.
+func (s *StorageState) queueNotification(bhash common.Hash, changes []KeyValue) {
+	s.notifyLock.Lock()
+	defer s.notifyLock.Unlock()
+
+	s.notifyQueue = append(s.notifyQueue, &SubscriptionResult{
+		Hash:    bhash,
+		Changes: changes,
+	})
+
+	if s.notifying {
+		return
 	}
 
-	if t == nil {
-		return errTrieDoesNotExist(root)
+	s.notifying = true
+	go s.notifyQueued()
+}
+
+// notifyQueued sends the queued changes to every observer in order, until the queue is empty
+func (s *StorageState) notifyQueued() {
+	for {
+		s.notifyLock.Lock()
+		if len(s.notifyQueue) == 0 {
+			s.notifying = false
+			s.notifyLock.Unlock()
+			return
+		}
+
+		next := s.notifyQueue[0]
+		s.notifyQueue = s.notifyQueue[1:]
+		s.notifyLock.Unlock()
+
+		s.notifyAll(next.Hash, next.Changes)
 	}
+}
 
+// notifyAll sends the keys changed in the block with the given hash to every observer
+func (s *StorageState) notifyAll(bhash common.Hash, changes []KeyValue) {
+	s.changedLock.RLock()
+	defer s.changedLock.RUnlock()
+	for _, observer := range s.observerList {
+		s.notifyObserver(bhash, changes, observer)
+	}
+}
+
+func (s *StorageState) notifyObserver(bhash common.Hash, changes []KeyValue, o Observer) {
 	subRes := &SubscriptionResult{
-		Hash: root,
+		Hash: bhash,
 	}
-	if len(o.GetFilter()) == 0 {
-		// no filter, so send all changes
-		ent := t.TrieEntries()
-		for k, v := range ent {
-			if k != ":code" {
-				// todo, currently we're ignoring :code since this is a lot of data
-				kv := &KeyValue{
-					Key:   common.MustHexToBytes(fmt.Sprintf("0x%x", k)),
-					Value: v,
-				}
-				subRes.Changes = append(subRes.Changes, *kv)
-			}
-		}
-	} else {
-		// filter result to include only interested keys
-		for k, cachedValue := range o.GetFilter() {
-			value := t.Get(common.MustHexToBytes(k))
-			if !reflect.DeepEqual(cachedValue, value) {
-				kv := &KeyValue{
-					Key:   common.MustHexToBytes(k),
-					Value: value,
-				}
-				subRes.Changes = append(subRes.Changes, *kv)
-				o.GetFilter()[k] = value
+
+	filter := o.GetFilter()
+	for _, kv := range changes {
+		if len(filter) != 0 {
+			if _, has := filter[common.BytesToHex(kv.Key)]; !has {
+				continue
 			}
 		}
+
+		subRes.Changes = append(subRes.Changes, kv)
 	}
 
-	if len(subRes.Changes) > 0 {
-		logger.Trace("update observer", "changes", subRes.Changes)
-		go func() {
-			o.Update(subRes)
-		}()
+	if len(subRes.Changes) == 0 {
+		return
 	}
 
-	return nil
+	logger.Trace("update observer", "block", bhash, "changes", subRes.Changes)
+	o.Update(subRes)
 }
 
-func (s *StorageState) removeFromSlice(observerList []Observer, observerToRemove Observer) []Observer {
-	s.changedLock.Lock()
-	defer s.changedLock.Unlock()
+func removeFromSlice(observerList []Observer, observerToRemove Observer) []Observer {
 	observerListLength := len(observerList)
 	for i, observer := range observerList {
 		if observerToRemove.GetID() == observer.GetID() {
@@ -20,19 +20,22 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"os"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/stretchr/testify/require"
 )
 
 type MockStorageObserver struct {
 	id         uint
-	filter     map[string][]byte
+	filter     map[string]struct{}
 	lastUpdate *SubscriptionResult
 	m          sync.RWMutex
 }
@@ -46,7 +49,7 @@ func (m *MockStorageObserver) Update(change *SubscriptionResult) {
 func (m *MockStorageObserver) GetID() uint {
 	return m.id
 }
-func (m *MockStorageObserver) GetFilter() map[string][]byte {
+func (m *MockStorageObserver) GetFilter() map[string]struct{} {
 	return m.filter
 }
 
@@ -62,11 +65,12 @@ func TestStorageState_RegisterStorageObserver(t *testing.T) {
 	defer ss.UnregisterStorageObserver(observer)
 
 	ts.Set([]byte("mackcom"), []byte("wuz here"))
-	err = ss.StoreTrie(ts)
+	header := newTestStorageHeader(t, ss, ts)
+	err = ss.StoreTrie(ts, header)
 	require.NoError(t, err)
 
 	expectedResult := &SubscriptionResult{
-		Hash: ts.MustRoot(),
+		Hash: header.Hash(),
 		Changes: []KeyValue{{
 			Key:   []byte("mackcom"),
 			Value: []byte("wuz here"),
@@ -78,6 +82,50 @@ func TestStorageState_RegisterStorageObserver(t *testing.T) {
 	require.Equal(t, expectedResult, observer.lastUpdate)
 }
 
+// orderedStorageObserver records the hashes of the blocks it's notified of
+type orderedStorageObserver struct {
+	MockStorageObserver
+	hashes chan common.Hash
+}
+
+func (o *orderedStorageObserver) Update(change *SubscriptionResult) {
+	o.hashes <- change.Hash
+}
+
+func TestStorageState_RegisterStorageObserver_Ordered(t *testing.T) {
+	ss := newTestStorageState(t)
+
+	observer := &orderedStorageObserver{
+		hashes: make(chan common.Hash, 16),
+	}
+	ss.RegisterStorageObserver(observer)
+	defer ss.UnregisterStorageObserver(observer)
+
+	hashes := make([]common.Hash, 16)
+	for i := range hashes {
+		ts, err := ss.TrieState(nil)
+		require.NoError(t, err)
+
+		ts.Set([]byte("key"), []byte{byte(i)})
+		header, err := types.NewHeader(common.Hash{byte(i)}, ts.MustRoot(), common.Hash{}, big.NewInt(1),
+			[]types.DigestItem{})
+		require.NoError(t, err)
+
+		err = ss.StoreTrie(ts, header)
+		require.NoError(t, err)
+		hashes[i] = header.Hash()
+	}
+
+	for _, hash := range hashes {
+		select {
+		case notified := <-observer.hashes:
+			require.Equal(t, hash, notified)
+		case <-time.After(time.Second):
+			t.Fatal("observer wasn't notified")
+		}
+	}
+}
+
 func TestStorageState_RegisterStorageObserver_Multi(t *testing.T) {
 	ss := newTestStorageState(t)
 	ts, err := ss.TrieState(nil)
@@ -101,7 +149,7 @@ func TestStorageState_RegisterStorageObserver_Multi(t *testing.T) {
 
 	ts.Set(key1, value1)
 
-	err = ss.StoreTrie(ts)
+	err = ss.StoreTrie(ts, newTestStorageHeader(t, ss, ts))
 	require.NoError(t, err)
 
 	time.Sleep(time.Millisecond * 10)
@@ -134,7 +182,7 @@ func TestStorageState_RegisterStorageObserver_Multi_Filter(t *testing.T) {
 	for i := 0; i < num; i++ {
 		observer := &MockStorageObserver{
 			id: uint(i),
-			filter: map[string][]byte{
+			filter: map[string]struct{}{
 				common.BytesToHex(key1): {},
 			},
 		}
@@ -143,7 +191,7 @@ func TestStorageState_RegisterStorageObserver_Multi_Filter(t *testing.T) {
 	}
 
 	ts.Set(key1, value1)
-	err = ss.StoreTrie(ts)
+	err = ss.StoreTrie(ts, newTestStorageHeader(t, ss, ts))
 	require.NoError(t, err)
 
 	time.Sleep(time.Millisecond * 10)
@@ -161,6 +209,54 @@ func TestStorageState_RegisterStorageObserver_Multi_Filter(t *testing.T) {
 	}
 }
 
+func TestStorageState_RegisterStorageObserver_Filter(t *testing.T) {
+	ss := newTestStorageState(t)
+	ts, err := ss.TrieState(nil)
+	require.NoError(t, err)
+
+	key1 := []byte("key1")
+	value1 := []byte("value1")
+
+	observer := &MockStorageObserver{
+		filter: map[string]struct{}{
+			common.BytesToHex(key1): {},
+		},
+	}
+	ss.RegisterStorageObserver(observer)
+	defer ss.UnregisterStorageObserver(observer)
+
+	// the observer is first sent the current value of the key, which doesn't exist yet
+	time.Sleep(time.Millisecond * 10)
+	observer.m.RLock()
+	require.Equal(t, &SubscriptionResult{
+		Hash:    ss.blockState.BestBlockHash(),
+		Changes: []KeyValue{{Key: key1}},
+	}, observer.lastUpdate)
+	observer.m.RUnlock()
+
+	ts.Set(key1, value1)
+	ts.Set([]byte("key2"), []byte("value2"))
+	header := newTestStorageHeader(t, ss, ts)
+	err = ss.StoreTrie(ts, header)
+	require.NoError(t, err)
+
+	// only the key in the filter is sent
+	time.Sleep(time.Millisecond * 10)
+	observer.m.RLock()
+	require.Equal(t, &SubscriptionResult{
+		Hash:    header.Hash(),
+		Changes: []KeyValue{{Key: key1, Value: value1}},
+	}, observer.lastUpdate)
+	observer.m.RUnlock()
+}
+
+// newTestStorageHeader returns a header on top of the best block with the state root of the given TrieState
+func newTestStorageHeader(t *testing.T, ss *StorageState, ts *rtstorage.TrieState) *types.Header {
+	header, err := types.NewHeader(ss.blockState.BestBlockHash(), ts.MustRoot(), common.Hash{}, big.NewInt(1), []types.DigestItem{})
+	require.NoError(t, err)
+	return header
+}
+
 func Test_Example(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping subscription example")
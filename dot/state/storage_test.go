@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 	runtime "github.com/ChainSafe/gossamer/lib/runtime/storage"
 	"github.com/ChainSafe/gossamer/lib/trie"
 
@@ -28,7 +29,7 @@ func TestStorage_StoreAndLoadTrie(t *testing.T) {
 
 	root, err := ts.Root()
 	require.NoError(t, err)
-	err = storage.StoreTrie(ts)
+	err = storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 
 	time.Sleep(time.Millisecond * 100)
@@ -52,7 +53,7 @@ func TestStorage_GetStorageByBlockHash(t *testing.T) {
 
 	root, err := ts.Root()
 	require.NoError(t, err)
-	err = storage.StoreTrie(ts)
+	err = storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 
 	block := &types.Block{
@@ -79,7 +80,7 @@ func TestStorage_TrieState(t *testing.T) {
 
 	root, err := ts.Root()
 	require.NoError(t, err)
-	err = storage.StoreTrie(ts)
+	err = storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 
 	time.Sleep(time.Millisecond * 100)
@@ -115,7 +116,7 @@ func TestStorage_LoadFromDB(t *testing.T) {
 	require.NoError(t, err)
 
 	// Write trie to disk.
-	err = storage.StoreTrie(ts)
+	err = storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 
 	// Clear trie from cache and fetch data from disk.
@@ -154,7 +155,7 @@ func TestStorage_StoreTrie_Syncing(t *testing.T) {
 	ts.Set(key, value)
 
 	storage.SetSyncing(true)
-	err = storage.StoreTrie(ts)
+	err = storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(storage.tries))
 }
@@ -169,7 +170,69 @@ func TestStorage_StoreTrie_NotSyncing(t *testing.T) {
 	ts.Set(key, value)
 
 	storage.SetSyncing(false)
-	err = storage.StoreTrie(ts)
+	err = storage.StoreTrie(ts, nil)
 	require.NoError(t, err)
 	require.Equal(t, 2, len(storage.tries))
 }
+
+func TestStorage_StoreTrie_OffchainIndex(t *testing.T) {
+	storage := newTestStorageState(t)
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	key := []byte("testkey")
+	value := []byte("testvalue")
+	ts.SetOffchainIndex(key, value)
+
+	// offchain indexing writes are only committed once the state is stored
+	_, err = storage.offchainDB.Get(key)
+	require.Error(t, err)
+
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	res, err := storage.offchainDB.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, value, res)
+}
+
+func TestStorage_GenerateTrieProof(t *testing.T) {
+	storage := newTestStorageState(t)
+	ts, err := storage.TrieState(&trie.EmptyHash)
+	require.NoError(t, err)
+
+	ts.Set([]byte("noot"), []byte("washere"))
+	ts.Set([]byte("key"), []byte("value"))
+
+	child := trie.NewEmptyTrie()
+	child.Put([]byte("childkey"), []byte("childvalue"))
+	err = ts.SetChild([]byte("child"), child)
+	require.NoError(t, err)
+
+	root, err := ts.Root()
+	require.NoError(t, err)
+	err = storage.StoreTrie(ts, nil)
+	require.NoError(t, err)
+
+	proof, err := storage.GenerateTrieProof(root, [][]byte{[]byte("noot")})
+	require.NoError(t, err)
+
+	val, err := trie.VerifyProof(root, []byte("noot"), proof)
+	require.NoError(t, err)
+	require.Equal(t, []byte("washere"), val)
+
+	proof, err = storage.GenerateChildTrieProof(root, []byte("child"), [][]byte{[]byte("childkey")})
+	require.NoError(t, err)
+
+	childKey := append(append([]byte{}, trie.ChildStorageKeyPrefix...), []byte("child")...)
+	childRoot, err := trie.VerifyProof(root, childKey, proof)
+	require.NoError(t, err)
+	require.Equal(t, child.MustHash(), common.BytesToHash(childRoot))
+
+	val, err = trie.VerifyProof(child.MustHash(), []byte("childkey"), proof)
+	require.NoError(t, err)
+	require.Equal(t, []byte("childvalue"), val)
+
+	_, err = storage.GenerateChildTrieProof(root, []byte("nochild"), [][]byte{[]byte("childkey")})
+	require.Error(t, err)
+}
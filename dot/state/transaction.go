@@ -1,6 +1,9 @@
 package state
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/transaction"
@@ -10,19 +13,42 @@ import (
 type TransactionState struct {
 	queue *transaction.PriorityQueue
 	pool  *transaction.Pool
+
+	status       map[common.Hash]map[uint64]*statusWatcher
+	statusHashes map[uint64]common.Hash
+	nextStatusID uint64
+	statusLock   sync.RWMutex
+
+	journal *transactionJournal // nil unless the journal is enabled
 }
 
 // NewTransactionState returns a new TransactionState
 func NewTransactionState() *TransactionState {
-	return &TransactionState{
-		queue: transaction.NewPriorityQueue(),
-		pool:  transaction.NewPool(),
+	s := &TransactionState{
+		queue:        transaction.NewPriorityQueue(),
+		pool:         transaction.NewPool(),
+		status:       make(map[common.Hash]map[uint64]*statusWatcher),
+		statusHashes: make(map[uint64]common.Hash),
 	}
+	s.queue.SetStatusHandler(s.NotifyStatus)
+	return s
 }
 
-// Push pushes a transaction to the queue, ordered by priority
+// SetLimits sets the limits of the transaction queue
+func (s *TransactionState) SetLimits(limits transaction.Limits) {
+	s.queue.SetLimits(limits)
+}
+
+// Push pushes a transaction to the queue, ordered by priority. It is only popped once the transactions providing the
+// tags it requires have been popped or included in a block.
 func (s *TransactionState) Push(vt *transaction.ValidTransaction) (common.Hash, error) {
-	return s.queue.Push(vt)
+	hash, err := s.queue.Push(vt)
+	if err != nil {
+		return hash, err
+	}
+
+	s.journalTransaction(vt)
+	return hash, nil
 }
 
 // Pop removes and returns the head of the queue
@@ -51,6 +77,62 @@ func (s *TransactionState) RemoveExtrinsic(ext types.Extrinsic) {
 	s.queue.RemoveExtrinsic(ext)
 }
 
+// UpdateValidity replaces the validity of a pending transaction after it has been revalidated. Queued transactions
+// are ordered again by their new tags. It returns the error of pushing the transaction again, in which case it has
+// been removed from the queue.
+func (s *TransactionState) UpdateValidity(ext types.Extrinsic, validity *transaction.Validity) error {
+	vt := transaction.NewValidTransaction(ext, validity)
+	if s.pool.Has(ext.Hash()) {
+		s.pool.Insert(vt)
+		return nil
+	}
+
+	err := s.queue.Update(vt)
+	if errors.Is(err, transaction.ErrTransactionNotFound) {
+		// the transaction was popped or removed in the meantime
+		return nil
+	}
+	return err
+}
+
+// RemoveInvalid removes an extrinsic that is no longer valid from the queue and pool. Subscribers are notified that it
+// is invalid if it was pending.
+func (s *TransactionState) RemoveInvalid(ext types.Extrinsic) {
+	inPool := s.pool.Remove(ext.Hash())
+	inQueue := s.queue.RemoveExtrinsic(ext)
+	if inPool || inQueue {
+		s.NotifyStatus(&transaction.StatusNotification{
+			Hash:   ext.Hash(),
+			Status: transaction.Invalid,
+		})
+	}
+}
+
+// RemoveIncludedExtrinsics removes extrinsics that were included in a block from the queue and pool. The queued
+// transactions that require the tags they provide become ready.
+func (s *TransactionState) RemoveIncludedExtrinsics(exts []types.Extrinsic) {
+	for _, ext := range exts {
+		s.pool.Remove(ext.Hash())
+	}
+	s.queue.Prune(exts)
+}
+
+// PruneTags marks the tags provided by the included extrinsics that weren't pending as provided by the chain, so that
+// the queued transactions that require them become ready. It must be called after RemoveIncludedExtrinsics.
+func (s *TransactionState) PruneTags(tags [][]byte) {
+	s.queue.PruneTags(tags)
+}
+
+// Exists returns true if the transaction with the given hash is in the queue or pool
+func (s *TransactionState) Exists(hash common.Hash) bool {
+	return s.queue.Has(hash) || s.pool.Has(hash)
+}
+
+// RemoveExpired removes the transactions whose longevity has run out at the given block number from the queue
+func (s *TransactionState) RemoveExpired(number uint64) []*transaction.ValidTransaction {
+	return s.queue.RemoveExpired(number)
+}
+
 // RemoveExtrinsicFromPool removes an extrinsic from the pool
 func (s *TransactionState) RemoveExtrinsicFromPool(ext types.Extrinsic) {
 	s.pool.Remove(ext.Hash())
@@ -58,5 +140,7 @@ func (s *TransactionState) RemoveExtrinsicFromPool(ext types.Extrinsic) {
 
 // AddToPool adds a transaction to the pool
 func (s *TransactionState) AddToPool(vt *transaction.ValidTransaction) common.Hash {
-	return s.pool.Insert(vt)
+	hash := s.pool.Insert(vt)
+	s.journalTransaction(vt)
+	return hash
 }
@@ -0,0 +1,235 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/scale"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+)
+
+var (
+	transactionJournalPrefix = "txjournal"
+	journalLengthKey         = []byte("length")
+	journalEntryPrefix       = []byte("entry")
+)
+
+// minJournalRotation is the journal length under which it is never rotated
+const minJournalRotation = 1024
+
+func journalEntryKey(index uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, index)
+	return append(journalEntryPrefix, buf...)
+}
+
+// transactionJournal is an append-only log of the transactions added to the queue and pool. Removed transactions
+// aren't logged, the journal is instead rotated to the pending transactions once it grows to twice their number.
+// A transaction that is added again is logged again, and only its latest entry is loaded.
+type transactionJournal struct {
+	sync.Mutex
+	db     chaindb.Database
+	length uint64
+}
+
+// EnableJournal makes the transaction state keep a journal of the transactions added to the queue and pool in the
+// given database, so that they can be restored with LoadJournal after a restart.
+func (s *TransactionState) EnableJournal(db chaindb.Database) error {
+	j := &transactionJournal{
+		db: chaindb.NewTable(db, transactionJournalPrefix),
+	}
+
+	enc, err := j.db.Get(journalLengthKey)
+	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return err
+	}
+
+	if len(enc) == 8 {
+		j.length = binary.LittleEndian.Uint64(enc)
+	}
+
+	s.journal = j
+	return nil
+}
+
+// LoadJournal returns the transactions in the journal and clears it. The transactions that are added back to the
+// queue or pool are journaled again. It returns nil if the journal isn't enabled.
+func (s *TransactionState) LoadJournal() ([]*transaction.ValidTransaction, error) {
+	if s.journal == nil {
+		return nil, nil
+	}
+
+	j := s.journal
+	j.Lock()
+	defer j.Unlock()
+
+	// the transactions are loaded in the order they were first journaled, with their latest validity
+	seen := make(map[common.Hash]int)
+	var txs []*transaction.ValidTransaction
+	for i := uint64(0); i < j.length; i++ {
+		enc, err := j.db.Get(journalEntryKey(i))
+		if err != nil {
+			return nil, err
+		}
+
+		vt, err := decodeJournalEntry(enc)
+		if err != nil {
+			logger.Warn("skipping invalid transaction journal entry", "index", i, "error", err)
+			continue
+		}
+
+		hash := vt.Extrinsic.Hash()
+		if idx, has := seen[hash]; has {
+			txs[idx] = vt
+			continue
+		}
+
+		seen[hash] = len(txs)
+		txs = append(txs, vt)
+	}
+
+	return txs, j.rewrite(nil)
+}
+
+// journalTransaction appends the transaction to the journal if it's enabled, and rotates the journal if it has grown
+// too large
+func (s *TransactionState) journalTransaction(vt *transaction.ValidTransaction) {
+	if s.journal == nil {
+		return
+	}
+
+	j := s.journal
+	j.Lock()
+	defer j.Unlock()
+
+	if err := j.append(vt); err != nil {
+		logger.Warn("failed to journal transaction", "hash", vt.Extrinsic.Hash(), "error", err)
+		return
+	}
+
+	if j.length < minJournalRotation {
+		return
+	}
+
+	pending := s.Pending()
+	if j.length < uint64(2*len(pending)) {
+		return
+	}
+
+	if err := j.rewrite(pending); err != nil {
+		logger.Warn("failed to rotate transaction journal", "error", err)
+	}
+}
+
+// append adds the transaction at the end of the journal
+func (j *transactionJournal) append(vt *transaction.ValidTransaction) error {
+	enc, err := encodeJournalEntry(vt)
+	if err != nil {
+		return err
+	}
+
+	batch := j.db.NewBatch()
+	if err = batch.Put(journalEntryKey(j.length), enc); err != nil {
+		return err
+	}
+
+	if err = batch.Put(journalLengthKey, lengthBytes(j.length+1)); err != nil {
+		return err
+	}
+
+	if err = batch.Flush(); err != nil {
+		return err
+	}
+
+	j.length++
+	return nil
+}
+
+// rewrite replaces the content of the journal with the given transactions
+func (j *transactionJournal) rewrite(txs []*transaction.ValidTransaction) error {
+	batch := j.db.NewBatch()
+	for i, vt := range txs {
+		enc, err := encodeJournalEntry(vt)
+		if err != nil {
+			return err
+		}
+
+		if err = batch.Put(journalEntryKey(uint64(i)), enc); err != nil {
+			return err
+		}
+	}
+
+	length := uint64(len(txs))
+	for i := length; i < j.length; i++ {
+		if err := batch.Del(journalEntryKey(i)); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.Put(journalLengthKey, lengthBytes(length)); err != nil {
+		return err
+	}
+
+	if err := batch.Flush(); err != nil {
+		return err
+	}
+
+	j.length = length
+	return nil
+}
+
+func lengthBytes(length uint64) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, length)
+	return buf
+}
+
+// encodeJournalEntry returns the SCALE encoding of the extrinsic followed by the SCALE encoding of its validity
+func encodeJournalEntry(vt *transaction.ValidTransaction) ([]byte, error) {
+	enc, err := scale.Encode([]byte(vt.Extrinsic))
+	if err != nil {
+		return nil, err
+	}
+
+	validity, err := scale.Encode(vt.Validity)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(enc, validity...), nil
+}
+
+func decodeJournalEntry(enc []byte) (*transaction.ValidTransaction, error) {
+	sd := &scale.Decoder{Reader: bytes.NewReader(enc)}
+	ext, err := sd.DecodeByteArray()
+	if err != nil {
+		return nil, err
+	}
+
+	validity, err := sd.Decode(new(transaction.Validity))
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction.NewValidTransaction(ext, validity.(*transaction.Validity)), nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+)
+
+// RegisterStatusChannel registers a channel for the status notifications of the transaction with the given hash.
+// It returns the channel ID (used for unregistering the channel)
+func (s *TransactionState) RegisterStatusChannel(hash common.Hash, ch chan<- *transaction.StatusNotification) uint64 {
+	s.statusLock.Lock()
+	defer s.statusLock.Unlock()
+
+	s.nextStatusID++
+	id := s.nextStatusID
+
+	if s.status[hash] == nil {
+		s.status[hash] = make(map[uint64]*statusWatcher)
+	}
+
+	s.status[hash][id] = newStatusWatcher(ch)
+	s.statusHashes[id] = hash
+	return id
+}
+
+// UnregisterStatusChannel removes the transaction status notification channel with the given ID. Once it returns,
+// no more notifications are sent to the channel, so it can be closed.
+func (s *TransactionState) UnregisterStatusChannel(id uint64) {
+	s.statusLock.Lock()
+	hash, has := s.statusHashes[id]
+	if !has {
+		s.statusLock.Unlock()
+		return
+	}
+
+	w := s.status[hash][id]
+	delete(s.statusHashes, id)
+	delete(s.status[hash], id)
+	if len(s.status[hash]) == 0 {
+		delete(s.status, hash)
+	}
+	s.statusLock.Unlock()
+
+	w.stop()
+}
+
+// NotifyStatus sends the transaction status notification to the channels registered for the transaction. It is used
+// for the status changes the pool doesn't detect itself, such as the inclusion of the transaction in a block.
+// Notifications are sent in order, and queued for the channels that aren't ready to receive them.
+func (s *TransactionState) NotifyStatus(n *transaction.StatusNotification) {
+	s.statusLock.RLock()
+	defer s.statusLock.RUnlock()
+
+	for _, w := range s.status[n.Hash] {
+		w.notify(n)
+	}
+}
+
+// statusWatcher forwards the status notifications of a transaction to a channel. Notifications are queued until the
+// channel receives them, so that none are dropped and the notifier never blocks.
+type statusWatcher struct {
+	ch      chan<- *transaction.StatusNotification
+	lock    sync.Mutex
+	pending []*transaction.StatusNotification
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newStatusWatcher(ch chan<- *transaction.StatusNotification) *statusWatcher {
+	w := &statusWatcher{
+		ch:      ch,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go w.forward()
+	return w
+}
+
+func (w *statusWatcher) notify(n *transaction.StatusNotification) {
+	w.lock.Lock()
+	w.pending = append(w.pending, n)
+	w.lock.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *statusWatcher) forward() {
+	defer close(w.stopped)
+
+	for {
+		w.lock.Lock()
+		if len(w.pending) == 0 {
+			w.lock.Unlock()
+
+			select {
+			case <-w.wake:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+
+		n := w.pending[0]
+		w.pending = w.pending[1:]
+		w.lock.Unlock()
+
+		select {
+		case w.ch <- n:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// stop stops forwarding notifications, and returns once the channel won't be sent any more notifications
+func (w *statusWatcher) stop() {
+	close(w.done)
+	<-w.stopped
+}
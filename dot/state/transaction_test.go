@@ -3,6 +3,7 @@ package state
 import (
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/transaction"
@@ -59,3 +60,150 @@ func TestTransactionState_Pending(t *testing.T) {
 	head := ts.Peek()
 	require.Nil(t, head)
 }
+
+func TestTransactionState_StatusNotifications(t *testing.T) {
+	ts := NewTransactionState()
+
+	tx := &transaction.ValidTransaction{
+		Extrinsic: []byte("a"),
+		Validity:  &transaction.Validity{Priority: 1},
+	}
+
+	ch := make(chan *transaction.StatusNotification)
+	id := ts.RegisterStatusChannel(tx.Extrinsic.Hash(), ch)
+
+	// notifications of other transactions aren't sent
+	ts.NotifyStatus(&transaction.StatusNotification{Hash: common.Hash{2}, Status: transaction.Dropped})
+
+	hash, err := ts.Push(tx)
+	require.NoError(t, err)
+	require.Equal(t, &transaction.StatusNotification{Hash: hash, Status: transaction.Ready}, <-ch)
+
+	ts.RemoveInvalid(tx.Extrinsic)
+	require.Equal(t, &transaction.StatusNotification{Hash: hash, Status: transaction.Invalid}, <-ch)
+	require.Nil(t, ts.Peek())
+
+	// removing a transaction that isn't pending doesn't notify
+	ts.RemoveInvalid(tx.Extrinsic)
+
+	block := common.Hash{1}
+	ts.NotifyStatus(&transaction.StatusNotification{Hash: hash, Status: transaction.InBlock, Block: block})
+	require.Equal(t, &transaction.StatusNotification{Hash: hash, Status: transaction.InBlock, Block: block}, <-ch)
+
+	ts.UnregisterStatusChannel(id)
+	ts.AddToPool(tx)
+	ts.RemoveInvalid(tx.Extrinsic)
+	select {
+	case n := <-ch:
+		t.Fatalf("unexpected notification %v", n)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestTransactionState_StatusNotifications_Queued(t *testing.T) {
+	ts := NewTransactionState()
+	hash := common.Hash{1}
+
+	// there is no limit on the number of channels, and notifications are queued until they are received
+	chs := make([]chan *transaction.StatusNotification, 300)
+	for i := range chs {
+		chs[i] = make(chan *transaction.StatusNotification)
+		ts.RegisterStatusChannel(hash, chs[i])
+	}
+
+	statuses := []transaction.Status{transaction.Ready, transaction.Broadcast, transaction.InBlock, transaction.Finalized}
+	for _, status := range statuses {
+		ts.NotifyStatus(&transaction.StatusNotification{Hash: hash, Status: status})
+	}
+
+	for _, ch := range chs {
+		for _, status := range statuses {
+			require.Equal(t, &transaction.StatusNotification{Hash: hash, Status: status}, <-ch)
+		}
+	}
+}
+
+func TestTransactionState_Journal(t *testing.T) {
+	db := NewInMemoryDB(t)
+
+	ts := NewTransactionState()
+	require.NoError(t, ts.EnableJournal(db))
+
+	txs := []*transaction.ValidTransaction{
+		{
+			Extrinsic: []byte("a"),
+			Validity:  transaction.NewValidity(1, [][]byte{}, [][]byte{{1}}, 64, true),
+		},
+		{
+			Extrinsic: []byte("b"),
+			Validity:  transaction.NewValidity(2, [][]byte{{1}}, [][]byte{{2}}, 8, false),
+		},
+	}
+
+	ts.AddToPool(txs[0])
+	_, err := ts.Push(txs[1])
+	require.NoError(t, err)
+
+	// a transaction that is removed and added again is journaled again, with its new validity
+	ts.RemoveExtrinsicFromPool(txs[0].Extrinsic)
+	txs[0] = transaction.NewValidTransaction(txs[0].Extrinsic,
+		transaction.NewValidity(3, [][]byte{}, [][]byte{{1}}, 32, true))
+	_, err = ts.Push(txs[0])
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), ts.journal.length)
+
+	// the journal is read back from the database after a restart
+	ts = NewTransactionState()
+	require.NoError(t, ts.EnableJournal(db))
+
+	loaded, err := ts.LoadJournal()
+	require.NoError(t, err)
+	require.Equal(t, txs, loaded)
+
+	// loading the journal clears it
+	ts = NewTransactionState()
+	require.NoError(t, ts.EnableJournal(db))
+
+	loaded, err = ts.LoadJournal()
+	require.NoError(t, err)
+	require.Empty(t, loaded)
+}
+
+func TestTransactionState_JournalRotation(t *testing.T) {
+	ts := NewTransactionState()
+	require.NoError(t, ts.EnableJournal(NewInMemoryDB(t)))
+
+	first := &transaction.ValidTransaction{
+		Extrinsic: []byte("first"),
+		Validity:  transaction.NewValidity(1, [][]byte{}, [][]byte{}, 0, false),
+	}
+	ts.AddToPool(first)
+
+	for i := 1; i < minJournalRotation-1; i++ {
+		ext := []byte{byte(i), byte(i >> 8)}
+		ts.AddToPool(transaction.NewValidTransaction(ext, &transaction.Validity{Priority: 1}))
+		ts.RemoveExtrinsicFromPool(ext)
+	}
+	require.Equal(t, uint64(minJournalRotation-1), ts.journal.length)
+
+	// the journal is rotated to the pending transactions once it reaches the minimum length
+	last := &transaction.ValidTransaction{
+		Extrinsic: []byte("last"),
+		Validity:  transaction.NewValidity(1, [][]byte{}, [][]byte{}, 0, false),
+	}
+	ts.AddToPool(last)
+	require.Equal(t, uint64(2), ts.journal.length)
+
+	loaded, err := ts.LoadJournal()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*transaction.ValidTransaction{first, last}, loaded)
+}
+
+func TestTransactionState_LoadJournal_Disabled(t *testing.T) {
+	ts := NewTransactionState()
+	ts.AddToPool(transaction.NewValidTransaction([]byte("a"), &transaction.Validity{}))
+
+	loaded, err := ts.LoadJournal()
+	require.NoError(t, err)
+	require.Nil(t, loaded)
+}
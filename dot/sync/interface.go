@@ -51,14 +51,14 @@ type BlockState interface {
 // StorageState is the interface for the storage state
 type StorageState interface {
 	TrieState(root *common.Hash) (*rtstorage.TrieState, error)
-	StoreTrie(ts *rtstorage.TrieState) error
+	StoreTrie(ts *rtstorage.TrieState, header *types.Header) error
 	LoadCodeHash(*common.Hash) (common.Hash, error)
 	SetSyncing(bool)
 }
 
 // TransactionState is the interface for transaction queue methods
 type TransactionState interface {
-	RemoveExtrinsic(ext types.Extrinsic)
+	RemoveIncludedExtrinsics(exts []types.Extrinsic)
 }
 
 // BlockProducer is the interface that a block production service must implement
@@ -50,6 +50,7 @@ type Service struct {
 	synced           bool
 	highestSeenBlock *big.Int // highest block number we have seen
 	runtime          runtime.Instance
+	runtimes         *runtime.Registry // runtime instances used to execute blocks, if set
 
 	// BABE verification
 	verifier Verifier
@@ -67,6 +68,7 @@ type Config struct {
 	FinalityGadget   FinalityGadget
 	TransactionState TransactionState
 	Runtime          runtime.Instance
+	Runtimes         *runtime.Registry
 	Verifier         Verifier
 	DigestHandler    DigestHandler
 }
@@ -112,6 +114,7 @@ func NewService(cfg *Config) (*Service, error) {
 		highestSeenBlock: big.NewInt(0),
 		transactionState: cfg.TransactionState,
 		runtime:          cfg.Runtime,
+		runtimes:         cfg.Runtimes,
 		verifier:         cfg.Verifier,
 		digestHandler:    cfg.DigestHandler,
 	}, nil
@@ -303,17 +306,15 @@ func (s *Service) handleHeader(header *types.Header) error {
 
 // handleHeader handles block bodies included in BlockResponses
 func (s *Service) handleBody(body *types.Body) error {
-	exts, err := body.AsExtrinsics()
+	// the pooled extrinsics are SCALE encoded, as submitted
+	exts, err := body.AsEncodedExtrinsics()
 	if err != nil {
 		logger.Error("cannot parse body as extrinsics", "error", err)
 		return err
 	}
 
-	for _, ext := range exts {
-		s.transactionState.RemoveExtrinsic(ext)
-	}
-
-	return err
+	s.transactionState.RemoveIncludedExtrinsics(exts)
+	return nil
 }
 
 // handleHeader handles blocks (header+body) included in BlockResponses
@@ -338,15 +339,17 @@ func (s *Service) handleBlock(block *types.Block) error {
 		panic("parent state root does not match snapshot state root")
 	}
 
-	s.runtime.SetContextStorage(ts)
 	logger.Trace("going to execute block", "header", block.Header, "exts", block.Body)
 
-	_, err = s.runtime.ExecuteBlock(block)
+	err = s.callRuntime(ts, func(rt runtime.Instance) error {
+		_, err := rt.ExecuteBlock(block)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to execute block %d: %w", block.Header.Number, err)
 	}
 
-	err = s.storageState.StoreTrie(ts)
+	err = s.storageState.StoreTrie(ts, block.Header)
 	if err != nil {
 		return err
 	}
@@ -434,6 +437,18 @@ func (s *Service) handleRuntimeChanges(newState *rtstorage.TrieState) error {
 	return nil
 }
 
+// callRuntime calls fn with the runtime instance for the runtime code in the given state, with the state set as its
+// context storage. Blocks are executed on the runtime in their parent's state, so that blocks on forks with a different
+// runtime and blocks imported after a runtime upgrade are executed with the right code.
+func (s *Service) callRuntime(ts *rtstorage.TrieState, fn func(rt runtime.Instance) error) error {
+	if s.runtimes == nil {
+		s.runtime.SetContextStorage(ts)
+		return fn(s.runtime)
+	}
+
+	return s.runtimes.Call(ts, fn)
+}
+
 func (s *Service) handleDigests(header *types.Header) {
 	for i, d := range header.Digest {
 		if d.Type() == types.ConsensusDigestType {
@@ -30,6 +30,7 @@ import (
 	"github.com/ChainSafe/gossamer/lib/common/optional"
 	"github.com/ChainSafe/gossamer/lib/common/variadic"
 	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/scale"
 	"github.com/ChainSafe/gossamer/lib/transaction"
 	"github.com/stretchr/testify/require"
 )
@@ -125,8 +126,12 @@ func TestRemoveIncludedExtrinsics(t *testing.T) {
 	syncer := NewTestSyncer(t)
 
 	ext := []byte("nootwashere")
+	// the pooled extrinsic is SCALE encoded, as submitted
+	encExt, err := scale.Encode(ext)
+	require.NoError(t, err)
+
 	tx := &transaction.ValidTransaction{
-		Extrinsic: ext,
+		Extrinsic: encExt,
 		Validity:  &transaction.Validity{Priority: 1},
 	}
 
@@ -98,6 +98,13 @@ type ConfigData struct {
 	SecondarySlots byte
 }
 
+// EpochAuthorship contains the slots in an epoch that an authority can author a block in, by type of slot claim
+type EpochAuthorship struct {
+	Primary      []uint64 `json:"primary"`
+	Secondary    []uint64 `json:"secondary"`
+	SecondaryVRF []uint64 `json:"secondary_vrf"`
+}
+
 // GetSlotFromHeader returns the BABE slot from the given header
 func GetSlotFromHeader(header *Header) (uint64, error) {
 	if len(header.Digest) == 0 {
@@ -17,6 +17,7 @@ require (
 	github.com/docker/docker v1.13.1
 	github.com/elastic/gosigar v0.14.0 // indirect
 	github.com/ethereum/go-ethereum v1.9.7
+	github.com/go-interpreter/wagon v0.6.0
 	github.com/go-playground/validator/v10 v10.4.1
 	github.com/golang/groupcache v0.0.0-20191227052852-215e87163ea7 // indirect
 	github.com/golang/protobuf v1.4.3
@@ -0,0 +1,166 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package babe
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+)
+
+// the values of the SecondarySlots field of the BABE configuration
+const (
+	primarySlots byte = iota
+	primaryAndSecondaryPlainSlots
+	primaryAndSecondaryVRFSlots
+)
+
+// EpochAuthorship returns the slots in the current epoch that each of the given keys can author a block in,
+// keyed by the address of the key. Keys that aren't in the current authority set are skipped.
+func (b *Service) EpochAuthorship(keypairs []*sr25519.Keypair) (map[common.Address]*types.EpochAuthorship, error) {
+	epoch, err := b.epochState.GetCurrentEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	startSlot, err := b.epochState.GetStartSlotForEpoch(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	// the epoch data is loaded for the epoch, as the service's epoch data may not be updated for it yet
+	data, err := b.getEpochDataForAuthorship(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[common.Address]*types.EpochAuthorship)
+
+	for _, kp := range keypairs {
+		idx, ok := authorityIndexOf(kp.Public().(*sr25519.PublicKey), data.authorities)
+		if !ok {
+			continue
+		}
+
+		authorship := &types.EpochAuthorship{
+			Primary:      []uint64{},
+			Secondary:    []uint64{},
+			SecondaryVRF: []uint64{},
+		}
+
+		for slot := startSlot; slot < startSlot+b.epochLength; slot++ {
+			proof, err := claimPrimarySlot(data.randomness, slot, epoch, data.threshold, kp)
+			if err != nil {
+				return nil, err
+			}
+
+			if proof != nil {
+				authorship.Primary = append(authorship.Primary, slot)
+				continue
+			}
+
+			if data.secondarySlots == primarySlots {
+				continue
+			}
+
+			author, err := getSecondarySlotAuthor(slot, len(data.authorities), data.randomness)
+			if err != nil {
+				return nil, err
+			}
+
+			if author != idx {
+				continue
+			}
+
+			switch data.secondarySlots {
+			case primaryAndSecondaryPlainSlots:
+				authorship.Secondary = append(authorship.Secondary, slot)
+			case primaryAndSecondaryVRFSlots:
+				authorship.SecondaryVRF = append(authorship.SecondaryVRF, slot)
+			}
+		}
+
+		res[kp.Public().Address()] = authorship
+	}
+
+	return res, nil
+}
+
+// authorshipData contains the epoch information needed to find the slots that an authority can author a block in
+type authorshipData struct {
+	epochData
+	secondarySlots byte
+}
+
+// getEpochDataForAuthorship returns the randomness, authorities and threshold of the given epoch, and the type of
+// secondary slots allowed in it. The configuration of the epoch is the latest one at or before the epoch.
+func (b *Service) getEpochDataForAuthorship(epoch uint64) (*authorshipData, error) {
+	data, err := b.epochState.GetEpochData(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := b.getLatestConfigData(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold, err := CalculateThreshold(cfg.C1, cfg.C2, len(data.Authorities))
+	if err != nil {
+		return nil, err
+	}
+
+	return &authorshipData{
+		epochData: epochData{
+			randomness:  data.Randomness,
+			authorities: data.Authorities,
+			threshold:   threshold,
+		},
+		secondarySlots: cfg.SecondarySlots,
+	}, nil
+}
+
+// getLatestConfigData returns the latest configuration set at or before the given epoch
+func (b *Service) getLatestConfigData(epoch uint64) (*types.ConfigData, error) {
+	for e := epoch; ; e-- {
+		has, err := b.epochState.HasConfigData(e)
+		if err != nil {
+			return nil, err
+		}
+
+		if has {
+			return b.epochState.GetConfigData(e)
+		}
+
+		if e == 0 {
+			return nil, fmt.Errorf("no configuration data at or before epoch %d", epoch)
+		}
+	}
+}
+
+func authorityIndexOf(pub *sr25519.PublicKey, authorities []*types.Authority) (uint32, bool) {
+	for i, auth := range authorities {
+		if bytes.Equal(pub.Encode(), auth.Key.Encode()) {
+			return uint32(i), true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package babe
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+
+	"github.com/stretchr/testify/require"
+)
+
+// authorshipEpochState overrides the data and configuration of every epoch
+type authorshipEpochState struct {
+	EpochState
+	data *types.EpochData
+	cfg  *types.ConfigData
+}
+
+func (s *authorshipEpochState) GetEpochData(_ uint64) (*types.EpochData, error) {
+	return s.data, nil
+}
+
+func (s *authorshipEpochState) HasConfigData(_ uint64) (bool, error) {
+	return true, nil
+}
+
+func (s *authorshipEpochState) GetConfigData(_ uint64) (*types.ConfigData, error) {
+	return s.cfg, nil
+}
+
+// newAuthorshipEpochState returns an epoch state where the service's key is the only authority of every epoch
+func newAuthorshipEpochState(bs *Service, cfg *types.ConfigData) *authorshipEpochState {
+	return &authorshipEpochState{
+		EpochState: bs.epochState,
+		data: &types.EpochData{
+			Authorities: bs.epochData.authorities,
+			Randomness:  bs.epochData.randomness,
+		},
+		cfg: cfg,
+	}
+}
+
+func TestEpochAuthorship(t *testing.T) {
+	bs := createTestService(t, &ServiceConfig{
+		Authority:   true,
+		EpochLength: 10,
+	})
+
+	// every primary slot is won with C1/C2 = 1
+	bs.epochState = newAuthorshipEpochState(bs, &types.ConfigData{C1: 1, C2: 1})
+
+	// the service's epoch data isn't used, as it may belong to another epoch
+	bs.epochData = &epochData{threshold: minThreshold}
+
+	other, err := sr25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	res, err := bs.EpochAuthorship([]*sr25519.Keypair{bs.keypair, other})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	startSlot, err := bs.epochState.GetStartSlotForEpoch(0)
+	require.NoError(t, err)
+
+	authorship := res[bs.keypair.Public().Address()]
+	require.NotNil(t, authorship)
+	require.Len(t, authorship.Primary, 10)
+	require.Equal(t, startSlot, authorship.Primary[0])
+	require.Empty(t, authorship.Secondary)
+	require.Empty(t, authorship.SecondaryVRF)
+}
+
+func TestEpochAuthorship_Secondary(t *testing.T) {
+	bs := createTestService(t, &ServiceConfig{
+		Authority:   true,
+		EpochLength: 10,
+	})
+
+	epochState := newAuthorshipEpochState(bs, nil)
+	bs.epochState = epochState
+
+	// as we are the only authority, we are the author of every secondary slot, and no primary slot is won with C1 = 0
+	for _, secondarySlots := range []byte{primaryAndSecondaryPlainSlots, primaryAndSecondaryVRFSlots} {
+		epochState.cfg = &types.ConfigData{C1: 0, C2: 1, SecondarySlots: secondarySlots}
+
+		res, err := bs.EpochAuthorship([]*sr25519.Keypair{bs.keypair})
+		require.NoError(t, err)
+
+		authorship := res[bs.keypair.Public().Address()]
+		require.Empty(t, authorship.Primary)
+		if secondarySlots == primaryAndSecondaryPlainSlots {
+			require.Len(t, authorship.Secondary, 10)
+			require.Empty(t, authorship.SecondaryVRF)
+		} else {
+			require.Empty(t, authorship.Secondary)
+			require.Len(t, authorship.SecondaryVRF, 10)
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
 	"github.com/ChainSafe/gossamer/lib/runtime"
 	log "github.com/ChainSafe/log15"
@@ -66,7 +67,9 @@ type Service struct {
 
 	// State variables
 	sync.RWMutex
-	pause chan struct{}
+	pause         chan struct{}
+	authoringLock sync.Mutex // ensures only one block is built at a time, in a slot or manually
+	manualSeal    bool       // set once a block is created manually, slot authoring is suspended until resumed
 }
 
 // ServiceConfig represents a BABE configuration
@@ -240,6 +243,10 @@ func (b *Service) Pause() error {
 
 // Resume resumes the service ie. resumes block production
 func (b *Service) Resume() error {
+	b.authoringLock.Lock()
+	b.manualSeal = false
+	b.authoringLock.Unlock()
+
 	if !b.paused {
 		return nil
 	}
@@ -441,6 +448,14 @@ func (b *Service) invokeBlockAuthoring(epoch uint64) {
 }
 
 func (b *Service) handleSlot(slotNum uint64) error {
+	b.authoringLock.Lock()
+	defer b.authoringLock.Unlock()
+
+	if b.manualSeal {
+		logger.Trace("skipping slot, blocks are being created manually", "slot", slotNum)
+		return nil
+	}
+
 	if _, has := b.slotToProof[slotNum]; !has {
 		return ErrNotAuthorized
 	}
@@ -484,7 +499,7 @@ func (b *Service) handleSlot(slotNum uint64) error {
 		return nil
 	}
 
-	err = b.storageState.StoreTrie(ts)
+	err = b.storageState.StoreTrie(ts, block.Header)
 	if err != nil {
 		logger.Error("failed to store trie in storage state", "error", err)
 	}
@@ -507,6 +522,123 @@ func (b *Service) handleSlot(slotNum uint64) error {
 	return nil
 }
 
+// CreateBlock builds a block on top of the given parent, or the best block if parent is nil, imports it, and returns it.
+// The block is built for the current slot without waiting for the slot to start, whether or not the slot lottery is
+// won for it. This is used to manually seal blocks on demand in development; once a block is created, blocks are no
+// longer authored in slots until Resume is called.
+func (b *Service) CreateBlock(parentHash *common.Hash) (*types.Block, error) {
+	if !b.authority {
+		return nil, ErrNotAuthority
+	}
+
+	b.authoringLock.Lock()
+	defer b.authoringLock.Unlock()
+
+	var (
+		parentHeader *types.Header
+		err          error
+	)
+
+	if parentHash == nil {
+		parentHeader, err = b.blockState.BestBlockHeader()
+	} else {
+		parentHeader, err = b.blockState.GetHeader(*parentHash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent header: %w", err)
+	}
+
+	parent := parentHeader.DeepCopy()
+
+	slotNum := getCurrentSlot(b.slotDuration)
+	if parent.Number.Cmp(big.NewInt(0)) > 0 {
+		parentSlot, err := types.GetSlotFromHeader(parent) //nolint
+		if err != nil {
+			return nil, err
+		}
+
+		// blocks may be created faster than the slot duration, but each block needs a later slot than its parent
+		if slotNum <= parentSlot {
+			slotNum = parentSlot + 1
+		}
+	}
+
+	proof, err := b.claimSlotForManualSeal(slotNum)
+	if err != nil {
+		return nil, err
+	}
+
+	builder, err := NewBlockBuilder(
+		b.rt,
+		b.keypair,
+		b.transactionState,
+		b.blockState,
+		map[uint64]*VrfOutputAndProof{slotNum: proof},
+		b.epochData.authorityIndex,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create block builder: %w", err)
+	}
+
+	ts, err := b.storageState.TrieState(&parent.StateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent trie: %w", err)
+	}
+
+	b.rt.SetContextStorage(ts)
+
+	block, err := builder.buildBlock(parent, Slot{
+		start:    time.Now(),
+		duration: b.slotDuration,
+		number:   slotNum,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.storageState.StoreTrie(ts, block.Header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store trie in storage state: %w", err)
+	}
+
+	hash := block.Header.Hash()
+	logger.Info("created block", "hash", hash.String(), "number", block.Header.Number, "state root", block.Header.StateRoot, "slot", slotNum)
+
+	err = b.blockState.AddBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	b.manualSeal = true
+
+	err = b.safeSend(*block)
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// claimSlotForManualSeal returns the VRF output and proof for the given slot. The lottery is run with the maximum
+// threshold, so that manual sealing doesn't depend on the VRF output and the slot is always claimed.
+func (b *Service) claimSlotForManualSeal(slot uint64) (*VrfOutputAndProof, error) {
+	epoch, err := b.epochState.GetCurrentEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := claimPrimarySlot(b.epochData.randomness, slot, epoch, common.MaxUint128, b.keypair)
+	if err != nil {
+		return nil, err
+	}
+
+	if proof == nil {
+		return nil, ErrNotAuthorized
+	}
+
+	return proof, nil
+}
+
 func getCurrentSlot(slotDuration time.Duration) uint64 {
 	return uint64(time.Now().UnixNano()) / uint64(slotDuration.Nanoseconds())
 }
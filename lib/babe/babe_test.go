@@ -252,3 +252,93 @@ func TestStartAndStop(t *testing.T) {
 	err = bs.Stop()
 	require.NoError(t, err)
 }
+
+func TestService_CreateBlock(t *testing.T) {
+	bs := createTestService(t, &ServiceConfig{
+		Authority: true,
+		LogLvl:    log.LvlCrit,
+	})
+
+	bs.epochData.threshold = maxThreshold
+
+	go func() {
+		for range bs.GetBlockChannel() {
+		}
+	}()
+	t.Cleanup(func() {
+		_ = bs.Stop()
+	})
+
+	block, err := bs.CreateBlock(nil)
+	require.NoError(t, err)
+	require.Equal(t, genesisHeader.Hash(), block.Header.ParentHash)
+	require.Equal(t, big.NewInt(1), block.Header.Number)
+	require.Equal(t, block.Header.Hash(), bs.blockState.BestBlockHash())
+
+	parentHash := block.Header.Hash()
+	next, err := bs.CreateBlock(&parentHash)
+	require.NoError(t, err)
+	require.Equal(t, parentHash, next.Header.ParentHash)
+	require.Equal(t, big.NewInt(2), next.Header.Number)
+
+	slot, err := types.GetSlotFromHeader(block.Header)
+	require.NoError(t, err)
+	nextSlot, err := types.GetSlotFromHeader(next.Header)
+	require.NoError(t, err)
+	require.Greater(t, nextSlot, slot)
+
+	// blocks aren't authored in slots until the service is resumed
+	bs.slotToProof[nextSlot+1] = &VrfOutputAndProof{}
+	err = bs.handleSlot(nextSlot + 1)
+	require.NoError(t, err)
+	require.Equal(t, next.Header.Hash(), bs.blockState.BestBlockHash())
+
+	err = bs.Resume()
+	require.NoError(t, err)
+	require.False(t, bs.manualSeal)
+}
+
+func TestService_CreateBlock_LotteryLost(t *testing.T) {
+	bs := createTestService(t, &ServiceConfig{
+		Authority: true,
+		LogLvl:    log.LvlCrit,
+	})
+
+	// blocks are created even if the slot lottery is lost
+	bs.epochData.threshold = minThreshold
+
+	go func() {
+		for range bs.GetBlockChannel() {
+		}
+	}()
+	t.Cleanup(func() {
+		_ = bs.Stop()
+	})
+
+	block, err := bs.CreateBlock(nil)
+	require.NoError(t, err)
+	require.Equal(t, block.Header.Hash(), bs.blockState.BestBlockHash())
+	require.True(t, bs.manualSeal)
+}
+
+func TestService_CreateBlock_Failure(t *testing.T) {
+	bs := createTestService(t, &ServiceConfig{
+		Authority: true,
+		LogLvl:    log.LvlCrit,
+	})
+
+	// slot authoring isn't suspended if no block is created
+	_, err := bs.CreateBlock(&common.Hash{0x1})
+	require.Error(t, err)
+	require.Equal(t, genesisHeader.Hash(), bs.blockState.BestBlockHash())
+	require.False(t, bs.manualSeal)
+}
+
+func TestService_CreateBlock_NotAuthority(t *testing.T) {
+	bs := createTestService(t, &ServiceConfig{
+		LogLvl: log.LvlCrit,
+	})
+
+	_, err := bs.CreateBlock(nil)
+	require.Equal(t, ErrNotAuthority, err)
+}
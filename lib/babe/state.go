@@ -48,7 +48,7 @@ type BlockState interface {
 // StorageState interface for storage state methods
 type StorageState interface {
 	TrieState(hash *common.Hash) (*rtstorage.TrieState, error)
-	StoreTrie(ts *rtstorage.TrieState) error
+	StoreTrie(ts *rtstorage.TrieState, header *types.Header) error
 }
 
 // TransactionState is the interface for transaction queue methods
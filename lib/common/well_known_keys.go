@@ -3,6 +3,9 @@ package common
 var (
 	// CodeKey is the key where runtime code is stored in the trie
 	CodeKey = []byte(":code")
+
+	// HeapPagesKey is the key where the number of pages of the runtime's heap is stored in the trie
+	HeapPagesKey = []byte(":heappages")
 )
 
 // BalanceKey returns the storage trie key for the balance of the account with the given public key
@@ -0,0 +1,295 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package conformance
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/optional"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/life"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+	"github.com/ChainSafe/gossamer/lib/runtime/wasmer"
+	"github.com/ChainSafe/gossamer/lib/runtime/wasmtime"
+	"github.com/ChainSafe/gossamer/lib/trie"
+
+	log "github.com/ChainSafe/log15"
+	wasmtimego "github.com/bytecodealliance/wasmtime-go"
+	"github.com/stretchr/testify/require"
+)
+
+// backend creates runtime instances using one of the wasm interpreters
+type backend struct {
+	name        string
+	newInstance func(code []byte, cfg runtime.InstanceConfig) (runtime.Instance, error)
+}
+
+var backends = []backend{
+	{
+		name: wasmer.Name,
+		newInstance: func(code []byte, cfg runtime.InstanceConfig) (runtime.Instance, error) {
+			return wasmer.NewInstance(code, &wasmer.Config{
+				InstanceConfig: cfg,
+				Imports:        wasmer.ImportsNodeRuntime,
+			})
+		},
+	},
+	{
+		name: wasmtime.Name,
+		newInstance: func(code []byte, cfg runtime.InstanceConfig) (runtime.Instance, error) {
+			return wasmtime.NewInstance(code, &wasmtime.Config{
+				InstanceConfig: cfg,
+				Imports:        wasmtime.ImportNodeRuntime,
+			})
+		},
+	},
+	{
+		name: life.Name,
+		newInstance: func(code []byte, cfg runtime.InstanceConfig) (runtime.Instance, error) {
+			return life.NewInstance(code, &life.Config{
+				InstanceConfig: cfg,
+			})
+		},
+	},
+}
+
+// testModule wraps the given imports and body of the exported function "test", which takes the (ptr, len) of the
+// call's input and returns the span of its output like any runtime function. $span packs a pointer and length into
+// a span.
+func testModule(imports, body string) string {
+	return fmt.Sprintf(`(module
+	%s
+	(memory (export "memory") 23)
+	(global (export "__heap_base") i32 (i32.const 1469576))
+	(func $span (param $ptr i32) (param $len i32) (result i64)
+		(i64.or
+			(i64.extend_i32_u (local.get $ptr))
+			(i64.shl (i64.extend_i32_u (local.get $len)) (i64.const 32))))
+	(func (export "test") (param $ptr i32) (param $len i32) (result i64)
+		%s))`, imports, body)
+}
+
+// conformanceCase runs the "test" function of a module with the given input and checks its output and the storage
+// it leaves behind
+type conformanceCase struct {
+	name  string
+	wat   string
+	input []byte
+	check func(t *testing.T, out []byte, s *rtstorage.TrieState)
+}
+
+const (
+	importStorageSet    = `(import "env" "ext_storage_set_version_1" (func $set (param i64 i64)))`
+	importStorageGet    = `(import "env" "ext_storage_get_version_1" (func $get (param i64) (result i64)))`
+	importStorageClear  = `(import "env" "ext_storage_clear_version_1" (func $clear (param i64)))`
+	importStorageNext   = `(import "env" "ext_storage_next_key_version_1" (func $next (param i64) (result i64)))`
+	importStorageAppend = `(import "env" "ext_storage_append_version_1" (func $append (param i64 i64)))`
+	importStorageRoot   = `(import "env" "ext_storage_root_version_1" (func $root (result i64)))`
+	importBlake2256     = `(import "env" "ext_hashing_blake2_256_version_1" (func $hash (param i64) (result i32)))`
+	importTwox128       = `(import "env" "ext_hashing_twox_128_version_1" (func $hash (param i64) (result i32)))`
+	importKeccak256     = `(import "env" "ext_hashing_keccak_256_version_1" (func $hash (param i64) (result i32)))`
+	importMalloc        = `(import "env" "ext_allocator_malloc_version_1" (func $malloc (param i32) (result i32)))`
+	importEd25519Verify = `(import "env" "ext_crypto_ed25519_verify_version_1" (func $verify (param i32 i64 i32) (result i32)))`
+
+	// the first byte of the input is the key, the rest is the value
+	keySpan   = `(call $span (local.get $ptr) (i32.const 1))`
+	valueSpan = `(call $span (i32.add (local.get $ptr) (i32.const 1)) (i32.sub (local.get $len) (i32.const 1)))`
+	inputSpan = `(call $span (local.get $ptr) (local.get $len))`
+)
+
+func encodeOptional(t *testing.T, value []byte) []byte {
+	enc, err := optional.NewBytes(value != nil, value).Encode()
+	require.NoError(t, err)
+	return enc
+}
+
+func testCases(t *testing.T) []conformanceCase {
+	kp, err := ed25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	msg := []byte("conformance")
+	sig, err := kp.Sign(msg)
+	require.NoError(t, err)
+
+	// the input of the ed25519 cases is the signature, followed by the public key and the message
+	verifyInput := append(append(append([]byte{}, sig...), kp.Public().Encode()...), msg...)
+	badInput := append([]byte{}, verifyInput...)
+	badInput[0]++
+
+	verifyBody := `(i32.store8 (local.get $ptr)
+			(call $verify
+				(local.get $ptr)
+				(call $span (i32.add (local.get $ptr) (i32.const 96)) (i32.sub (local.get $len) (i32.const 96)))
+				(i32.add (local.get $ptr) (i32.const 64))))
+		(call $span (local.get $ptr) (i32.const 1))`
+
+	return []conformanceCase{
+		{
+			name:  "storage set and get",
+			wat:   testModule(importStorageSet+importStorageGet, `(call $set `+keySpan+valueSpan+`) (call $get `+keySpan+`)`),
+			input: []byte("kvalue"),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				require.Equal(t, encodeOptional(t, []byte("value")), out)
+				require.Equal(t, []byte("value"), s.Get([]byte("k")))
+			},
+		},
+		{
+			name: "storage clear",
+			wat: testModule(importStorageSet+importStorageGet+importStorageClear,
+				`(call $set `+keySpan+valueSpan+`) (call $clear `+keySpan+`) (call $get `+keySpan+`)`),
+			input: []byte("kvalue"),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				require.Equal(t, encodeOptional(t, nil), out)
+				require.Nil(t, s.Get([]byte("k")))
+			},
+		},
+		{
+			name: "storage next key",
+			// sets each of the two input bytes as a key and returns the key after the first one
+			wat: testModule(importStorageSet+importStorageNext, `
+		(call $set `+keySpan+keySpan+`)
+		(call $set
+			(call $span (i32.add (local.get $ptr) (i32.const 1)) (i32.const 1))
+			(call $span (i32.add (local.get $ptr) (i32.const 1)) (i32.const 1)))
+		(call $next `+keySpan+`)`),
+			input: []byte("ab"),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				require.Equal(t, encodeOptional(t, []byte("b")), out)
+			},
+		},
+		{
+			name: "storage append",
+			wat: testModule(importStorageAppend+importStorageGet,
+				`(call $append `+keySpan+valueSpan+`) (call $append `+keySpan+valueSpan+`) (call $get `+keySpan+`)`),
+			input: []byte{'k', 4, 1, 2},
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				// the value is a SCALE encoded vector of the two appended items
+				expected := []byte{8, 4, 1, 2, 4, 1, 2}
+				require.Equal(t, encodeOptional(t, expected), out)
+				require.Equal(t, expected, s.Get([]byte("k")))
+			},
+		},
+		{
+			name:  "storage root",
+			wat:   testModule(importStorageSet+importStorageRoot, `(call $set `+keySpan+valueSpan+`) (call $root)`),
+			input: []byte("kvalue"),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				tt := trie.NewEmptyTrie()
+				tt.Put([]byte("k"), []byte("value"))
+				expected, err := tt.Hash()
+				require.NoError(t, err)
+				require.Equal(t, expected[:], out)
+			},
+		},
+		{
+			name:  "hashing blake2_256",
+			wat:   testModule(importBlake2256, `(call $span (call $hash `+inputSpan+`) (i32.const 32))`),
+			input: []byte("conformance"),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				expected, err := common.Blake2bHash([]byte("conformance"))
+				require.NoError(t, err)
+				require.Equal(t, expected[:], out)
+			},
+		},
+		{
+			name:  "hashing twox_128",
+			wat:   testModule(importTwox128, `(call $span (call $hash `+inputSpan+`) (i32.const 16))`),
+			input: []byte("conformance"),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				expected, err := common.Twox128Hash([]byte("conformance"))
+				require.NoError(t, err)
+				require.Equal(t, expected, out)
+			},
+		},
+		{
+			name:  "hashing keccak_256",
+			wat:   testModule(importKeccak256, `(call $span (call $hash `+inputSpan+`) (i32.const 32))`),
+			input: []byte("conformance"),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				expected, err := common.Keccak256([]byte("conformance"))
+				require.NoError(t, err)
+				require.Equal(t, expected[:], out)
+			},
+		},
+		{
+			name: "allocation grows memory",
+			// allocates the number of bytes given by the input, which is more than the initial memory, and writes
+			// to the last byte of the allocation
+			wat: testModule(importMalloc, `(local $out i32) (local $size i32)
+		(local.set $size (i32.load (local.get $ptr)))
+		(local.set $out (call $malloc (local.get $size)))
+		(i32.store8 (i32.sub (i32.add (local.get $out) (local.get $size)) (i32.const 1)) (i32.const 0xff))
+		(call $span (local.get $out) (local.get $size))`),
+			input: func() []byte {
+				size := make([]byte, 4)
+				binary.LittleEndian.PutUint32(size, 4*1024*1024)
+				return size
+			}(),
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				require.Len(t, out, 4*1024*1024)
+				require.Equal(t, byte(0xff), out[len(out)-1])
+			},
+		},
+		{
+			name:  "ed25519 verify",
+			wat:   testModule(importEd25519Verify, verifyBody),
+			input: verifyInput,
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				require.Equal(t, []byte{1}, out)
+			},
+		},
+		{
+			name:  "ed25519 verify invalid signature",
+			wat:   testModule(importEd25519Verify, verifyBody),
+			input: badInput,
+			check: func(t *testing.T, out []byte, s *rtstorage.TrieState) {
+				require.Equal(t, []byte{0}, out)
+			},
+		},
+	}
+}
+
+func TestHostAPIConformance(t *testing.T) {
+	for _, c := range testCases(t) {
+		c := c
+		code, err := wasmtimego.Wat2Wasm(c.wat)
+		require.NoError(t, err, c.name)
+
+		for _, b := range backends {
+			b := b
+			t.Run(b.name+"/"+c.name, func(t *testing.T) {
+				s, err := rtstorage.NewTrieState(nil)
+				require.NoError(t, err)
+
+				instance, err := b.newInstance(code, runtime.InstanceConfig{
+					Storage: s,
+					LogLvl:  log.LvlCrit,
+				})
+				require.NoError(t, err)
+				defer instance.Stop()
+
+				out, err := instance.Exec("test", append([]byte{}, c.input...))
+				require.NoError(t, err)
+				c.check(t, out, s)
+			})
+		}
+	}
+}
@@ -67,6 +67,12 @@ var (
 	BlockBuilderApplyExtrinsic = "BlockBuilder_apply_extrinsic"
 	// BlockBuilderFinalizeBlock is the runtime API call BlockBuilder_finalize_block
 	BlockBuilderFinalizeBlock = "BlockBuilder_finalize_block"
+	// TransactionPaymentAPIQueryInfo is the runtime API call TransactionPaymentApi_query_info
+	TransactionPaymentAPIQueryInfo = "TransactionPaymentApi_query_info"
+	// ContractsAPIGetStorage is the runtime API call ContractsApi_get_storage
+	ContractsAPIGetStorage = "ContractsApi_get_storage"
+	// OffchainWorkerAPIOffchainWorker is the runtime API call OffchainWorkerApi_offchain_worker
+	OffchainWorkerAPIOffchainWorker = "OffchainWorkerApi_offchain_worker"
 )
 
 // GrandpaAuthoritiesKey is the location of GRANDPA authority data in the storage trie for LEGACY_NODE_RUNTIME and NODE_RUNTIME
@@ -35,3 +35,6 @@ var ErrUnknownTransaction = &json2.Error{Code: 1011, Message: "Unknown Transacti
 
 // ErrNilStorage is returned when the runtime context storage isn't set
 var ErrNilStorage = errors.New("runtime context storage is nil")
+
+// ErrEmptyRuntimeCode is returned when the storage doesn't contain any runtime code
+var ErrEmptyRuntimeCode = errors.New("runtime code is empty")
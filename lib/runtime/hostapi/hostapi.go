@@ -0,0 +1,123 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package hostapi implements the host functions imported by the runtime independently of the wasm backend.
+// Each backend provides an Env that exposes the instance's memory and context, and registers the functions in
+// Imports with its engine.
+package hostapi
+
+import (
+	"github.com/ChainSafe/gossamer/lib/runtime"
+
+	log "github.com/ChainSafe/log15"
+)
+
+var logger = log.New("pkg", "runtime", "module", "hostapi")
+
+// Env is the environment of the runtime instance that is calling a host function
+type Env interface {
+	// Memory returns the instance's memory. The returned slice is invalidated when the memory grows, so it must
+	// be fetched again after anything that may allocate.
+	Memory() []byte
+	// Context returns the instance's runtime context
+	Context() *runtime.Context
+	// RuntimeVersion returns the version of the given runtime code
+	RuntimeVersion(code []byte) (runtime.Version, error)
+}
+
+// SetLogHandler sets the handler of the host functions' logger
+func SetLogHandler(h log.Handler) {
+	logger.SetHandler(h)
+}
+
+// Imports maps the name of each host function to its implementation. Each implementation takes the Env as its first
+// parameter, followed by the function's wasm parameters.
+var Imports = map[string]interface{}{
+	"ext_allocator_free_version_1":                            ExtAllocatorFreeVersion1,
+	"ext_allocator_malloc_version_1":                          ExtAllocatorMallocVersion1,
+	"ext_crypto_ed25519_generate_version_1":                   ExtCryptoEd25519GenerateVersion1,
+	"ext_crypto_ed25519_public_keys_version_1":                ExtCryptoEd25519PublicKeysVersion1,
+	"ext_crypto_ed25519_sign_version_1":                       ExtCryptoEd25519SignVersion1,
+	"ext_crypto_ed25519_verify_version_1":                     ExtCryptoEd25519VerifyVersion1,
+	"ext_crypto_finish_batch_verify_version_1":                ExtCryptoFinishBatchVerifyVersion1,
+	"ext_crypto_secp256k1_ecdsa_recover_compressed_version_1": ExtCryptoSecp256k1EcdsaRecoverCompressedVersion1,
+	"ext_crypto_secp256k1_ecdsa_recover_version_1":            ExtCryptoSecp256k1EcdsaRecoverVersion1,
+	"ext_crypto_sr25519_generate_version_1":                   ExtCryptoSr25519GenerateVersion1,
+	"ext_crypto_sr25519_public_keys_version_1":                ExtCryptoSr25519PublicKeysVersion1,
+	"ext_crypto_sr25519_sign_version_1":                       ExtCryptoSr25519SignVersion1,
+	"ext_crypto_sr25519_verify_version_1":                     ExtCryptoSr25519VerifyVersion1,
+	"ext_crypto_sr25519_verify_version_2":                     ExtCryptoSr25519VerifyVersion2,
+	"ext_crypto_start_batch_verify_version_1":                 ExtCryptoStartBatchVerifyVersion1,
+	"ext_default_child_storage_clear_prefix_version_1":        ExtDefaultChildStorageClearPrefixVersion1,
+	"ext_default_child_storage_clear_version_1":               ExtDefaultChildStorageClearVersion1,
+	"ext_default_child_storage_exists_version_1":              ExtDefaultChildStorageExistsVersion1,
+	"ext_default_child_storage_get_version_1":                 ExtDefaultChildStorageGetVersion1,
+	"ext_default_child_storage_next_key_version_1":            ExtDefaultChildStorageNextKeyVersion1,
+	"ext_default_child_storage_read_version_1":                ExtDefaultChildStorageReadVersion1,
+	"ext_default_child_storage_root_version_1":                ExtDefaultChildStorageRootVersion1,
+	"ext_default_child_storage_set_version_1":                 ExtDefaultChildStorageSetVersion1,
+	"ext_default_child_storage_storage_kill_version_1":        ExtDefaultChildStorageStorageKillVersion1,
+	"ext_hashing_blake2_128_version_1":                        ExtHashingBlake2128Version1,
+	"ext_hashing_blake2_256_version_1":                        ExtHashingBlake2256Version1,
+	"ext_hashing_keccak_256_version_1":                        ExtHashingKeccak256Version1,
+	"ext_hashing_sha2_256_version_1":                          ExtHashingSha2256Version1,
+	"ext_hashing_twox_128_version_1":                          ExtHashingTwox128Version1,
+	"ext_hashing_twox_256_version_1":                          ExtHashingTwox256Version1,
+	"ext_hashing_twox_64_version_1":                           ExtHashingTwox64Version1,
+	"ext_logging_log_version_1":                               ExtLoggingLogVersion1,
+	"ext_misc_print_hex_version_1":                            ExtMiscPrintHexVersion1,
+	"ext_misc_print_num_version_1":                            ExtMiscPrintNumVersion1,
+	"ext_misc_print_utf8_version_1":                           ExtMiscPrintUtf8Version1,
+	"ext_misc_runtime_version_version_1":                      ExtMiscRuntimeVersionVersion1,
+	"ext_offchain_http_request_add_header_version_1":          ExtOffchainHttpRequestAddHeaderVersion1,
+	"ext_offchain_http_request_start_version_1":               ExtOffchainHttpRequestStartVersion1,
+	"ext_offchain_http_request_write_body_version_1":          ExtOffchainHttpRequestWriteBodyVersion1,
+	"ext_offchain_http_response_headers_version_1":            ExtOffchainHttpResponseHeadersVersion1,
+	"ext_offchain_http_response_read_body_version_1":          ExtOffchainHttpResponseReadBodyVersion1,
+	"ext_offchain_http_response_wait_version_1":               ExtOffchainHttpResponseWaitVersion1,
+	"ext_offchain_index_set_version_1":                        ExtOffchainIndexSetVersion1,
+	"ext_offchain_is_validator_version_1":                     ExtOffchainIsValidatorVersion1,
+	"ext_offchain_local_storage_compare_and_set_version_1":    ExtOffchainLocalStorageCompareAndSetVersion1,
+	"ext_offchain_local_storage_get_version_1":                ExtOffchainLocalStorageGetVersion1,
+	"ext_offchain_local_storage_set_version_1":                ExtOffchainLocalStorageSetVersion1,
+	"ext_offchain_network_state_version_1":                    ExtOffchainNetworkStateVersion1,
+	"ext_offchain_random_seed_version_1":                      ExtOffchainRandomSeedVersion1,
+	"ext_offchain_sleep_until_version_1":                      ExtOffchainSleepUntilVersion1,
+	"ext_offchain_submit_transaction_version_1":               ExtOffchainSubmitTransactionVersion1,
+	"ext_offchain_timestamp_version_1":                        ExtOffchainTimestampVersion1,
+	"ext_sandbox_instance_teardown_version_1":                 ExtSandboxInstanceTeardownVersion1,
+	"ext_sandbox_instantiate_version_1":                       ExtSandboxInstantiateVersion1,
+	"ext_sandbox_invoke_version_1":                            ExtSandboxInvokeVersion1,
+	"ext_sandbox_memory_get_version_1":                        ExtSandboxMemoryGetVersion1,
+	"ext_sandbox_memory_new_version_1":                        ExtSandboxMemoryNewVersion1,
+	"ext_sandbox_memory_set_version_1":                        ExtSandboxMemorySetVersion1,
+	"ext_sandbox_memory_teardown_version_1":                   ExtSandboxMemoryTeardownVersion1,
+	"ext_storage_append_version_1":                            ExtStorageAppendVersion1,
+	"ext_storage_changes_root_version_1":                      ExtStorageChangesRootVersion1,
+	"ext_storage_clear_prefix_version_1":                      ExtStorageClearPrefixVersion1,
+	"ext_storage_clear_version_1":                             ExtStorageClearVersion1,
+	"ext_storage_commit_transaction_version_1":                ExtStorageCommitTransactionVersion1,
+	"ext_storage_exists_version_1":                            ExtStorageExistsVersion1,
+	"ext_storage_get_version_1":                               ExtStorageGetVersion1,
+	"ext_storage_next_key_version_1":                          ExtStorageNextKeyVersion1,
+	"ext_storage_read_version_1":                              ExtStorageReadVersion1,
+	"ext_storage_rollback_transaction_version_1":              ExtStorageRollbackTransactionVersion1,
+	"ext_storage_root_version_1":                              ExtStorageRootVersion1,
+	"ext_storage_set_version_1":                               ExtStorageSetVersion1,
+	"ext_storage_start_transaction_version_1":                 ExtStorageStartTransactionVersion1,
+	"ext_trie_blake2_256_ordered_root_version_1":              ExtTrieBlake2256OrderedRootVersion1,
+	"ext_trie_blake2_256_root_version_1":                      ExtTrieBlake2256RootVersion1,
+}
@@ -0,0 +1,1992 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package hostapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/common/optional"
+	rtype "github.com/ChainSafe/gossamer/lib/common/types"
+	"github.com/ChainSafe/gossamer/lib/crypto"
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/crypto/secp256k1"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/offchain"
+	"github.com/ChainSafe/gossamer/lib/runtime/sandbox"
+	"github.com/ChainSafe/gossamer/lib/scale"
+	"github.com/ChainSafe/gossamer/lib/transaction"
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// ExtLoggingLogVersion1 implements ext_logging_log_version_1
+func ExtLoggingLogVersion1(env Env, level int32, targetData int64, msgData int64) {
+	logger.Trace("[ext_logging_log_version_1] executing...")
+
+	target := string(asMemorySlice(env, targetData))
+	msg := string(asMemorySlice(env, msgData))
+
+	switch int(level) {
+	case 0:
+		logger.Crit("[ext_logging_log_version_1]", "target", target, "message", msg)
+	case 1:
+		logger.Warn("[ext_logging_log_version_1]", "target", target, "message", msg)
+	case 2:
+		logger.Info("[ext_logging_log_version_1]", "target", target, "message", msg)
+	case 3:
+		logger.Debug("[ext_logging_log_version_1]", "target", target, "message", msg)
+	case 4:
+		logger.Trace("[ext_logging_log_version_1]", "target", target, "message", msg)
+	default:
+		logger.Error("[ext_logging_log_version_1]", "level", int(level), "target", target, "message", msg)
+	}
+}
+
+// ExtSandboxInstanceTeardownVersion1 implements ext_sandbox_instance_teardown_version_1
+func ExtSandboxInstanceTeardownVersion1(env Env, instanceIdx int32) {
+	logger.Trace("[ext_sandbox_instance_teardown_version_1] executing...")
+	sb := env.Context().Sandbox
+
+	err := sb.InstanceTeardown(uint32(instanceIdx))
+	if err != nil {
+		logger.Error("[ext_sandbox_instance_teardown_version_1]", "error", err)
+	}
+}
+
+// ExtSandboxInstantiateVersion1 implements ext_sandbox_instantiate_version_1
+func ExtSandboxInstantiateVersion1(env Env, dispatchThunk int32, wasmCodeSpan, envDefSpan int64, statePtr int32) int32 {
+	logger.Trace("[ext_sandbox_instantiate_version_1] executing...")
+	sb := env.Context().Sandbox
+
+	code := asMemorySlice(env, wasmCodeSpan)
+	envDef := asMemorySlice(env, envDefSpan)
+
+	idx, err := sb.Instantiate(uint32(dispatchThunk), code, envDef, uint32(statePtr))
+	if errors.Is(err, sandbox.ErrExecution) {
+		logger.Debug("[ext_sandbox_instantiate_version_1]", "error", err)
+		return toSandboxCode(sandbox.ErrCodeExecution)
+	} else if err != nil {
+		logger.Debug("[ext_sandbox_instantiate_version_1]", "error", err)
+		return toSandboxCode(sandbox.ErrCodeModule)
+	}
+
+	return int32(idx)
+}
+
+// ExtSandboxInvokeVersion1 implements ext_sandbox_invoke_version_1
+func ExtSandboxInvokeVersion1(env Env, instanceIdx int32, exportNameSpan, argsSpan int64, returnValPtr, returnValLen, statePtr int32) int32 {
+	logger.Trace("[ext_sandbox_invoke_version_1] executing...")
+	sb := env.Context().Sandbox
+
+	name := string(asMemorySlice(env, exportNameSpan))
+
+	args, err := sandbox.DecodeValues(asMemorySlice(env, argsSpan))
+	if err != nil {
+		logger.Error("[ext_sandbox_invoke_version_1] failed to decode arguments", "error", err)
+		return toSandboxCode(sandbox.ErrCodeExecution)
+	}
+
+	ret, err := sb.Invoke(uint32(instanceIdx), name, args, uint32(statePtr))
+	if err != nil {
+		logger.Debug("[ext_sandbox_invoke_version_1]", "function", name, "error", err)
+		return toSandboxCode(sandbox.ErrCodeExecution)
+	}
+
+	if ret == nil {
+		return toSandboxCode(sandbox.ErrCodeOK)
+	}
+
+	enc := sandbox.EncodeReturnValue(ret)
+	if len(enc) > int(uint32(returnValLen)) {
+		logger.Error("[ext_sandbox_invoke_version_1] return value buffer is too small")
+		return toSandboxCode(sandbox.ErrCodeExecution)
+	}
+
+	// the memory may have been grown by the invocation, so it's only accessed afterwards
+	memory := env.Memory()
+	if int(uint32(returnValPtr))+len(enc) > len(memory) {
+		logger.Error("[ext_sandbox_invoke_version_1] return value buffer is out of bounds")
+		return toSandboxCode(sandbox.ErrCodeExecution)
+	}
+
+	copy(memory[uint32(returnValPtr):], enc)
+	return toSandboxCode(sandbox.ErrCodeOK)
+}
+
+// ExtSandboxMemoryGetVersion1 implements ext_sandbox_memory_get_version_1
+func ExtSandboxMemoryGetVersion1(env Env, memoryIdx, offset, bufPtr, bufLen int32) int32 {
+	logger.Trace("[ext_sandbox_memory_get_version_1] executing...")
+	sb := env.Context().Sandbox
+
+	memory := env.Memory()
+	if uint64(uint32(bufPtr))+uint64(uint32(bufLen)) > uint64(len(memory)) {
+		return toSandboxCode(sandbox.ErrCodeOutOfBounds)
+	}
+
+	err := sb.MemoryGet(uint32(memoryIdx), uint32(offset), memory[uint32(bufPtr):uint32(bufPtr)+uint32(bufLen)])
+	if err != nil {
+		logger.Debug("[ext_sandbox_memory_get_version_1]", "error", err)
+		return toSandboxCode(sandbox.ErrCodeOutOfBounds)
+	}
+
+	return toSandboxCode(sandbox.ErrCodeOK)
+}
+
+// ExtSandboxMemoryNewVersion1 implements ext_sandbox_memory_new_version_1
+func ExtSandboxMemoryNewVersion1(env Env, initial, maximum int32) int32 {
+	logger.Trace("[ext_sandbox_memory_new_version_1] executing...")
+	sb := env.Context().Sandbox
+
+	idx, err := sb.NewMemory(uint32(initial), uint32(maximum))
+	if err != nil {
+		logger.Error("[ext_sandbox_memory_new_version_1]", "error", err)
+		return toSandboxCode(sandbox.ErrCodeModule)
+	}
+
+	return int32(idx)
+}
+
+// ExtSandboxMemorySetVersion1 implements ext_sandbox_memory_set_version_1
+func ExtSandboxMemorySetVersion1(env Env, memoryIdx, offset, valPtr, valLen int32) int32 {
+	logger.Trace("[ext_sandbox_memory_set_version_1] executing...")
+	sb := env.Context().Sandbox
+
+	memory := env.Memory()
+	if uint64(uint32(valPtr))+uint64(uint32(valLen)) > uint64(len(memory)) {
+		return toSandboxCode(sandbox.ErrCodeOutOfBounds)
+	}
+
+	err := sb.MemorySet(uint32(memoryIdx), uint32(offset), memory[uint32(valPtr):uint32(valPtr)+uint32(valLen)])
+	if err != nil {
+		logger.Debug("[ext_sandbox_memory_set_version_1]", "error", err)
+		return toSandboxCode(sandbox.ErrCodeOutOfBounds)
+	}
+
+	return toSandboxCode(sandbox.ErrCodeOK)
+}
+
+// ExtSandboxMemoryTeardownVersion1 implements ext_sandbox_memory_teardown_version_1
+func ExtSandboxMemoryTeardownVersion1(env Env, memoryIdx int32) {
+	logger.Trace("[ext_sandbox_memory_teardown_version_1] executing...")
+	sb := env.Context().Sandbox
+
+	err := sb.MemoryTeardown(uint32(memoryIdx))
+	if err != nil {
+		logger.Error("[ext_sandbox_memory_teardown_version_1]", "error", err)
+	}
+}
+
+// toSandboxCode converts a sandbox return code to the runtime's i32 representation
+func toSandboxCode(code uint32) int32 {
+	return int32(code)
+}
+
+// ExtCryptoEd25519GenerateVersion1 implements ext_crypto_ed25519_generate_version_1
+func ExtCryptoEd25519GenerateVersion1(env Env, keyTypeID int32, seedSpan int64) int32 {
+	logger.Trace("[ext_crypto_ed25519_generate_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	memory := env.Memory()
+
+	id := memory[keyTypeID : keyTypeID+4]
+	seedBytes := asMemorySlice(env, seedSpan)
+	buf := &bytes.Buffer{}
+	buf.Write(seedBytes)
+
+	seed, err := optional.NewBytes(false, nil).Decode(buf)
+	if err != nil {
+		logger.Warn("[ext_crypto_ed25519_generate_version_1] cannot generate key", "error", err)
+		return 0
+	}
+
+	var kp crypto.Keypair
+
+	if seed.Exists() {
+		kp, err = ed25519.NewKeypairFromMnenomic(string(seed.Value()), "")
+	} else {
+		kp, err = ed25519.GenerateKeypair()
+	}
+
+	if err != nil {
+		logger.Warn("[ext_crypto_ed25519_generate_version_1] cannot generate key", "error", err)
+		return 0
+	}
+
+	ks, err := runtimeCtx.Keystore.GetKeystore(id)
+	if err != nil {
+		logger.Warn("[ext_crypto_ed25519_generate_version_1]", "name", id, "error", err)
+		return 0
+	}
+
+	ks.Insert(kp)
+
+	ret, err := toWasmMemorySized(env, kp.Public().Encode(), 32)
+	if err != nil {
+		logger.Warn("[ext_crypto_ed25519_generate_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_crypto_ed25519_generate_version_1] generated ed25519 keypair", "public", kp.Public().Hex())
+	return int32(ret)
+}
+
+// ExtCryptoEd25519PublicKeysVersion1 implements ext_crypto_ed25519_public_keys_version_1
+func ExtCryptoEd25519PublicKeysVersion1(env Env, keyTypeID int32) int64 {
+	logger.Debug("[ext_crypto_ed25519_public_keys_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	memory := env.Memory()
+
+	id := memory[keyTypeID : keyTypeID+4]
+
+	ks, err := runtimeCtx.Keystore.GetKeystore(id)
+	if err != nil {
+		logger.Warn("[ext_crypto_ed25519_public_keys_version_1]", "name", id, "error", err)
+		ret, _ := toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	if ks.Type() != crypto.Ed25519Type && ks.Type() != crypto.UnknownType {
+		logger.Warn("[ext_crypto_ed25519_public_keys_version_1]", "name", id, "error", "keystore type is not ed25519", "type", ks.Type())
+		ret, _ := toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	keys := ks.PublicKeys()
+
+	var encodedKeys []byte
+	for _, key := range keys {
+		encodedKeys = append(encodedKeys, key.Encode()...)
+	}
+
+	prefix, err := scale.Encode(big.NewInt(int64(len(keys))))
+	if err != nil {
+		logger.Error("[ext_crypto_ed25519_public_keys_version_1] failed to allocate memory", err)
+		ret, _ := toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	ret, err := toWasmMemory(env, append(prefix, encodedKeys...))
+	if err != nil {
+		logger.Error("[ext_crypto_ed25519_public_keys_version_1] failed to allocate memory", err)
+		ret, _ = toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	return ret
+}
+
+// ExtCryptoEd25519SignVersion1 implements ext_crypto_ed25519_sign_version_1
+func ExtCryptoEd25519SignVersion1(env Env, keyTypeID int32, key int32, msg int64) int64 {
+	logger.Debug("[ext_crypto_ed25519_sign_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	memory := env.Memory()
+
+	id := memory[keyTypeID : keyTypeID+4]
+
+	pubKeyData := memory[key : key+32]
+	pubKey, err := ed25519.NewPublicKey(pubKeyData)
+	if err != nil {
+		logger.Error("[ext_crypto_ed25519_sign_version_1] failed to get public keys", "error", err)
+		return 0
+	}
+
+	ks, err := runtimeCtx.Keystore.GetKeystore(id)
+	if err != nil {
+		logger.Warn("[ext_crypto_ed25519_sign_version_1]", "name", id, "error", err)
+		ret, _ := toWasmMemoryOptional(env, nil)
+		return ret
+	}
+
+	var ret int64
+	signingKey := ks.GetKeypair(pubKey)
+	if signingKey == nil {
+		logger.Error("[ext_crypto_ed25519_sign_version_1] could not find public key in keystore", "error", pubKey)
+		ret, err = toWasmMemoryOptional(env, nil)
+		if err != nil {
+			logger.Error("[ext_crypto_ed25519_sign_version_1] failed to allocate memory", err)
+			return 0
+		}
+		return ret
+	}
+
+	sig, err := signingKey.Sign(asMemorySlice(env, msg))
+	if err != nil {
+		logger.Error("[ext_crypto_ed25519_sign_version_1] could not sign message")
+	}
+
+	ret, err = toWasmMemoryFixedSizeOptional(env, sig)
+	if err != nil {
+		logger.Error("[ext_crypto_ed25519_sign_version_1] failed to allocate memory", err)
+		return 0
+	}
+
+	return ret
+}
+
+// ExtCryptoEd25519VerifyVersion1 implements ext_crypto_ed25519_verify_version_1
+func ExtCryptoEd25519VerifyVersion1(env Env, sig int32, msg int64, key int32) int32 {
+	logger.Debug("[ext_crypto_ed25519_verify_version_1] executing...")
+
+	memory := env.Memory()
+	sigVerifier := env.Context().SigVerifier
+
+	signature := memory[sig : sig+64]
+	message := asMemorySlice(env, msg)
+	pubKeyData := memory[key : key+32]
+
+	pubKey, err := ed25519.NewPublicKey(pubKeyData)
+	if err != nil {
+		logger.Error("[ext_crypto_ed25519_verify_version_1] failed to create public key")
+		return 0
+	}
+
+	// the signature is verified when the batch is finished, so it's copied out of the wasm memory
+	if sigVerifier.IsStarted() {
+		signature := runtime.Signature{
+			PubKey:    pubKey.Encode(),
+			Sign:      append([]byte{}, signature...),
+			Msg:       append([]byte{}, message...),
+			KeyTypeID: crypto.Ed25519Type,
+		}
+		sigVerifier.Add(&signature)
+		return 1
+	}
+
+	if ok, err := pubKey.Verify(message, signature); err != nil || !ok {
+		logger.Error("[ext_crypto_ed25519_verify_version_1] failed to verify")
+		return 0
+	}
+
+	logger.Debug("[ext_crypto_ed25519_verify_version_1] verified ed25519 signature")
+	return 1
+}
+
+// ExtCryptoSecp256k1EcdsaRecoverVersion1 implements ext_crypto_secp256k1_ecdsa_recover_version_1
+func ExtCryptoSecp256k1EcdsaRecoverVersion1(env Env, sig, msg int32) int64 {
+	logger.Trace("[ext_crypto_secp256k1_ecdsa_recover_version_1] executing...")
+	memory := env.Memory()
+
+	// msg must be the 32-byte hash of the message to be signed.
+	// sig must be a 65-byte compact ECDSA signature containing the
+	// recovery id as the last element
+	message := memory[msg : msg+32]
+	signature := memory[sig : sig+65]
+
+	if signature[64] == 27 {
+		signature[64] = 0
+	}
+
+	if signature[64] == 28 {
+		signature[64] = 1
+	}
+
+	// the recovered public key is returned to the runtime, so unlike signature verification the recovery can't be
+	// deferred to the signature batch
+	pub, err := secp256k1.RecoverPublicKey(message, signature)
+	if err != nil {
+		logger.Error("[ext_crypto_secp256k1_ecdsa_recover_version_1] failed to recover public key", "error", err)
+		var ret int64
+		ret, err = toWasmMemoryResult(env, nil)
+		if err != nil {
+			logger.Error("[ext_crypto_secp256k1_ecdsa_recover_version_1] failed to allocate memory", "error", err)
+			return 0
+		}
+		return ret
+	}
+
+	logger.Debug("[ext_crypto_secp256k1_ecdsa_recover_version_1]", "len", len(pub), "recovered public key", fmt.Sprintf("0x%x", pub))
+
+	ret, err := toWasmMemoryResult(env, pub[1:])
+	if err != nil {
+		logger.Error("[ext_crypto_secp256k1_ecdsa_recover_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+
+	return ret
+}
+
+// ExtCryptoSecp256k1EcdsaRecoverCompressedVersion1 implements ext_crypto_secp256k1_ecdsa_recover_compressed_version_1
+func ExtCryptoSecp256k1EcdsaRecoverCompressedVersion1(env Env, sig, msg int32) int64 {
+	logger.Trace("[ext_crypto_secp256k1_ecdsa_recover_compressed_version_1] executing...")
+	memory := env.Memory()
+
+	// msg must be the 32-byte hash of the message to be signed.
+	// sig must be a 65-byte compact ECDSA signature containing the
+	// recovery id as the last element
+	message := memory[msg : msg+32]
+	signature := memory[sig : sig+65]
+
+	if signature[64] == 27 {
+		signature[64] = 0
+	}
+
+	if signature[64] == 28 {
+		signature[64] = 1
+	}
+
+	cpub, err := secp256k1.RecoverPublicKeyCompressed(message, signature)
+	if err != nil {
+		logger.Error("[ext_crypto_secp256k1_ecdsa_recover_compressed_version_1] failed to recover public key", "error", err)
+		ret, _ := toWasmMemoryResult(env, nil)
+		return ret
+	}
+
+	logger.Debug("[ext_crypto_secp256k1_ecdsa_recover_compressed_version_1]", "len", len(cpub), "recovered public key", fmt.Sprintf("0x%x", cpub))
+
+	ret, err := toWasmMemoryResult(env, cpub)
+	if err != nil {
+		logger.Error("[ext_crypto_secp256k1_ecdsa_recover_compressed_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+
+	return ret
+}
+
+// ExtCryptoSr25519GenerateVersion1 implements ext_crypto_sr25519_generate_version_1
+func ExtCryptoSr25519GenerateVersion1(env Env, keyTypeID int32, seedSpan int64) int32 {
+	logger.Trace("[ext_crypto_sr25519_generate_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	memory := env.Memory()
+
+	id := memory[keyTypeID : keyTypeID+4]
+
+	seedBytes := asMemorySlice(env, seedSpan)
+	buf := &bytes.Buffer{}
+	buf.Write(seedBytes)
+
+	seed, err := optional.NewBytes(false, nil).Decode(buf)
+	if err != nil {
+		logger.Warn("[ext_crypto_sr25519_generate_version_1] cannot generate key", "error", err)
+		return 0
+	}
+
+	var kp crypto.Keypair
+	if seed.Exists() {
+		kp, err = sr25519.NewKeypairFromMnenomic(string(seed.Value()), "")
+	} else {
+		kp, err = sr25519.GenerateKeypair()
+	}
+
+	if err != nil {
+		logger.Trace("[ext_crypto_sr25519_generate_version_1] cannot generate key", "error", err)
+		panic(err)
+	}
+
+	ks, err := runtimeCtx.Keystore.GetKeystore(id)
+	if err != nil {
+		logger.Warn("[ext_crypto_sr25519_generate_version_1]", "name", id, "error", err)
+		return 0
+	}
+
+	ks.Insert(kp)
+	ret, err := toWasmMemorySized(env, kp.Public().Encode(), 32)
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_generate_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_crypto_sr25519_generate_version_1] generated sr25519 keypair", "public", kp.Public().Hex())
+	return int32(ret)
+}
+
+// ExtCryptoSr25519PublicKeysVersion1 implements ext_crypto_sr25519_public_keys_version_1
+func ExtCryptoSr25519PublicKeysVersion1(env Env, keyTypeID int32) int64 {
+	logger.Debug("[ext_crypto_sr25519_public_keys_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	memory := env.Memory()
+
+	id := memory[keyTypeID : keyTypeID+4]
+
+	ks, err := runtimeCtx.Keystore.GetKeystore(id)
+	if err != nil {
+		logger.Warn("[ext_crypto_sr25519_public_keys_version_1]", "name", id, "error", err)
+		ret, _ := toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	if ks.Type() != crypto.Sr25519Type && ks.Type() != crypto.UnknownType {
+		logger.Warn("[ext_crypto_sr25519_public_keys_version_1]", "name", id, "error", "keystore type is not sr25519")
+		ret, _ := toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	keys := ks.PublicKeys()
+
+	var encodedKeys []byte
+	for _, key := range keys {
+		encodedKeys = append(encodedKeys, key.Encode()...)
+	}
+
+	prefix, err := scale.Encode(big.NewInt(int64(len(keys))))
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_public_keys_version_1] failed to allocate memory", err)
+		ret, _ := toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	ret, err := toWasmMemory(env, append(prefix, encodedKeys...))
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_public_keys_version_1] failed to allocate memory", err)
+		ret, _ = toWasmMemory(env, []byte{0})
+		return ret
+	}
+
+	return ret
+}
+
+// ExtCryptoSr25519SignVersion1 implements ext_crypto_sr25519_sign_version_1
+func ExtCryptoSr25519SignVersion1(env Env, keyTypeID, key int32, msg int64) int64 {
+	logger.Debug("[ext_crypto_sr25519_sign_version_1] executing...")
+	runtimeCtx := env.Context()
+	memory := env.Memory()
+
+	emptyRet, _ := toWasmMemoryOptional(env, nil)
+
+	id := memory[keyTypeID : keyTypeID+4]
+
+	ks, err := runtimeCtx.Keystore.GetKeystore(id)
+	if err != nil {
+		logger.Warn("[ext_crypto_sr25519_sign_version_1]", "name", id, "error", err)
+		return emptyRet
+	}
+
+	var ret int64
+	pubKey, err := sr25519.NewPublicKey(memory[key : key+32])
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_sign_version_1] failed to get public key", "error", err)
+		return emptyRet
+	}
+
+	signingKey := ks.GetKeypair(pubKey)
+	if signingKey == nil {
+		logger.Error("[ext_crypto_sr25519_sign_version_1] could not find public key in keystore", "error", pubKey)
+		return emptyRet
+	}
+
+	msgData := asMemorySlice(env, msg)
+	sig, err := signingKey.Sign(msgData)
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_sign_version_1] could not sign message", "error", err)
+		return emptyRet
+	}
+
+	ret, err = toWasmMemoryFixedSizeOptional(env, sig)
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_sign_version_1] failed to allocate memory", "error", err)
+		return emptyRet
+	}
+
+	return ret
+}
+
+// ExtCryptoSr25519VerifyVersion1 implements ext_crypto_sr25519_verify_version_1
+func ExtCryptoSr25519VerifyVersion1(env Env, sig int32, msg int64, key int32) int32 {
+	logger.Debug("[ext_crypto_sr25519_verify_version_1] executing...")
+
+	memory := env.Memory()
+	sigVerifier := env.Context().SigVerifier
+
+	message := asMemorySlice(env, msg)
+	signature := memory[sig : sig+64]
+
+	pub, err := sr25519.NewPublicKey(memory[key : key+32])
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_verify_version_1] invalid sr25519 public key")
+		return 0
+	}
+
+	logger.Debug("[ext_crypto_sr25519_verify_version_1]", "pub", pub.Hex(),
+		"message", fmt.Sprintf("0x%x", message),
+		"signature", fmt.Sprintf("0x%x", signature),
+	)
+
+	// the signature is verified when the batch is finished, so it's copied out of the wasm memory
+	if sigVerifier.IsStarted() {
+		signature := runtime.Signature{
+			PubKey:    pub.Encode(),
+			Sign:      append([]byte{}, signature...),
+			Msg:       append([]byte{}, message...),
+			KeyTypeID: runtime.Sr25519DeprecatedType,
+		}
+		sigVerifier.Add(&signature)
+		return 1
+	}
+
+	if ok, err := pub.VerifyDeprecated(message, signature); err != nil || !ok {
+		logger.Error("[ext_crypto_sr25519_verify_version_1] failed to validate signature", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_crypto_sr25519_verify_version_1] verified sr25519 signature")
+	return 1
+}
+
+// ExtCryptoSr25519VerifyVersion2 implements ext_crypto_sr25519_verify_version_2
+func ExtCryptoSr25519VerifyVersion2(env Env, sig int32, msg int64, key int32) int32 {
+	logger.Trace("[ext_crypto_sr25519_verify_version_2] executing...")
+
+	memory := env.Memory()
+	sigVerifier := env.Context().SigVerifier
+
+	message := asMemorySlice(env, msg)
+	signature := memory[sig : sig+64]
+
+	pub, err := sr25519.NewPublicKey(memory[key : key+32])
+	if err != nil {
+		logger.Error("[ext_crypto_sr25519_verify_version_2] invalid sr25519 public key")
+		return 0
+	}
+
+	logger.Debug("[ext_crypto_sr25519_verify_version_2]", "pub", pub.Hex(),
+		"message", fmt.Sprintf("0x%x", message),
+		"signature", fmt.Sprintf("0x%x", signature),
+	)
+
+	// the signature is verified when the batch is finished, so it's copied out of the wasm memory
+	if sigVerifier.IsStarted() {
+		signature := runtime.Signature{
+			PubKey:    pub.Encode(),
+			Sign:      append([]byte{}, signature...),
+			Msg:       append([]byte{}, message...),
+			KeyTypeID: crypto.Sr25519Type,
+		}
+		sigVerifier.Add(&signature)
+		return 1
+	}
+
+	if ok, err := pub.Verify(message, signature); err != nil || !ok {
+		logger.Error("[ext_crypto_sr25519_verify_version_2] failed to validate signature", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_crypto_sr25519_verify_version_2] validated signature")
+	return 1
+}
+
+// ExtCryptoStartBatchVerifyVersion1 implements ext_crypto_start_batch_verify_version_1
+func ExtCryptoStartBatchVerifyVersion1(env Env) {
+	logger.Debug("[ext_crypto_start_batch_verify_version_1] executing...")
+
+	sigVerifier := env.Context().SigVerifier
+
+	if sigVerifier.IsStarted() {
+		logger.Error("[ext_crypto_start_batch_verify_version_1] previous batch verification is not finished")
+		return
+	}
+
+	sigVerifier.Start()
+}
+
+// ExtCryptoFinishBatchVerifyVersion1 implements ext_crypto_finish_batch_verify_version_1
+func ExtCryptoFinishBatchVerifyVersion1(env Env) int32 {
+	logger.Debug("[ext_crypto_finish_batch_verify_version_1] executing...")
+
+	sigVerifier := env.Context().SigVerifier
+
+	if !sigVerifier.IsStarted() {
+		logger.Error("[ext_crypto_finish_batch_verify_version_1] batch verification is not started")
+		return 0
+	}
+
+	if sigVerifier.Finish() {
+		return 1
+	}
+	logger.Error("[ext_crypto_finish_batch_verify_version_1] failed to batch verify; invalid signature")
+	return 0
+}
+
+// ExtTrieBlake2256RootVersion1 implements ext_trie_blake2_256_root_version_1
+func ExtTrieBlake2256RootVersion1(env Env, dataSpan int64) int32 {
+	logger.Debug("[ext_trie_blake2_256_root_version_1] executing...")
+
+	memory := env.Memory()
+	runtimeCtx := env.Context()
+	data := asMemorySlice(env, dataSpan)
+
+	t := trie.NewEmptyTrie()
+	// TODO: this is a fix for the length until slices of structs can be decoded
+	// length passed in is the # of (key, value) tuples, but we are decoding as a slice of []byte
+	data[0] = data[0] << 1
+
+	// this function is expecting an array of (key, value) tuples
+	kvs, err := scale.Decode(data, [][]byte{})
+	if err != nil {
+		logger.Error("[ext_trie_blake2_256_root_version_1]", "error", err)
+		return 0
+	}
+
+	keyValues := kvs.([][]byte)
+	if len(keyValues)%2 != 0 { // TODO: this can be removed when we have decoding of slices of structs
+		logger.Warn("[ext_trie_blake2_256_root_version_1] odd number of input key-values, skipping last value")
+		keyValues = keyValues[:len(keyValues)-1]
+	}
+
+	for i := 0; i < len(keyValues); i = i + 2 {
+		t.Put(keyValues[i], keyValues[i+1])
+	}
+
+	// allocate memory for value and copy value to memory
+	ptr, err := runtimeCtx.Allocator.Allocate(32)
+	if err != nil {
+		logger.Error("[ext_trie_blake2_256_root_version_1]", "error", err)
+		return 0
+	}
+
+	hash, err := t.Hash()
+	if err != nil {
+		logger.Error("[ext_trie_blake2_256_root_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_trie_blake2_256_root_version_1]", "root", hash)
+	copy(memory[ptr:ptr+32], hash[:])
+	return int32(ptr)
+}
+
+// ExtTrieBlake2256OrderedRootVersion1 implements ext_trie_blake2_256_ordered_root_version_1
+func ExtTrieBlake2256OrderedRootVersion1(env Env, dataSpan int64) int32 {
+	logger.Debug("[ext_trie_blake2_256_ordered_root_version_1] executing...")
+
+	memory := env.Memory()
+	runtimeCtx := env.Context()
+	data := asMemorySlice(env, dataSpan)
+
+	t := trie.NewEmptyTrie()
+	v, err := scale.Decode(data, [][]byte{})
+	if err != nil {
+		logger.Error("[ext_trie_blake2_256_ordered_root_version_1]", "error", err)
+		return 0
+	}
+
+	values := v.([][]byte)
+
+	for i, val := range values {
+		key, err := scale.Encode(big.NewInt(int64(i))) //nolint
+		if err != nil {
+			logger.Error("[ext_blake2_256_enumerated_trie_root]", "error", err)
+			return 0
+		}
+		logger.Trace("[ext_trie_blake2_256_ordered_root_version_1]", "key", key, "value", val)
+
+		t.Put(key, val)
+	}
+
+	// allocate memory for value and copy value to memory
+	ptr, err := runtimeCtx.Allocator.Allocate(32)
+	if err != nil {
+		logger.Error("[ext_trie_blake2_256_ordered_root_version_1]", "error", err)
+		return 0
+	}
+
+	hash, err := t.Hash()
+	if err != nil {
+		logger.Error("[ext_trie_blake2_256_ordered_root_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_trie_blake2_256_ordered_root_version_1]", "root", hash)
+	copy(memory[ptr:ptr+32], hash[:])
+	return int32(ptr)
+}
+
+// ExtMiscPrintHexVersion1 implements ext_misc_print_hex_version_1
+func ExtMiscPrintHexVersion1(env Env, dataSpan int64) {
+	logger.Trace("[ext_misc_print_hex_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+	logger.Debug("[ext_misc_print_hex_version_1]", "hex", fmt.Sprintf("0x%x", data))
+}
+
+// ExtMiscPrintNumVersion1 implements ext_misc_print_num_version_1
+func ExtMiscPrintNumVersion1(env Env, data int64) {
+	logger.Trace("[ext_misc_print_num_version_1] executing...")
+
+	logger.Debug("[ext_misc_print_num_version_1]", "num", fmt.Sprintf("%d", data))
+}
+
+// ExtMiscPrintUtf8Version1 implements ext_misc_print_utf8_version_1
+func ExtMiscPrintUtf8Version1(env Env, dataSpan int64) {
+	logger.Trace("[ext_misc_print_utf8_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+	logger.Debug("[ext_misc_print_utf8_version_1]", "utf8", string(data))
+}
+
+// ExtMiscRuntimeVersionVersion1 implements ext_misc_runtime_version_version_1
+func ExtMiscRuntimeVersionVersion1(env Env, dataSpan int64) int64 {
+	logger.Trace("[ext_misc_runtime_version_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+
+	version, err := env.RuntimeVersion(data)
+	if err != nil {
+		logger.Error("[ext_misc_runtime_version_version_1] failed to get runtime version", "error", err)
+		out, _ := toWasmMemoryOptional(env, nil)
+		return out
+	}
+
+	logger.Debug("[ext_misc_runtime_version_version_1]", "version", version)
+
+	encodedData, err := version.Encode()
+	if err != nil {
+		logger.Error("[ext_misc_runtime_version_version_1] failed to encode result", "error", err)
+		return 0
+	}
+
+	out, err := toWasmMemoryOptional(env, encodedData)
+	if err != nil {
+		logger.Error("[ext_misc_runtime_version_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return out
+}
+
+// ExtDefaultChildStorageReadVersion1 implements ext_default_child_storage_read_version_1
+func ExtDefaultChildStorageReadVersion1(env Env, childStorageKey int64, key int64, valueOut int64, offset int32) int64 {
+	logger.Debug("[ext_default_child_storage_read_version_1] executing...")
+
+	storage := env.Context().Storage
+	memory := env.Memory()
+
+	value, err := storage.GetChildStorage(asMemorySlice(env, childStorageKey), asMemorySlice(env, key))
+	if err != nil {
+		logger.Error("[ext_default_child_storage_read_version_1] failed to get child storage", "error", err)
+		return 0
+	}
+
+	valueBuf, valueLen := int64ToPointerAndSize(valueOut)
+	copy(memory[valueBuf:valueBuf+valueLen], value[offset:])
+
+	size := uint32(len(value[offset:]))
+	sizeBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sizeBuf, size)
+
+	sizeSpan, err := toWasmMemoryOptional(env, sizeBuf)
+	if err != nil {
+		logger.Error("[ext_default_child_storage_read_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return sizeSpan
+}
+
+// ExtDefaultChildStorageClearVersion1 implements ext_default_child_storage_clear_version_1
+func ExtDefaultChildStorageClearVersion1(env Env, childStorageKey, keySpan int64) {
+	logger.Debug("[ext_default_child_storage_clear_version_1] executing...")
+
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	keyToChild := asMemorySlice(env, childStorageKey)
+	key := asMemorySlice(env, keySpan)
+
+	err := storage.ClearChildStorage(keyToChild, key)
+	if err != nil {
+		logger.Error("[ext_default_child_storage_clear_version_1] failed to clear child storage", "error", err)
+	}
+}
+
+// ExtDefaultChildStorageClearPrefixVersion1 implements ext_default_child_storage_clear_prefix_version_1
+func ExtDefaultChildStorageClearPrefixVersion1(env Env, childStorageKey int64, prefixSpan int64) {
+	logger.Debug("[ext_default_child_storage_clear_prefix_version_1] executing...")
+
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	keyToChild := asMemorySlice(env, childStorageKey)
+	prefix := asMemorySlice(env, prefixSpan)
+
+	err := storage.ClearPrefixInChild(keyToChild, prefix)
+	if err != nil {
+		logger.Error("[ext_default_child_storage_clear_prefix_version_1] failed to clear prefix in child", "error", err)
+	}
+}
+
+// ExtDefaultChildStorageExistsVersion1 implements ext_default_child_storage_exists_version_1
+func ExtDefaultChildStorageExistsVersion1(env Env, childStorageKey int64, key int64) int32 {
+	logger.Debug("[ext_default_child_storage_exists_version_1] executing...")
+
+	storage := env.Context().Storage
+
+	child, err := storage.GetChildStorage(asMemorySlice(env, childStorageKey), asMemorySlice(env, key))
+	if err != nil {
+		logger.Error("[ext_default_child_storage_exists_version_1] failed to get child from child storage", "error", err)
+		return 0
+	}
+	if child != nil {
+		return 1
+	}
+	return 0
+}
+
+// ExtDefaultChildStorageGetVersion1 implements ext_default_child_storage_get_version_1
+func ExtDefaultChildStorageGetVersion1(env Env, childStorageKey, key int64) int64 {
+	logger.Debug("[ext_default_child_storage_get_version_1] executing...")
+
+	storage := env.Context().Storage
+
+	child, err := storage.GetChildStorage(asMemorySlice(env, childStorageKey), asMemorySlice(env, key))
+	if err != nil {
+		logger.Error("[ext_default_child_storage_get_version_1] failed to get child from child storage", "error", err)
+		return 0
+	}
+
+	value, err := toWasmMemoryOptional(env, child)
+	if err != nil {
+		logger.Error("[ext_default_child_storage_get_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return value
+}
+
+// ExtDefaultChildStorageNextKeyVersion1 implements ext_default_child_storage_next_key_version_1
+func ExtDefaultChildStorageNextKeyVersion1(env Env, childStorageKey int64, key int64) int64 {
+	logger.Debug("[ext_default_child_storage_next_key_version_1] executing...")
+
+	storage := env.Context().Storage
+
+	child, err := storage.GetChildNextKey(asMemorySlice(env, childStorageKey), asMemorySlice(env, key))
+	if err != nil {
+		logger.Error("[ext_default_child_storage_next_key_version_1] failed to get child's next key", "error", err)
+		return 0
+	}
+
+	value, err := toWasmMemoryOptional(env, child)
+	if err != nil {
+		logger.Error("[ext_default_child_storage_next_key_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return value
+}
+
+// ExtDefaultChildStorageRootVersion1 implements ext_default_child_storage_root_version_1
+func ExtDefaultChildStorageRootVersion1(env Env, childStorageKey int64) int64 {
+	logger.Debug("[ext_default_child_storage_root_version_1] executing...")
+
+	storage := env.Context().Storage
+
+	child, err := storage.GetChild(asMemorySlice(env, childStorageKey))
+	if err != nil {
+		logger.Error("[ext_default_child_storage_root_version_1] failed to retrieve child", "error", err)
+		return 0
+	}
+
+	childRoot, err := child.Hash()
+	if err != nil {
+		logger.Error("[ext_default_child_storage_root_version_1] failed to encode child root", "error", err)
+		return 0
+	}
+
+	root, err := toWasmMemoryOptional(env, childRoot[:])
+	if err != nil {
+		logger.Error("[ext_default_child_storage_root_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return root
+}
+
+// ExtDefaultChildStorageSetVersion1 implements ext_default_child_storage_set_version_1
+func ExtDefaultChildStorageSetVersion1(env Env, childStorageKeySpan, keySpan, valueSpan int64) {
+	logger.Debug("[ext_default_child_storage_set_version_1] executing...")
+
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	childStorageKey := asMemorySlice(env, childStorageKeySpan)
+	key := asMemorySlice(env, keySpan)
+	value := asMemorySlice(env, valueSpan)
+
+	cp := make([]byte, len(value))
+	copy(cp, value)
+
+	err := storage.SetChildStorage(childStorageKey, key, cp)
+	if err != nil {
+		logger.Error("[ext_default_child_storage_set_version_1] failed to set value in child storage", "error", err)
+		return
+	}
+}
+
+// ExtDefaultChildStorageStorageKillVersion1 implements ext_default_child_storage_storage_kill_version_1
+func ExtDefaultChildStorageStorageKillVersion1(env Env, childStorageKeySpan int64) {
+	logger.Debug("[ext_default_child_storage_storage_kill_version_1] executing...")
+
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	childStorageKey := asMemorySlice(env, childStorageKeySpan)
+	storage.DeleteChild(childStorageKey)
+}
+
+// ExtAllocatorFreeVersion1 implements ext_allocator_free_version_1
+func ExtAllocatorFreeVersion1(env Env, addr int32) {
+	logger.Trace("[ext_allocator_free_version_1] executing...")
+	runtimeCtx := env.Context()
+
+	// Deallocate memory
+	err := runtimeCtx.Allocator.Deallocate(uint32(addr))
+	if err != nil {
+		logger.Error("[ext_allocator_free_version_1] failed to free memory", "error", err)
+	}
+}
+
+// ExtAllocatorMallocVersion1 implements ext_allocator_malloc_version_1
+func ExtAllocatorMallocVersion1(env Env, size int32) int32 {
+	logger.Trace("[ext_allocator_malloc_version_1] executing...", "size", size)
+
+	ctx := env.Context()
+
+	// Allocate memory
+	res, err := ctx.Allocator.Allocate(uint32(size))
+	if err != nil {
+		logger.Crit("[ext_allocator_malloc_version_1] failed to allocate memory", "error", err)
+		panic(err)
+	}
+
+	return int32(res)
+}
+
+// ExtHashingBlake2128Version1 implements ext_hashing_blake2_128_version_1
+func ExtHashingBlake2128Version1(env Env, dataSpan int64) int32 {
+	logger.Trace("[ext_hashing_blake2_128_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+
+	hash, err := common.Blake2b128(data)
+	if err != nil {
+		logger.Error("[ext_hashing_blake2_128_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_hashing_blake2_128_version_1]", "data", fmt.Sprintf("0x%x", data), "hash", fmt.Sprintf("0x%x", hash))
+
+	out, err := toWasmMemorySized(env, hash, 16)
+	if err != nil {
+		logger.Error("[ext_hashing_blake2_128_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return int32(out)
+}
+
+// ExtHashingBlake2256Version1 implements ext_hashing_blake2_256_version_1
+func ExtHashingBlake2256Version1(env Env, dataSpan int64) int32 {
+	logger.Trace("[ext_hashing_blake2_256_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+
+	hash, err := common.Blake2bHash(data)
+	if err != nil {
+		logger.Error("[ext_hashing_blake2_256_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_hashing_blake2_256_version_1]", "data", fmt.Sprintf("0x%x", data), "hash", hash)
+
+	out, err := toWasmMemorySized(env, hash[:], 32)
+	if err != nil {
+		logger.Error("[ext_hashing_blake2_256_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return int32(out)
+}
+
+// ExtHashingKeccak256Version1 implements ext_hashing_keccak_256_version_1
+func ExtHashingKeccak256Version1(env Env, dataSpan int64) int32 {
+	logger.Trace("[ext_hashing_keccak_256_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+
+	hash, err := common.Keccak256(data)
+	if err != nil {
+		logger.Error("[ext_hashing_keccak_256_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_hashing_keccak_256_version_1]", "data", fmt.Sprintf("0x%x", data), "hash", hash)
+
+	out, err := toWasmMemorySized(env, hash[:], 32)
+	if err != nil {
+		logger.Error("[ext_hashing_keccak_256_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return int32(out)
+}
+
+// ExtHashingSha2256Version1 implements ext_hashing_sha2_256_version_1
+func ExtHashingSha2256Version1(env Env, dataSpan int64) int32 {
+	logger.Trace("[ext_hashing_sha2_256_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+	hash := common.Sha256(data)
+
+	logger.Debug("[ext_hashing_sha2_256_version_1]", "data", data, "hash", hash)
+
+	out, err := toWasmMemorySized(env, hash[:], 32)
+	if err != nil {
+		logger.Error("[ext_hashing_sha2_256_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return int32(out)
+}
+
+// ExtHashingTwox256Version1 implements ext_hashing_twox_256_version_1
+func ExtHashingTwox256Version1(env Env, dataSpan int64) int32 {
+	logger.Trace("[ext_hashing_twox_256_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+
+	hash, err := common.Twox256(data)
+	if err != nil {
+		logger.Error("[ext_hashing_twox_256_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_hashing_twox_256_version_1]", "data", data, "hash", hash)
+
+	out, err := toWasmMemorySized(env, hash[:], 32)
+	if err != nil {
+		logger.Error("[ext_hashing_twox_256_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return int32(out)
+}
+
+// ExtHashingTwox128Version1 implements ext_hashing_twox_128_version_1
+func ExtHashingTwox128Version1(env Env, dataSpan int64) int32 {
+	logger.Trace("[ext_hashing_twox_128_version_1] executing...")
+	data := asMemorySlice(env, dataSpan)
+
+	hash, err := common.Twox128Hash(data)
+	if err != nil {
+		logger.Error("[ext_hashing_twox_128_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_hashing_twox_128_version_1]", "data", string(data), "hash", fmt.Sprintf("0x%x", hash))
+
+	out, err := toWasmMemorySized(env, hash, 16)
+	if err != nil {
+		logger.Error("[ext_hashing_twox_128_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return int32(out)
+}
+
+// ExtHashingTwox64Version1 implements ext_hashing_twox_64_version_1
+func ExtHashingTwox64Version1(env Env, dataSpan int64) int32 {
+	logger.Trace("[ext_hashing_twox_64_version_1] executing...")
+
+	data := asMemorySlice(env, dataSpan)
+
+	hash, err := common.Twox64(data)
+	if err != nil {
+		logger.Error("[ext_hashing_twox_64_version_1]", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_hashing_twox_64_version_1]", "data", fmt.Sprintf("0x%x", data), "hash", fmt.Sprintf("0x%x", hash))
+
+	out, err := toWasmMemorySized(env, hash, 8)
+	if err != nil {
+		logger.Error("[ext_hashing_twox_64_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return int32(out)
+}
+
+// ExtOffchainIndexSetVersion1 implements ext_offchain_index_set_version_1
+func ExtOffchainIndexSetVersion1(env Env, keySpan, valueSpan int64) {
+	logger.Trace("[ext_offchain_index_set_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	if !runtimeCtx.OffchainIndexing {
+		return
+	}
+
+	// the write is buffered in the storage and committed to the offchain database once the block is imported
+	key := asMemorySlice(env, keySpan)
+	value := asMemorySlice(env, valueSpan)
+	runtimeCtx.Storage.SetOffchainIndex(key, value)
+}
+
+// ExtOffchainIsValidatorVersion1 implements ext_offchain_is_validator_version_1
+func ExtOffchainIsValidatorVersion1(env Env) int32 {
+	logger.Debug("[ext_offchain_is_validator_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	if runtimeCtx.Validator {
+		return 1
+	}
+	return 0
+}
+
+// ExtOffchainLocalStorageCompareAndSetVersion1 implements ext_offchain_local_storage_compare_and_set_version_1
+func ExtOffchainLocalStorageCompareAndSetVersion1(env Env, kind int32, key, oldValue, newValue int64) int32 {
+	logger.Debug("[ext_offchain_local_storage_compare_and_set_version_1] executing...")
+
+	runtimeCtx := env.Context()
+
+	storageKey := asMemorySlice(env, key)
+
+	var storedValue []byte
+	var err error
+
+	switch runtime.NodeStorageType(kind) {
+	case runtime.NodeStorageTypePersistent:
+		storedValue, err = runtimeCtx.NodeStorage.PersistentStorage.Get(storageKey)
+	case runtime.NodeStorageTypeLocal:
+		storedValue, err = runtimeCtx.NodeStorage.LocalStorage.Get(storageKey)
+	}
+
+	if err != nil {
+		logger.Error("[ext_offchain_local_storage_compare_and_set_version_1] failed to get value from storage", "error", err)
+		return 0
+	}
+
+	oldVal := asMemorySlice(env, oldValue)
+	newVal := asMemorySlice(env, newValue)
+	if reflect.DeepEqual(storedValue, oldVal) {
+		cp := make([]byte, len(newVal))
+		copy(cp, newVal)
+		err = runtimeCtx.NodeStorage.LocalStorage.Put(storageKey, cp)
+		if err != nil {
+			logger.Error("[ext_offchain_local_storage_compare_and_set_version_1] failed to set value in storage", "error", err)
+			return 0
+		}
+	}
+
+	return 1
+}
+
+// ExtOffchainLocalStorageGetVersion1 implements ext_offchain_local_storage_get_version_1
+func ExtOffchainLocalStorageGetVersion1(env Env, kind int32, key int64) int64 {
+	logger.Debug("[ext_offchain_local_storage_get_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	storageKey := asMemorySlice(env, key)
+
+	var res []byte
+	var err error
+
+	switch runtime.NodeStorageType(kind) {
+	case runtime.NodeStorageTypePersistent:
+		res, err = runtimeCtx.NodeStorage.PersistentStorage.Get(storageKey)
+	case runtime.NodeStorageTypeLocal:
+		res, err = runtimeCtx.NodeStorage.LocalStorage.Get(storageKey)
+	}
+
+	if err != nil {
+		logger.Error("[ext_offchain_local_storage_get_version_1] failed to get value from storage", "error", err)
+	}
+	// allocate memory for value and copy value to memory
+	ptr, err := toWasmMemoryOptional(env, res)
+	if err != nil {
+		logger.Error("[ext_offchain_local_storage_get_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+	return ptr
+}
+
+// ExtOffchainLocalStorageSetVersion1 implements ext_offchain_local_storage_set_version_1
+func ExtOffchainLocalStorageSetVersion1(env Env, kind int32, key, value int64) {
+	logger.Debug("[ext_offchain_local_storage_set_version_1] executing...")
+
+	runtimeCtx := env.Context()
+	storageKey := asMemorySlice(env, key)
+	newValue := asMemorySlice(env, value)
+	cp := make([]byte, len(newValue))
+	copy(cp, newValue)
+
+	var err error
+	switch runtime.NodeStorageType(kind) {
+	case runtime.NodeStorageTypePersistent:
+		err = runtimeCtx.NodeStorage.PersistentStorage.Put(storageKey, cp)
+	case runtime.NodeStorageTypeLocal:
+		err = runtimeCtx.NodeStorage.LocalStorage.Put(storageKey, cp)
+	}
+
+	if err != nil {
+		logger.Error("[ext_offchain_local_storage_set_version_1] failed to set value in storage", "error", err)
+	}
+}
+
+// ExtOffchainNetworkStateVersion1 implements ext_offchain_network_state_version_1
+func ExtOffchainNetworkStateVersion1(env Env) int64 {
+	logger.Debug("[ext_offchain_network_state_version_1] executing...")
+	runtimeCtx := env.Context()
+	if runtimeCtx.Network == nil {
+		return 0
+	}
+
+	nsEnc, err := scale.Encode(runtimeCtx.Network.NetworkState())
+	if err != nil {
+		logger.Error("[ext_offchain_network_state_version_1] failed at encoding network state", "error", err)
+		return 0
+	}
+
+	// copy network state length to memory writtenOut location
+	nsEncLen := uint32(len(nsEnc))
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, nsEncLen)
+
+	// allocate memory for value and copy value to memory
+	ptr, err := toWasmMemorySized(env, nsEnc, nsEncLen)
+	if err != nil {
+		logger.Error("[ext_offchain_network_state_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+
+	return int64(ptr)
+}
+
+// ExtOffchainRandomSeedVersion1 implements ext_offchain_random_seed_version_1
+func ExtOffchainRandomSeedVersion1(env Env) int32 {
+	logger.Debug("[ext_offchain_random_seed_version_1] executing...")
+
+	seed := make([]byte, 32)
+	_, err := rand.Read(seed)
+	if err != nil {
+		logger.Error("[ext_offchain_random_seed_version_1] failed to generate random seed", "error", err)
+	}
+	ptr, err := toWasmMemorySized(env, seed, 32)
+	if err != nil {
+		logger.Error("[ext_offchain_random_seed_version_1] failed to allocate memory", "error", err)
+	}
+	return int32(ptr)
+}
+
+// ExtOffchainSubmitTransactionVersion1 implements ext_offchain_submit_transaction_version_1
+func ExtOffchainSubmitTransactionVersion1(env Env, data int64) int64 {
+	logger.Debug("[ext_offchain_submit_transaction_version_1] executing...")
+
+	extBytes := asMemorySlice(env, data)
+
+	var decExt interface{}
+	decExt, err := scale.Decode(extBytes, decExt)
+	if err != nil {
+		logger.Error("[ext_offchain_submit_transaction_version_1] failed to decode extrinsic data", "error", err)
+	}
+
+	extrinsic := types.Extrinsic(decExt.([]byte))
+
+	// validate the transaction
+	txv := transaction.NewValidity(0, [][]byte{{}}, [][]byte{{}}, 0, false)
+	vtx := transaction.NewValidTransaction(extrinsic, txv)
+
+	runtimeCtx := env.Context()
+	runtimeCtx.Transaction.AddToPool(vtx)
+
+	ptr, err := toWasmMemoryOptional(env, nil)
+	if err != nil {
+		logger.Error("[ext_offchain_submit_transaction_version_1] failed to allocate memory", "error", err)
+	}
+	return ptr
+}
+
+// ExtOffchainTimestampVersion1 implements ext_offchain_timestamp_version_1
+func ExtOffchainTimestampVersion1(env Env) int64 {
+	logger.Trace("[ext_offchain_timestamp_version_1] executing...")
+	return int64(offchain.Timestamp())
+}
+
+// ExtOffchainSleepUntilVersion1 implements ext_offchain_sleep_until_version_1
+func ExtOffchainSleepUntilVersion1(env Env, deadline int64) {
+	logger.Trace("[ext_offchain_sleep_until_version_1] executing...")
+
+	ms := deadline
+	time.Sleep(time.Until(time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))))
+}
+
+// ExtOffchainHttpRequestStartVersion1 implements ext_offchain_http_request_start_version_1
+func ExtOffchainHttpRequestStartVersion1(env Env, method, uri, meta int64) int64 {
+	logger.Debug("[ext_offchain_http_request_start_version_1] executing...")
+
+	runtimeCtx := env.Context()
+
+	// the request metadata is unused and reserved for future use
+	res := []byte{1}
+	id, err := runtimeCtx.OffchainHTTP.StartRequest(string(asMemorySlice(env, method)), string(asMemorySlice(env, uri)))
+	if err != nil {
+		logger.Error("[ext_offchain_http_request_start_version_1] failed to start request", "error", err)
+	} else {
+		res = []byte{0, 0, 0}
+		binary.LittleEndian.PutUint16(res[1:], id)
+	}
+
+	ptr, err := toWasmMemory(env, res)
+	if err != nil {
+		logger.Error("[ext_offchain_http_request_start_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+	return ptr
+}
+
+// ExtOffchainHttpRequestAddHeaderVersion1 implements ext_offchain_http_request_add_header_version_1
+func ExtOffchainHttpRequestAddHeaderVersion1(env Env, id int32, name, value int64) int64 {
+	logger.Debug("[ext_offchain_http_request_add_header_version_1] executing...")
+
+	runtimeCtx := env.Context()
+
+	res := []byte{0}
+	err := runtimeCtx.OffchainHTTP.AddHeader(uint16(id), string(asMemorySlice(env, name)), string(asMemorySlice(env, value)))
+	if err != nil {
+		logger.Error("[ext_offchain_http_request_add_header_version_1] failed to add header", "error", err)
+		res = []byte{1}
+	}
+
+	ptr, err := toWasmMemory(env, res)
+	if err != nil {
+		logger.Error("[ext_offchain_http_request_add_header_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+	return ptr
+}
+
+// ExtOffchainHttpRequestWriteBodyVersion1 implements ext_offchain_http_request_write_body_version_1
+func ExtOffchainHttpRequestWriteBodyVersion1(env Env, id int32, chunk, deadline int64) int64 {
+	logger.Debug("[ext_offchain_http_request_write_body_version_1] executing...")
+
+	runtimeCtx := env.Context()
+
+	res := []byte{0}
+	d, err := offchain.DecodeDeadline(asMemorySlice(env, deadline))
+	if err == nil {
+		// the chunk is copied, as it's written asynchronously and the memory may be reallocated
+		data := asMemorySlice(env, chunk)
+		err = runtimeCtx.OffchainHTTP.WriteBody(uint16(id), append([]byte{}, data...), d)
+	}
+
+	if err != nil {
+		logger.Debug("[ext_offchain_http_request_write_body_version_1] failed to write body", "error", err)
+		res = []byte{1, byte(toHTTPError(err))}
+	}
+
+	ptr, err := toWasmMemory(env, res)
+	if err != nil {
+		logger.Error("[ext_offchain_http_request_write_body_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+	return ptr
+}
+
+// ExtOffchainHttpResponseWaitVersion1 implements ext_offchain_http_response_wait_version_1
+func ExtOffchainHttpResponseWaitVersion1(env Env, ids, deadline int64) int64 {
+	logger.Debug("[ext_offchain_http_response_wait_version_1] executing...")
+
+	runtimeCtx := env.Context()
+
+	reqIDs, err := decodeHTTPRequestIDs(asMemorySlice(env, ids))
+	if err != nil {
+		logger.Error("[ext_offchain_http_response_wait_version_1] failed to decode request ids", "error", err)
+		return 0
+	}
+
+	d, err := offchain.DecodeDeadline(asMemorySlice(env, deadline))
+	if err != nil {
+		logger.Error("[ext_offchain_http_response_wait_version_1] failed to decode deadline", "error", err)
+		return 0
+	}
+
+	enc, err := offchain.EncodeHTTPRequestStatuses(runtimeCtx.OffchainHTTP.Wait(reqIDs, d))
+	if err != nil {
+		logger.Error("[ext_offchain_http_response_wait_version_1] failed to encode statuses", "error", err)
+		return 0
+	}
+
+	ptr, err := toWasmMemory(env, enc)
+	if err != nil {
+		logger.Error("[ext_offchain_http_response_wait_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+	return ptr
+}
+
+// ExtOffchainHttpResponseHeadersVersion1 implements ext_offchain_http_response_headers_version_1
+func ExtOffchainHttpResponseHeadersVersion1(env Env, id int32) int64 {
+	logger.Debug("[ext_offchain_http_response_headers_version_1] executing...")
+
+	runtimeCtx := env.Context()
+
+	enc, err := offchain.EncodeHTTPHeaders(runtimeCtx.OffchainHTTP.ResponseHeaders(uint16(id)))
+	if err != nil {
+		logger.Error("[ext_offchain_http_response_headers_version_1] failed to encode headers", "error", err)
+		return 0
+	}
+
+	ptr, err := toWasmMemory(env, enc)
+	if err != nil {
+		logger.Error("[ext_offchain_http_response_headers_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+	return ptr
+}
+
+// ExtOffchainHttpResponseReadBodyVersion1 implements ext_offchain_http_response_read_body_version_1
+func ExtOffchainHttpResponseReadBodyVersion1(env Env, id int32, buffer, deadline int64) int64 {
+	logger.Debug("[ext_offchain_http_response_read_body_version_1] executing...")
+
+	runtimeCtx := env.Context()
+
+	var n int
+	d, err := offchain.DecodeDeadline(asMemorySlice(env, deadline))
+	if err == nil {
+		buf := make([]byte, len(asMemorySlice(env, buffer)))
+		n, err = runtimeCtx.OffchainHTTP.ReadBody(uint16(id), buf, d)
+		copy(asMemorySlice(env, buffer), buf[:n])
+	}
+
+	var res []byte
+	if err != nil {
+		logger.Debug("[ext_offchain_http_response_read_body_version_1] failed to read body", "error", err)
+		res = []byte{1, byte(toHTTPError(err))}
+	} else {
+		res = make([]byte, 5)
+		binary.LittleEndian.PutUint32(res[1:], uint32(n))
+	}
+
+	ptr, err := toWasmMemory(env, res)
+	if err != nil {
+		logger.Error("[ext_offchain_http_response_read_body_version_1] failed to allocate memory", "error", err)
+		return 0
+	}
+	return ptr
+}
+
+// toHTTPError converts an error returned by the offchain HTTP functions to the HttpError passed to the runtime
+func toHTTPError(err error) offchain.HTTPError {
+	var httpErr offchain.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	return offchain.ErrInvalidRequest
+}
+
+// decodeHTTPRequestIDs decodes a SCALE encoded Vec<HttpRequestId>
+func decodeHTTPRequestIDs(in []byte) ([]uint16, error) {
+	buf := bytes.NewBuffer(in)
+	sd := scale.Decoder{Reader: buf}
+
+	l, err := sd.DecodeUnsignedInteger()
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(buf.Len()) != 2*l {
+		return nil, errors.New("invalid request ids length")
+	}
+
+	ids := make([]uint16, l)
+	for i := range ids {
+		ids[i] = binary.LittleEndian.Uint16(buf.Next(2))
+	}
+	return ids, nil
+}
+
+func storageAppend(storage runtime.Storage, key, valueToAppend []byte) error {
+	nextLength := big.NewInt(1)
+	var valueRes []byte
+
+	// this function assumes the item in storage is a SCALE encoded array of items
+	// the valueToAppend is a new item, so it appends the item and increases the length prefix by 1
+	valueCurr := storage.Get(key)
+	if len(valueCurr) == 0 {
+		valueRes = valueToAppend
+	} else {
+		// remove length prefix from existing value
+		r := &bytes.Buffer{}
+		_, _ = r.Write(valueCurr)
+		dec := &scale.Decoder{Reader: r}
+		currLength, err := dec.DecodeBigInt() //nolint
+		if err != nil {
+			logger.Trace("[ext_storage_append_version_1] item in storage is not SCALE encoded, overwriting", "key", key)
+			storage.Set(key, append([]byte{4}, valueToAppend...))
+			return nil
+		}
+
+		// append new item
+		valueRes = append(r.Bytes(), valueToAppend...)
+
+		// increase length by 1
+		nextLength = big.NewInt(0).Add(currLength, big.NewInt(1))
+	}
+
+	lengthEnc, err := scale.Encode(nextLength)
+	if err != nil {
+		logger.Trace("[ext_storage_append_version_1] failed to encode new length", "error", err)
+		return err
+	}
+
+	// append new length prefix to start of items array
+	finalVal := append(lengthEnc, valueRes...)
+	logger.Debug("[ext_storage_append_version_1]", "resulting value", fmt.Sprintf("0x%x", finalVal))
+	storage.Set(key, finalVal)
+	return nil
+}
+
+// ExtStorageAppendVersion1 implements ext_storage_append_version_1
+func ExtStorageAppendVersion1(env Env, keySpan, valueSpan int64) {
+	logger.Trace("[ext_storage_append_version_1] executing...")
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	key := asMemorySlice(env, keySpan)
+	valueAppend := asMemorySlice(env, valueSpan)
+	logger.Debug("[ext_storage_append_version_1]", "key", fmt.Sprintf("0x%x", key), "value to append", fmt.Sprintf("0x%x", valueAppend))
+
+	cp := make([]byte, len(valueAppend))
+	copy(cp, valueAppend)
+
+	err := storageAppend(storage, key, cp)
+	if err != nil {
+		logger.Error("[ext_storage_append_version_1]", "error", err)
+	}
+}
+
+// ExtStorageChangesRootVersion1 implements ext_storage_changes_root_version_1
+func ExtStorageChangesRootVersion1(env Env, parentHashSpan int64) int64 {
+	logger.Trace("[ext_storage_changes_root_version_1] executing...")
+	logger.Debug("[ext_storage_changes_root_version_1] returning None")
+
+	rootSpan, err := toWasmMemoryOptional(env, nil)
+	if err != nil {
+		logger.Error("[ext_storage_changes_root_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return rootSpan
+}
+
+// ExtStorageClearVersion1 implements ext_storage_clear_version_1
+func ExtStorageClearVersion1(env Env, keySpan int64) {
+	logger.Trace("[ext_storage_clear_version_1] executing...")
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	key := asMemorySlice(env, keySpan)
+
+	logger.Debug("[ext_storage_clear_version_1]", "key", fmt.Sprintf("0x%x", key))
+	storage.Delete(key)
+}
+
+// ExtStorageClearPrefixVersion1 implements ext_storage_clear_prefix_version_1
+func ExtStorageClearPrefixVersion1(env Env, prefixSpan int64) {
+	logger.Trace("[ext_storage_clear_prefix_version_1] executing...")
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	prefix := asMemorySlice(env, prefixSpan)
+	logger.Debug("[ext_storage_clear_prefix_version_1]", "prefix", fmt.Sprintf("0x%x", prefix))
+
+	err := storage.ClearPrefix(prefix)
+	if err != nil {
+		logger.Error("[ext_storage_clear_prefix_version_1]", "error", err)
+	}
+}
+
+// ExtStorageExistsVersion1 implements ext_storage_exists_version_1
+func ExtStorageExistsVersion1(env Env, keySpan int64) int32 {
+	logger.Trace("[ext_storage_exists_version_1] executing...")
+	storage := env.Context().Storage
+
+	key := asMemorySlice(env, keySpan)
+	logger.Debug("[ext_storage_exists_version_1]", "key", fmt.Sprintf("0x%x", key))
+
+	val := storage.Get(key)
+	if len(val) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// ExtStorageGetVersion1 implements ext_storage_get_version_1
+func ExtStorageGetVersion1(env Env, keySpan int64) int64 {
+	logger.Trace("[ext_storage_get_version_1] executing...")
+
+	storage := env.Context().Storage
+
+	key := asMemorySlice(env, keySpan)
+	logger.Debug("[ext_storage_get_version_1]", "key", fmt.Sprintf("0x%x", key))
+
+	value := storage.Get(key)
+	logger.Debug("[ext_storage_get_version_1]", "value", fmt.Sprintf("0x%x", value))
+
+	valueSpan, err := toWasmMemoryOptional(env, value)
+	if err != nil {
+		logger.Error("[ext_storage_get_version_1] failed to allocate", "error", err)
+		ptr, _ := toWasmMemoryOptional(env, nil)
+		return ptr
+	}
+
+	return valueSpan
+}
+
+// ExtStorageNextKeyVersion1 implements ext_storage_next_key_version_1
+func ExtStorageNextKeyVersion1(env Env, keySpan int64) int64 {
+	logger.Trace("[ext_storage_next_key_version_1] executing...")
+
+	storage := env.Context().Storage
+
+	key := asMemorySlice(env, keySpan)
+
+	next := storage.NextKey(key)
+	logger.Debug("[ext_storage_next_key_version_1]", "key", fmt.Sprintf("0x%x", key), "next", fmt.Sprintf("0x%x", next))
+
+	nextSpan, err := toWasmMemoryOptional(env, next)
+	if err != nil {
+		logger.Error("[ext_storage_next_key_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return nextSpan
+}
+
+// ExtStorageReadVersion1 implements ext_storage_read_version_1
+func ExtStorageReadVersion1(env Env, keySpan, valueOut int64, offset int32) int64 {
+	logger.Trace("[ext_storage_read_version_1] executing...")
+
+	storage := env.Context().Storage
+	memory := env.Memory()
+
+	key := asMemorySlice(env, keySpan)
+	value := storage.Get(key)
+	logger.Debug("[ext_storage_read_version_1]", "key", fmt.Sprintf("0x%x", key), "value", fmt.Sprintf("0x%x", value))
+
+	if value == nil {
+		ret, _ := toWasmMemoryOptional(env, nil)
+		return ret
+	}
+
+	var size uint32
+
+	if int(offset) > len(value) {
+		size = uint32(0)
+	} else {
+		size = uint32(len(value[offset:]))
+		valueBuf, valueLen := int64ToPointerAndSize(valueOut)
+		copy(memory[valueBuf:valueBuf+valueLen], value[offset:])
+	}
+
+	sizeSpan, err := toWasmMemoryOptionalUint32(env, &size)
+	if err != nil {
+		logger.Error("[ext_storage_read_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return sizeSpan
+}
+
+// ExtStorageRootVersion1 implements ext_storage_root_version_1
+func ExtStorageRootVersion1(env Env) int64 {
+	logger.Trace("[ext_storage_root_version_1] executing...")
+
+	storage := env.Context().Storage
+
+	root, err := storage.Root()
+	if err != nil {
+		logger.Error("[ext_storage_root_version_1] failed to get storage root", "error", err)
+		return 0
+	}
+
+	logger.Debug("[ext_storage_root_version_1]", "root", root)
+
+	rootSpan, err := toWasmMemory(env, root[:])
+	if err != nil {
+		logger.Error("[ext_storage_root_version_1] failed to allocate", "error", err)
+		return 0
+	}
+
+	return rootSpan
+}
+
+// ExtStorageSetVersion1 implements ext_storage_set_version_1
+func ExtStorageSetVersion1(env Env, keySpan int64, valueSpan int64) {
+	logger.Trace("[ext_storage_set_version_1] executing...")
+
+	ctx := env.Context()
+	storage := ctx.Storage
+
+	key := asMemorySlice(env, keySpan)
+	value := asMemorySlice(env, valueSpan)
+
+	cp := make([]byte, len(value))
+	copy(cp, value)
+
+	logger.Debug("[ext_storage_set_version_1]", "key", fmt.Sprintf("0x%x", key), "val", fmt.Sprintf("0x%x", value))
+	storage.Set(key, cp)
+}
+
+// ExtStorageStartTransactionVersion1 implements ext_storage_start_transaction_version_1
+func ExtStorageStartTransactionVersion1(env Env) {
+	logger.Debug("[ext_storage_start_transaction_version_1] executing...")
+	env.Context().Storage.BeginStorageTransaction()
+}
+
+// ExtStorageRollbackTransactionVersion1 implements ext_storage_rollback_transaction_version_1
+func ExtStorageRollbackTransactionVersion1(env Env) {
+	logger.Debug("[ext_storage_rollback_transaction_version_1] executing...")
+	env.Context().Storage.RollbackStorageTransaction()
+}
+
+// ExtStorageCommitTransactionVersion1 implements ext_storage_commit_transaction_version_1
+func ExtStorageCommitTransactionVersion1(env Env) {
+	logger.Debug("[ext_storage_commit_transaction_version_1] executing...")
+	env.Context().Storage.CommitStorageTransaction()
+}
+
+// Convert 64bit wasm span descriptor to Go memory slice
+func asMemorySlice(env Env, span int64) []byte {
+	memory := env.Memory()
+	ptr, size := int64ToPointerAndSize(span)
+	return memory[ptr : ptr+size]
+}
+
+// Copy a byte slice to wasm memory and return the resulting 64bit span descriptor
+func toWasmMemory(env Env, data []byte) (int64, error) {
+	allocator := env.Context().Allocator
+	size := uint32(len(data))
+
+	out, err := allocator.Allocate(size)
+	if err != nil {
+		return 0, err
+	}
+
+	memory := env.Memory()
+
+	if uint32(len(memory)) < out+size {
+		panic(fmt.Sprintf("length of memory is less than expected, want %d have %d", out+size, len(memory)))
+	}
+
+	copy(memory[out:out+size], data)
+	return pointerAndSizeToInt64(int32(out), int32(size)), nil
+}
+
+// Copy a byte slice of a fixed size to wasm memory and return resulting pointer
+func toWasmMemorySized(env Env, data []byte, size uint32) (uint32, error) {
+	if int(size) != len(data) {
+		return 0, errors.New("internal byte array size missmatch")
+	}
+
+	allocator := env.Context().Allocator
+
+	out, err := allocator.Allocate(size)
+	if err != nil {
+		return 0, err
+	}
+
+	memory := env.Memory()
+	copy(memory[out:out+size], data)
+
+	return out, nil
+}
+
+// Wraps slice in optional.Bytes and copies result to wasm memory. Returns resulting 64bit span descriptor
+func toWasmMemoryOptional(env Env, data []byte) (int64, error) {
+	var opt *optional.Bytes
+	if data == nil {
+		opt = optional.NewBytes(false, nil)
+	} else {
+		opt = optional.NewBytes(true, data)
+	}
+
+	enc, err := opt.Encode()
+	if err != nil {
+		return 0, err
+	}
+
+	return toWasmMemory(env, enc)
+}
+
+// Wraps slice in Result type and copies result to wasm memory. Returns resulting 64bit span descriptor
+func toWasmMemoryResult(env Env, data []byte) (int64, error) {
+	var res *rtype.Result
+	if len(data) == 0 {
+		res = rtype.NewResult(byte(1), nil)
+	} else {
+		res = rtype.NewResult(byte(0), data)
+	}
+
+	enc, err := res.Encode()
+	if err != nil {
+		return 0, err
+	}
+
+	return toWasmMemory(env, enc)
+}
+
+// Wraps slice in optional and copies result to wasm memory. Returns resulting 64bit span descriptor
+func toWasmMemoryOptionalUint32(env Env, data *uint32) (int64, error) {
+	var opt *optional.Uint32
+	if data == nil {
+		opt = optional.NewUint32(false, 0)
+	} else {
+		opt = optional.NewUint32(true, *data)
+	}
+
+	enc := opt.Encode()
+	return toWasmMemory(env, enc)
+}
+
+// Wraps slice in optional.FixedSizeBytes and copies result to wasm memory. Returns resulting 64bit span descriptor
+func toWasmMemoryFixedSizeOptional(env Env, data []byte) (int64, error) {
+	var opt *optional.FixedSizeBytes
+	if data == nil {
+		opt = optional.NewFixedSizeBytes(false, nil)
+	} else {
+		opt = optional.NewFixedSizeBytes(true, data)
+	}
+
+	enc, err := opt.Encode()
+	if err != nil {
+		return 0, err
+	}
+
+	return toWasmMemory(env, enc)
+}
+
+// int64ToPointerAndSize converts an int64 into a int32 pointer and a int32 length
+func int64ToPointerAndSize(in int64) (ptr, length int32) {
+	return int32(in), int32(in >> 32)
+}
+
+// pointerAndSizeToInt64 converts int32 pointer and size to a int64
+func pointerAndSizeToInt64(ptr, size int32) int64 {
+	return int64(ptr) | (int64(size) << 32)
+}
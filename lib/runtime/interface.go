@@ -42,11 +42,11 @@ type Instance interface {
 	ApplyExtrinsic(data types.Extrinsic) ([]byte, error)
 	FinalizeBlock() (*types.Header, error)
 	ExecuteBlock(block *types.Block) ([]byte, error)
+	OffchainWorker(header *types.Header) error
 
 	// TODO: parameters and return values for these are undefined in the spec
 	CheckInherents()
 	RandomSeed()
-	OffchainWorker()
 	GenerateSessionKeys()
 }
 
@@ -69,6 +69,9 @@ type Storage interface {
 	BeginStorageTransaction()
 	CommitStorageTransaction()
 	RollbackStorageTransaction()
+	SetOffchainIndex(key, value []byte)
+	LoadCode() []byte
+	LoadCodeHash() (common.Hash, error)
 }
 
 // BasicNetwork interface for functions used by runtime network state function
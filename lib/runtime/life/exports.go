@@ -150,7 +150,17 @@ func (in *Instance) ExecuteBlock(block *types.Block) ([]byte, error) {
 	return in.Exec(runtime.CoreExecuteBlock, bdEnc)
 }
 
+// OffchainWorker calls runtime API function OffchainWorkerApi_offchain_worker for the given block
+func (in *Instance) OffchainWorker(header *types.Header) error {
+	encodedHeader, err := scale.Encode(header)
+	if err != nil {
+		return fmt.Errorf("cannot encode header: %w", err)
+	}
+
+	_, err = in.Exec(runtime.OffchainWorkerAPIOffchainWorker, encodedHeader)
+	return err
+}
+
 func (in *Instance) CheckInherents()      {} //nolint
 func (in *Instance) RandomSeed()          {} //nolint
-func (in *Instance) OffchainWorker()      {} //nolint
 func (in *Instance) GenerateSessionKeys() {} //nolint
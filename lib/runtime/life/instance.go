@@ -13,6 +13,7 @@
 //
 // You should have received a copy of the GNU Lesser General Public License
 // along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
 package life
 
 import (
@@ -24,6 +25,11 @@ import (
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/genesis"
 	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/hostapi"
+	"github.com/ChainSafe/gossamer/lib/runtime/offchain"
+	"github.com/ChainSafe/gossamer/lib/runtime/sandbox"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
+
 	log "github.com/ChainSafe/log15"
 	"github.com/perlin-network/life/exec"
 )
@@ -33,20 +39,23 @@ const Name = "life"
 
 // Check that runtime interfaces are satisfied
 var (
-	_      runtime.Instance = (*Instance)(nil)
-	logger                  = log.New("pkg", "runtime", "module", "perlin/life")
-	ctx    *runtime.Context
+	_ runtime.Instance   = (*Instance)(nil)
+	_ runtime.Memory     = (*Memory)(nil)
+	_ hostapi.Env        = (*Instance)(nil)
+	_ sandbox.Dispatcher = (*Instance)(nil)
+
+	logger = log.New("pkg", "runtime", "module", "perlin/life")
 )
 
 // Config represents a life configuration
 type Config struct {
 	runtime.InstanceConfig
-	Resolver exec.ImportResolver
 }
 
 // Instance represents a v0.8 runtime life instance
 type Instance struct {
 	vm      *exec.VirtualMachine
+	ctx     *runtime.Context
 	mu      sync.Mutex
 	version runtime.Version
 }
@@ -59,12 +68,15 @@ func NewRuntimeFromGenesis(g *genesis.Genesis, cfg *Config) (runtime.Instance, e
 	}
 
 	code := common.MustHexToBytes(codeStr)
-	cfg.Resolver = new(Resolver)
 	return NewInstance(code, cfg)
 }
 
 // NewInstance ...
 func NewInstance(code []byte, cfg *Config) (runtime.Instance, error) {
+	return newInstance(code, cfg)
+}
+
+func newInstance(code []byte, cfg *Config) (*Instance, error) {
 	if len(code) == 0 {
 		return nil, errors.New("code is empty")
 	}
@@ -74,42 +86,59 @@ func NewInstance(code []byte, cfg *Config) (runtime.Instance, error) {
 		h := log.StreamHandler(os.Stdout, log.TerminalFormat())
 		h = log.CallerFileHandler(h)
 		logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
+		hostapi.SetLogHandler(log.LvlFilterHandler(cfg.LogLvl, h))
 	}
 
-	vmCfg := exec.VMConfig{
-		DefaultMemoryPages: 20,
+	inst := &Instance{
+		ctx: &runtime.Context{
+			Keystore:         cfg.Keystore,
+			Storage:          cfg.Storage,
+			Validator:        cfg.Role == byte(4),
+			NodeStorage:      cfg.NodeStorage,
+			Network:          cfg.Network,
+			Transaction:      cfg.Transaction,
+			SigVerifier:      runtime.NewSignatureVerifier(),
+			OffchainHTTP:     offchain.NewHTTPSet(cfg.HTTPTransport),
+			OffchainIndexing: cfg.OffchainIndexing,
+		},
 	}
+	inst.ctx.Sandbox = sandbox.NewSandbox(inst)
 
-	instance, err := exec.NewVirtualMachine(code, vmCfg, cfg.Resolver, nil)
+	info, err := runtime.GetModuleInfo(code)
 	if err != nil {
 		return nil, err
 	}
 
-	memory := &Memory{
-		memory: instance.Memory,
+	heapPages, err := runtime.GetHeapPages(cfg.Storage)
+	if err != nil {
+		return nil, err
 	}
 
-	// TODO: use __heap_base
-	allocator := runtime.NewAllocator(memory, 0)
+	pages, err := info.MemoryPages(heapPages)
+	if err != nil {
+		return nil, err
+	}
 
-	runtimeCtx := &runtime.Context{
-		Storage:     cfg.Storage,
-		Allocator:   allocator,
-		Keystore:    cfg.Keystore,
-		Validator:   cfg.Role == byte(4),
-		NodeStorage: cfg.NodeStorage,
-		Network:     cfg.Network,
-		Transaction: cfg.Transaction,
-		SigVerifier: runtime.NewSignatureVerifier(),
+	vmCfg := exec.VMConfig{
+		DefaultMemoryPages: int(pages),
+		MaxMemoryPages:     int(info.MaxPages),
 	}
 
-	logger.Debug("creating new runtime instance", "context", runtimeCtx)
+	vm, err := exec.NewVirtualMachine(code, vmCfg, &Resolver{instance: inst}, nil)
+	if err != nil {
+		return nil, err
+	}
+	inst.vm = vm
 
-	inst := &Instance{
-		vm: instance,
+	memory := &Memory{vm: vm}
+	if err = runtime.GrowMemory(memory, pages); err != nil {
+		return nil, err
 	}
 
-	ctx = runtimeCtx
+	inst.ctx.Allocator = runtime.NewAllocator(memory, info.HeapBase)
+
+	logger.Debug("creating new runtime instance", "context", inst.ctx)
+
 	inst.version, _ = inst.Version()
 	return inst, nil
 }
@@ -117,22 +146,27 @@ func NewInstance(code []byte, cfg *Config) (runtime.Instance, error) {
 // Memory is a thin wrapper around life's memory to support
 // Gossamer runtime.Memory interface
 type Memory struct {
-	memory []byte
+	vm *exec.VirtualMachine
 }
 
 // Data returns the memory's data
 func (m *Memory) Data() []byte {
-	return m.memory
+	return m.vm.Memory
 }
 
 // Length returns the memory's length
 func (m *Memory) Length() uint32 {
-	return uint32(len(m.memory))
+	return uint32(len(m.vm.Memory))
 }
 
-// Grow ...
+// Grow grows the memory by the given number of pages
 func (m *Memory) Grow(numPages uint32) error {
-	m.memory = append(m.memory, make([]byte, runtime.PageSize*numPages)...)
+	pages := len(m.vm.Memory)/runtime.PageSize + int(numPages)
+	if m.vm.Config.MaxMemoryPages != 0 && pages > m.vm.Config.MaxMemoryPages {
+		return fmt.Errorf("cannot grow memory to %d pages, the maximum is %d", pages, m.vm.Config.MaxMemoryPages)
+	}
+
+	m.vm.Memory = append(m.vm.Memory, make([]byte, runtime.PageSize*numPages)...)
 	return nil
 }
 
@@ -143,25 +177,34 @@ func (in *Instance) UpdateRuntimeCode(_ []byte) error {
 
 // SetContextStorage sets the runtime's storage. It should be set before calls to the below functions.
 func (in *Instance) SetContextStorage(s runtime.Storage) {
-	ctx.Storage = s
+	in.ctx.Storage = s
 }
 
 // Exec calls the given function with the given data
 func (in *Instance) Exec(function string, data []byte) ([]byte, error) {
+	if in.ctx.Storage == nil {
+		return nil, runtime.ErrNilStorage
+	}
+
 	in.mu.Lock()
 	defer in.mu.Unlock()
 
-	ptr, err := ctx.Allocator.Allocate(uint32(len(data)))
+	ptr, err := in.ctx.Allocator.Allocate(uint32(len(data)))
 	if err != nil {
 		return nil, err
 	}
-	defer ctx.Allocator.Clear()
+
+	defer in.ctx.Allocator.Clear()
+	defer in.ctx.Sandbox.Reset()
+	defer in.ctx.OffchainHTTP.Reset()
+	// a batch left unfinished by a failed call must not carry over to the next call
+	defer in.ctx.SigVerifier.Reset()
 
 	copy(in.vm.Memory[ptr:ptr+uint32(len(data))], data)
 
 	fnc, ok := in.vm.GetFunctionExport(function)
 	if !ok {
-		panic("entry function not found")
+		return nil, fmt.Errorf("could not find exported function %s", function)
 	}
 
 	ret, err := in.vm.Run(fnc, int64(ptr), int64(len(data)))
@@ -174,27 +217,55 @@ func (in *Instance) Exec(function string, data []byte) ([]byte, error) {
 	return in.vm.Memory[offset : offset+length], nil
 }
 
+// Dispatch implements sandbox.Dispatcher. life can't call back into the runtime while it's executing a host
+// function, so sandboxed instances can't call the runtime's functions.
+func (in *Instance) Dispatch(thunk, funcIdx, state uint32, args []byte) ([]byte, error) {
+	return nil, errors.New("sandbox dispatch is not supported by life")
+}
+
 // Stop ...
 func (in *Instance) Stop() {}
 
 // NodeStorage to get reference to runtime node service
 func (in *Instance) NodeStorage() runtime.NodeStorage {
-	return ctx.NodeStorage
+	return in.ctx.NodeStorage
 }
 
 // NetworkService to get referernce to runtime network service
 func (in *Instance) NetworkService() runtime.BasicNetwork {
-	return ctx.Network
+	return in.ctx.Network
+}
+
+// Memory returns the instance's memory. It implements hostapi.Env.
+func (in *Instance) Memory() []byte {
+	return in.vm.Memory
+}
+
+// Context returns the instance's runtime context. It implements hostapi.Env.
+func (in *Instance) Context() *runtime.Context {
+	return in.ctx
 }
 
-// TODO: move below to lib/runtime
+// RuntimeVersion returns the version of the given runtime code. It implements hostapi.Env.
+func (in *Instance) RuntimeVersion(code []byte) (runtime.Version, error) {
+	cfg := &Config{}
+	cfg.LogLvl = -1 // don't change log level
+	cfg.Storage, _ = rtstorage.NewTrieState(nil)
+
+	instance, err := newInstance(code, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// instance version is set and cached in NewInstance
+	if instance.version == nil {
+		return nil, errors.New("failed to get runtime version")
+	}
+
+	return instance.version, nil
+}
 
 // int64ToPointerAndSize converts an int64 into a int32 pointer and a int32 length
 func int64ToPointerAndSize(in int64) (ptr, length int32) {
 	return int32(in), int32(in >> 32)
 }
-
-// pointerAndSizeToInt64 converts int32 pointer and size to a int64
-func pointerAndSizeToInt64(ptr, size int32) int64 {
-	return int64(ptr) | (int64(size) << 32)
-}
@@ -0,0 +1,148 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// HeapBaseExport is the name of the global exported by the runtime that holds the offset of its heap
+const HeapBaseExport = "__heap_base"
+
+// ModuleInfo holds the heap base and memory limits declared by a runtime's wasm module
+type ModuleInfo struct {
+	// HeapBase is the value of the module's __heap_base global, or DefaultHeapBase if the module doesn't export it
+	HeapBase uint32
+	// ImportsMemory is true if the module imports its memory instead of defining it
+	ImportsMemory bool
+	// MinPages is the initial size of the module's memory in pages
+	MinPages uint32
+	// MaxPages is the maximum size of the module's memory in pages, or 0 if the memory has no maximum
+	MaxPages uint32
+}
+
+// GetModuleInfo decodes the heap base and memory limits of the given wasm code
+func GetModuleInfo(code []byte) (*ModuleInfo, error) {
+	m, err := wasm.DecodeModule(bytes.NewReader(code))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode wasm module: %w", err)
+	}
+
+	info := &ModuleInfo{
+		HeapBase: DefaultHeapBase,
+	}
+
+	var numImportedGlobals uint32
+	if m.Import != nil {
+		for _, imp := range m.Import.Entries {
+			switch ty := imp.Type.(type) {
+			case wasm.MemoryImport:
+				info.ImportsMemory = true
+				info.setLimits(ty.Type.Limits)
+			case wasm.GlobalVarImport:
+				numImportedGlobals++
+			}
+		}
+	}
+
+	if m.Memory != nil && len(m.Memory.Entries) > 0 {
+		info.setLimits(m.Memory.Entries[0].Limits)
+	}
+
+	if m.Export == nil {
+		return info, nil
+	}
+
+	export, ok := m.Export.Entries[HeapBaseExport]
+	if !ok || export.Kind != wasm.ExternalGlobal {
+		return info, nil
+	}
+
+	if export.Index < numImportedGlobals || m.Global == nil || int(export.Index-numImportedGlobals) >= len(m.Global.Globals) {
+		return nil, fmt.Errorf("cannot find global for %s", HeapBaseExport)
+	}
+
+	val, err := m.ExecInitExpr(m.Global.Globals[export.Index-numImportedGlobals].Init)
+	if err != nil {
+		return nil, fmt.Errorf("cannot evaluate %s: %w", HeapBaseExport, err)
+	}
+
+	heapBase, ok := val.(int32)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an i32", HeapBaseExport)
+	}
+
+	info.HeapBase = uint32(heapBase)
+	return info, nil
+}
+
+func (info *ModuleInfo) setLimits(limits wasm.ResizableLimits) {
+	info.MinPages = limits.Initial
+	if limits.Flags&1 == 1 {
+		info.MaxPages = limits.Maximum
+	}
+}
+
+// MemoryPages returns the number of pages the module's memory needs for the given number of extra heap pages. It
+// returns an error if the memory can't grow to that size.
+func (info *ModuleInfo) MemoryPages(heapPages uint64) (uint32, error) {
+	pages := uint64(info.MinPages) + heapPages
+	if info.MaxPages != 0 && pages > uint64(info.MaxPages) {
+		return 0, fmt.Errorf("memory needs %d pages, but the module allows at most %d", pages, info.MaxPages)
+	}
+
+	if pages > 1<<16 {
+		return 0, fmt.Errorf("memory needs %d pages, which exceeds the wasm limit", pages)
+	}
+
+	return uint32(pages), nil
+}
+
+// GetHeapPages returns the number of extra heap pages stored under the :heappages key, or 0 if the key isn't set
+func GetHeapPages(s Storage) (uint64, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	enc := s.Get(common.HeapPagesKey)
+	if len(enc) == 0 {
+		return 0, nil
+	}
+
+	if len(enc) != 8 {
+		return 0, errors.New("invalid :heappages value")
+	}
+
+	return binary.LittleEndian.Uint64(enc), nil
+}
+
+// GrowMemory grows the memory to the given number of pages if it's smaller
+func GrowMemory(mem Memory, pages uint32) error {
+	have := mem.Length() / PageSize
+	if have >= pages {
+		return nil
+	}
+
+	return mem.Grow(pages - have)
+}
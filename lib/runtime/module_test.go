@@ -0,0 +1,116 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModuleInfo(t *testing.T) {
+	testCases := []struct {
+		name     string
+		wat      string
+		expected *ModuleInfo
+	}{
+		{
+			name: "exported memory",
+			wat: `(module
+				(memory (export "memory") 18)
+				(global (export "__heap_base") i32 (i32.const 1234)))`,
+			expected: &ModuleInfo{
+				HeapBase: 1234,
+				MinPages: 18,
+			},
+		},
+		{
+			name: "imported memory and globals",
+			wat: `(module
+				(import "env" "memory" (memory 17 100))
+				(import "env" "global" (global i32))
+				(global (mut i32) (i32.const 5))
+				(global i32 (i32.const 4096))
+				(export "__heap_base" (global 2)))`,
+			expected: &ModuleInfo{
+				HeapBase:      4096,
+				ImportsMemory: true,
+				MinPages:      17,
+				MaxPages:      100,
+			},
+		},
+		{
+			name: "no heap base",
+			wat:  `(module (memory 2))`,
+			expected: &ModuleInfo{
+				HeapBase: DefaultHeapBase,
+				MinPages: 2,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		code, err := wasmtime.Wat2Wasm(tc.wat)
+		require.NoError(t, err, tc.name)
+
+		info, err := GetModuleInfo(code)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, info, tc.name)
+	}
+}
+
+func TestGetModuleInfo_Invalid(t *testing.T) {
+	_, err := GetModuleInfo([]byte("not wasm"))
+	require.Error(t, err)
+}
+
+func TestModuleInfo_MemoryPages(t *testing.T) {
+	info := &ModuleInfo{MinPages: 17}
+	pages, err := info.MemoryPages(1024)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1041), pages)
+
+	info.MaxPages = 1000
+	_, err = info.MemoryPages(1024)
+	require.Error(t, err)
+}
+
+func TestGetHeapPages(t *testing.T) {
+	s, err := storage.NewTrieState(nil)
+	require.NoError(t, err)
+
+	heapPages, err := GetHeapPages(s)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), heapPages)
+
+	enc := make([]byte, 8)
+	binary.LittleEndian.PutUint64(enc, 2048)
+	s.Set(common.HeapPagesKey, enc)
+
+	heapPages, err = GetHeapPages(s)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2048), heapPages)
+
+	s.Set(common.HeapPagesKey, []byte{1})
+	_, err = GetHeapPages(s)
+	require.Error(t, err)
+}
@@ -0,0 +1,537 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package offchain
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChainSafe/gossamer/lib/scale"
+)
+
+// HTTPError is an error returned by the offchain HTTP functions, as defined by sp_core::offchain::HttpError
+type HTTPError byte
+
+// HTTPError variants
+const (
+	ErrDeadlineReached HTTPError = 0
+	ErrIO              HTTPError = 1
+	ErrInvalidRequest  HTTPError = 2
+)
+
+// Error returns the error message
+func (e HTTPError) Error() string {
+	switch e {
+	case ErrDeadlineReached:
+		return "deadline reached"
+	case ErrIO:
+		return "http request failed"
+	default:
+		return "invalid http request"
+	}
+}
+
+// ErrTooManyRequests is returned when starting a request while all request ids are in use
+var ErrTooManyRequests = errors.New("too many http requests")
+
+// HTTPRequestStatus is the status of an HTTP request, as returned by Wait
+type HTTPRequestStatus struct {
+	// Err is set if the response couldn't be received
+	Err error
+	// Code is the status code of the response
+	Code uint16
+}
+
+// Encode SCALE encodes the status as a sp_core::offchain::HttpRequestStatus
+func (s HTTPRequestStatus) Encode() []byte {
+	var httpErr HTTPError
+	if errors.As(s.Err, &httpErr) {
+		return []byte{byte(httpErr)}
+	} else if s.Err != nil {
+		return []byte{byte(ErrIO)}
+	}
+
+	enc := []byte{3, 0, 0}
+	binary.LittleEndian.PutUint16(enc[1:], s.Code)
+	return enc
+}
+
+// EncodeHTTPRequestStatuses SCALE encodes the given statuses as a Vec<HttpRequestStatus>
+func EncodeHTTPRequestStatuses(statuses []HTTPRequestStatus) ([]byte, error) {
+	enc, err := scale.Encode(big.NewInt(int64(len(statuses))))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range statuses {
+		enc = append(enc, s.Encode()...)
+	}
+	return enc, nil
+}
+
+// EncodeHTTPHeaders SCALE encodes the given headers as a Vec<(Vec<u8>, Vec<u8>)>
+func EncodeHTTPHeaders(headers [][2]string) ([]byte, error) {
+	enc, err := scale.Encode(big.NewInt(int64(len(headers))))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range headers {
+		for _, s := range h {
+			e, err := scale.Encode([]byte(s))
+			if err != nil {
+				return nil, err
+			}
+			enc = append(enc, e...)
+		}
+	}
+	return enc, nil
+}
+
+// httpRequest is an HTTP request started by an offchain worker
+type httpRequest struct {
+	method        string
+	uri           string
+	header        http.Header
+	contentLength int64
+
+	// set once the request has been dispatched
+	dispatched bool
+	body       *io.PipeWriter
+	bodyDone   bool
+	cancel     context.CancelFunc
+
+	// done is closed once the response, or an error, has been received
+	done chan struct{}
+	resp *http.Response
+	err  error
+}
+
+// HTTPSet holds the HTTP requests made by an offchain worker. Requests are identified by a 16 bit id; their body is
+// streamed to the server as it's written, and the response body is streamed from the server as it's read.
+type HTTPSet struct {
+	mtx    sync.Mutex
+	client *http.Client
+	reqs   map[uint16]*httpRequest
+	nextID uint16
+}
+
+// NewHTTPSet returns a new HTTPSet that sends its requests using the given transport, or http.DefaultTransport if
+// it's nil
+func NewHTTPSet(transport http.RoundTripper) *HTTPSet {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &HTTPSet{
+		client: &http.Client{Transport: transport},
+		reqs:   make(map[uint16]*httpRequest),
+	}
+}
+
+// Reset cancels all the requests of the set
+func (s *HTTPSet) Reset() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for id, r := range s.reqs {
+		r.close()
+		delete(s.reqs, id)
+	}
+}
+
+// StartRequest creates a new request with the given method and uri and returns its id. The request is only sent once
+// its body is written or its response is waited for.
+func (s *HTTPSet) StartRequest(method, uri string) (uint16, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return 0, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return 0, fmt.Errorf("unsupported scheme: %s", u.Scheme)
+	}
+
+	if method == "" || strings.ContainsAny(method, " \t\r\n") {
+		return 0, fmt.Errorf("invalid method: %s", method)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.reqs) > 0xFFFF {
+		return 0, ErrTooManyRequests
+	}
+
+	for {
+		if _, ok := s.reqs[s.nextID]; !ok {
+			break
+		}
+		s.nextID++
+	}
+
+	id := s.nextID
+	s.nextID++
+
+	s.reqs[id] = &httpRequest{
+		method:        method,
+		uri:           uri,
+		header:        make(http.Header),
+		contentLength: -1,
+		done:          make(chan struct{}),
+	}
+	return id, nil
+}
+
+func (s *HTTPSet) getRequest(id uint16) (*httpRequest, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	r, ok := s.reqs[id]
+	if !ok {
+		return nil, ErrInvalidRequest
+	}
+	return r, nil
+}
+
+func (s *HTTPSet) removeRequest(id uint16) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if r, ok := s.reqs[id]; ok {
+		r.close()
+		delete(s.reqs, id)
+	}
+}
+
+// AddHeader adds a header to the request. Headers can only be added before the request is sent.
+func (s *HTTPSet) AddHeader(id uint16, name, value string) error {
+	r, err := s.getRequest(id)
+	if err != nil {
+		return err
+	}
+
+	if r.dispatched {
+		return ErrInvalidRequest
+	}
+
+	if strings.EqualFold(name, "Content-Length") {
+		l, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || l < 0 {
+			return ErrInvalidRequest
+		}
+		r.contentLength = l
+		return nil
+	}
+
+	r.header.Add(name, value)
+	return nil
+}
+
+// WriteBody writes a chunk of the request body, sending the request if it hasn't been sent yet. An empty chunk marks
+// the end of the body. If the chunk cannot be written before the deadline, the request is cancelled.
+func (s *HTTPSet) WriteBody(id uint16, chunk []byte, deadline *time.Time) error {
+	r, err := s.getRequest(id)
+	if err != nil {
+		return err
+	}
+
+	if !r.dispatched {
+		s.dispatch(r, true)
+	}
+
+	if r.bodyDone || r.body == nil {
+		return ErrInvalidRequest
+	}
+
+	if len(chunk) == 0 {
+		r.finishBody()
+		return nil
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := r.body.Write(chunk)
+		written <- err
+	}()
+
+	timeout, stop := deadlineTimer(deadline)
+	defer stop()
+
+	select {
+	case err = <-written:
+		if err != nil {
+			return ErrIO
+		}
+		return nil
+	case <-timeout:
+		r.close()
+		return ErrDeadlineReached
+	}
+}
+
+// Wait sends the requests that haven't been sent yet, ends their bodies and waits for their responses until the
+// deadline. It returns the status of each of the requests.
+func (s *HTTPSet) Wait(ids []uint16, deadline *time.Time) []HTTPRequestStatus {
+	reqs := make([]*httpRequest, len(ids))
+	for i, id := range ids {
+		r, err := s.getRequest(id)
+		if err != nil {
+			continue
+		}
+
+		if !r.dispatched {
+			s.dispatch(r, false)
+		}
+		r.finishBody()
+		reqs[i] = r
+	}
+
+	timeout, stop := deadlineTimer(deadline)
+	defer stop()
+
+	statuses := make([]HTTPRequestStatus, len(ids))
+	expired := false
+	for i, r := range reqs {
+		if r == nil {
+			statuses[i] = HTTPRequestStatus{Err: ErrInvalidRequest}
+			continue
+		}
+
+		if !expired {
+			select {
+			case <-r.done:
+			case <-timeout:
+				expired = true
+			}
+		}
+
+		select {
+		case <-r.done:
+			statuses[i] = r.status()
+		default:
+			statuses[i] = HTTPRequestStatus{Err: ErrDeadlineReached}
+		}
+	}
+	return statuses
+}
+
+// ResponseHeaders returns the headers of the request's response, or nil if the response hasn't been received
+func (s *HTTPSet) ResponseHeaders(id uint16) [][2]string {
+	r, err := s.getRequest(id)
+	if err != nil {
+		return nil
+	}
+
+	select {
+	case <-r.done:
+	default:
+		return nil
+	}
+
+	if r.resp == nil {
+		return nil
+	}
+
+	var headers [][2]string
+	for name, values := range r.resp.Header {
+		for _, v := range values {
+			headers = append(headers, [2]string{name, v})
+		}
+	}
+	return headers
+}
+
+// ReadBody reads the next chunk of the response body into buf, waiting for the response until the deadline if it
+// hasn't been received yet. It returns the number of bytes read; 0 means the whole body has been read, after which
+// the request is removed.
+func (s *HTTPSet) ReadBody(id uint16, buf []byte, deadline *time.Time) (int, error) {
+	statuses := s.Wait([]uint16{id}, deadline)
+	if statuses[0].Err != nil {
+		if statuses[0].Err != ErrDeadlineReached {
+			s.removeRequest(id)
+		}
+		return 0, statuses[0].Err
+	}
+
+	r, err := s.getRequest(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	type readResult struct {
+		n   int
+		err error
+	}
+
+	read := make(chan readResult, 1)
+	go func() {
+		var (
+			n   int
+			err error
+		)
+		for n == 0 && err == nil {
+			n, err = r.resp.Body.Read(buf)
+		}
+		read <- readResult{n, err}
+	}()
+
+	timeout, stop := deadlineTimer(deadline)
+	defer stop()
+
+	select {
+	case res := <-read:
+		if res.n > 0 {
+			return res.n, nil
+		}
+
+		s.removeRequest(id)
+		if res.err == io.EOF {
+			return 0, nil
+		}
+		return 0, ErrIO
+	case <-timeout:
+		// the pending read would write into buf after we've returned, so the request cannot be continued
+		s.removeRequest(id)
+		<-read
+		return 0, ErrDeadlineReached
+	}
+}
+
+// dispatch sends the request. If withBody is set, the body is streamed from the chunks written with WriteBody.
+func (s *HTTPSet) dispatch(r *httpRequest, withBody bool) {
+	r.dispatched = true
+
+	var body io.Reader = http.NoBody
+	if withBody {
+		pr, pw := io.Pipe()
+		body, r.body = pr, pw
+	} else {
+		r.bodyDone = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	req, err := http.NewRequestWithContext(ctx, r.method, r.uri, body)
+	if err != nil {
+		r.err = err
+		close(r.done)
+		return
+	}
+
+	req.Header = r.header
+	if withBody && r.contentLength >= 0 {
+		req.ContentLength = r.contentLength
+	}
+
+	go func() {
+		resp, err := s.client.Do(req) //nolint
+		r.resp, r.err = resp, err
+		close(r.done)
+	}()
+}
+
+// finishBody ends the request body
+func (r *httpRequest) finishBody() {
+	if r.bodyDone {
+		return
+	}
+
+	r.bodyDone = true
+	if r.body != nil {
+		_ = r.body.Close()
+	}
+}
+
+// close cancels the request and closes its response body
+func (r *httpRequest) close() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+
+	if r.body != nil {
+		_ = r.body.CloseWithError(context.Canceled)
+	}
+
+	if !r.dispatched {
+		return
+	}
+
+	<-r.done
+	if r.resp != nil {
+		_ = r.resp.Body.Close()
+	}
+}
+
+// status returns the status of a request whose response has been received
+func (r *httpRequest) status() HTTPRequestStatus {
+	if r.err != nil {
+		return HTTPRequestStatus{Err: ErrIO}
+	}
+	return HTTPRequestStatus{Code: uint16(r.resp.StatusCode)}
+}
+
+// deadlineTimer returns a channel that fires at the deadline, or never if there is no deadline, and a function to
+// release the timer
+func deadlineTimer(deadline *time.Time) (<-chan time.Time, func()) {
+	if deadline == nil {
+		return nil, func() {}
+	}
+
+	t := time.NewTimer(time.Until(*deadline))
+	return t.C, func() { t.Stop() }
+}
+
+// DecodeDeadline decodes a SCALE encoded Option<Timestamp>, where the timestamp is the number of milliseconds since
+// the unix epoch
+func DecodeDeadline(in []byte) (*time.Time, error) {
+	if len(in) == 0 {
+		return nil, errors.New("invalid deadline")
+	}
+
+	if in[0] == 0 {
+		return nil, nil
+	}
+
+	if len(in) != 9 {
+		return nil, errors.New("invalid deadline")
+	}
+
+	ms := int64(binary.LittleEndian.Uint64(in[1:]))
+	t := time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+	return &t, nil
+}
+
+// Timestamp returns the current time as the number of milliseconds since the unix epoch
+func Timestamp() uint64 {
+	return uint64(time.Now().UnixNano() / int64(time.Millisecond))
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package offchain
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		switch r.URL.Path {
+		case "/echo":
+			w.Header().Set("X-Method", r.Method)
+			w.Header().Set("X-Test", r.Header.Get("X-Test"))
+			_, _ = w.Write(body)
+		case "/slow":
+			time.Sleep(time.Second)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPSet_Request(t *testing.T) {
+	srv := newTestServer(t)
+	set := NewHTTPSet(srv.Client().Transport)
+	defer set.Reset()
+
+	id, err := set.StartRequest("POST", srv.URL+"/echo")
+	require.NoError(t, err)
+
+	err = set.AddHeader(id, "X-Test", "noot")
+	require.NoError(t, err)
+
+	err = set.WriteBody(id, []byte("hello "), nil)
+	require.NoError(t, err)
+	err = set.WriteBody(id, []byte("world"), nil)
+	require.NoError(t, err)
+
+	// headers cannot be added once the request has been sent
+	err = set.AddHeader(id, "X-Other", "value")
+	require.Equal(t, ErrInvalidRequest, err)
+
+	err = set.WriteBody(id, nil, nil)
+	require.NoError(t, err)
+
+	statuses := set.Wait([]uint16{id, id + 1}, nil)
+	require.Equal(t, []HTTPRequestStatus{{Code: 200}, {Err: ErrInvalidRequest}}, statuses)
+
+	headers := set.ResponseHeaders(id)
+	require.Contains(t, headers, [2]string{"X-Method", "POST"})
+	require.Contains(t, headers, [2]string{"X-Test", "noot"})
+
+	var body []byte
+	buf := make([]byte, 4)
+	for {
+		n, err := set.ReadBody(id, buf, nil)
+		require.NoError(t, err)
+		if n == 0 {
+			break
+		}
+		body = append(body, buf[:n]...)
+	}
+	require.Equal(t, "hello world", string(body))
+
+	// the request is removed once its body has been read
+	_, err = set.ReadBody(id, buf, nil)
+	require.Equal(t, ErrInvalidRequest, err)
+}
+
+func TestHTTPSet_Wait(t *testing.T) {
+	srv := newTestServer(t)
+	set := NewHTTPSet(srv.Client().Transport)
+	defer set.Reset()
+
+	notFound, err := set.StartRequest("GET", srv.URL+"/missing")
+	require.NoError(t, err)
+
+	slow, err := set.StartRequest("GET", srv.URL+"/slow")
+	require.NoError(t, err)
+	require.NotEqual(t, notFound, slow)
+
+	deadline := time.Now().Add(200 * time.Millisecond)
+	statuses := set.Wait([]uint16{notFound, slow}, &deadline)
+	require.Equal(t, []HTTPRequestStatus{{Code: 404}, {Err: ErrDeadlineReached}}, statuses)
+
+	_, err = set.ReadBody(slow, make([]byte, 1), &deadline)
+	require.Equal(t, ErrDeadlineReached, err)
+}
+
+func TestHTTPSet_StartRequest_Invalid(t *testing.T) {
+	set := NewHTTPSet(nil)
+
+	_, err := set.StartRequest("GET", "ftp://localhost")
+	require.Error(t, err)
+
+	_, err = set.StartRequest("G ET", "http://localhost")
+	require.Error(t, err)
+}
+
+func TestHTTPSet_Reset(t *testing.T) {
+	set := NewHTTPSet(nil)
+
+	id, err := set.StartRequest("GET", "http://localhost")
+	require.NoError(t, err)
+
+	set.Reset()
+	err = set.AddHeader(id, "X-Test", "noot")
+	require.Equal(t, ErrInvalidRequest, err)
+}
+
+func TestHTTPRequestStatus_Encode(t *testing.T) {
+	enc, err := EncodeHTTPRequestStatuses([]HTTPRequestStatus{
+		{Code: 200},
+		{Err: ErrDeadlineReached},
+		{Err: ErrIO},
+		{Err: ErrInvalidRequest},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte{16, 3, 200, 0, 0, 1, 2}, enc)
+}
+
+func TestDecodeDeadline(t *testing.T) {
+	deadline, err := DecodeDeadline([]byte{0})
+	require.NoError(t, err)
+	require.Nil(t, deadline)
+
+	deadline, err = DecodeDeadline([]byte{1, 0xe8, 3, 0, 0, 0, 0, 0, 0})
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1, 0), *deadline)
+
+	_, err = DecodeDeadline([]byte{1, 0})
+	require.Error(t, err)
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// DefaultRegistryCapacity is the number of runtime codes a Registry caches instances for if no capacity is given
+const DefaultRegistryCapacity = 8
+
+// maxIdleInstances is the number of idle instances a Registry keeps for each runtime code. Any instances beyond
+// this, which are only created when the same runtime is called concurrently, are stopped once they are released.
+const maxIdleInstances = 4
+
+// InstanceFactory creates a runtime instance with the given code and storage
+type InstanceFactory func(code []byte, s Storage) (Instance, error)
+
+// Registry caches runtime instances keyed by the hash of their code and their number of heap pages, so that a runtime
+// can be called at the state of any block without recompiling its code. Instances are created lazily the first time
+// the code is needed, and the instances of the least recently used code are stopped once more than the registry's
+// capacity of codes are cached.
+type Registry struct {
+	lock        sync.Mutex
+	capacity    int
+	newInstance InstanceFactory
+	entries     map[registryKey]*list.Element
+	lru         *list.List // of *registryEntry, the most recently used entry is at the front
+}
+
+// registryKey identifies the instances that can be used for a state. The heap pages are part of the key since the
+// memory of an instance is sized when it is created.
+type registryKey struct {
+	codeHash  common.Hash
+	heapPages uint64
+}
+
+type registryEntry struct {
+	key  registryKey
+	idle []Instance // instances that aren't currently in use
+}
+
+// NewRegistry returns a new Registry that creates instances using the given factory and caches the instances of up
+// to capacity runtime codes
+func NewRegistry(capacity int, newInstance InstanceFactory) *Registry {
+	if capacity <= 0 {
+		capacity = DefaultRegistryCapacity
+	}
+
+	return &Registry{
+		capacity:    capacity,
+		newInstance: newInstance,
+		entries:     make(map[registryKey]*list.Element),
+		lru:         list.New(),
+	}
+}
+
+// Call calls fn with an instance of the runtime code in the given storage, with the storage set as the instance's
+// context storage. The instance is only used by fn until it returns, so it must not be retained.
+func (r *Registry) Call(s Storage, fn func(rt Instance) error) error {
+	codeHash, err := s.LoadCodeHash()
+	if err != nil {
+		return err
+	}
+
+	heapPages, err := GetHeapPages(s)
+	if err != nil {
+		return err
+	}
+
+	key := registryKey{
+		codeHash:  codeHash,
+		heapPages: heapPages,
+	}
+
+	rt, err := r.acquire(key, s)
+	if err != nil {
+		return err
+	}
+	defer r.release(key, rt)
+
+	rt.SetContextStorage(s)
+	return fn(rt)
+}
+
+// Len returns the number of runtime codes the registry has instances for
+func (r *Registry) Len() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.lru.Len()
+}
+
+// Stop stops all the idle instances in the registry and clears it
+func (r *Registry) Stop() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for e := r.lru.Front(); e != nil; e = e.Next() {
+		stopInstances(e.Value.(*registryEntry).idle)
+	}
+
+	r.entries = make(map[registryKey]*list.Element)
+	r.lru.Init()
+}
+
+// acquire returns an idle instance for the given key, or creates a new one using the code in the given storage if there
+// are none
+func (r *Registry) acquire(key registryKey, s Storage) (Instance, error) {
+	r.lock.Lock()
+	if e, has := r.entries[key]; has {
+		r.lru.MoveToFront(e)
+
+		entry := e.Value.(*registryEntry)
+		if n := len(entry.idle); n > 0 {
+			rt := entry.idle[n-1]
+			entry.idle = entry.idle[:n-1]
+			r.lock.Unlock()
+			return rt, nil
+		}
+	}
+	r.lock.Unlock()
+
+	// compiling the code is slow, so it is done without holding the lock
+	code := s.LoadCode()
+	if len(code) == 0 {
+		return nil, ErrEmptyRuntimeCode
+	}
+
+	return r.newInstance(code, s)
+}
+
+// release returns the given instance to the registry once it is no longer in use. If the registry is full, the
+// instances of the least recently used code are stopped.
+func (r *Registry) release(key registryKey, rt Instance) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	e, has := r.entries[key]
+	if !has {
+		e = r.lru.PushFront(&registryEntry{key: key})
+		r.entries[key] = e
+	}
+
+	entry := e.Value.(*registryEntry)
+	if len(entry.idle) < maxIdleInstances {
+		entry.idle = append(entry.idle, rt)
+	} else {
+		rt.Stop()
+	}
+
+	for r.lru.Len() > r.capacity {
+		oldest := r.lru.Back()
+		entry := r.lru.Remove(oldest).(*registryEntry)
+		delete(r.entries, entry.key)
+		stopInstances(entry.idle)
+	}
+}
+
+func stopInstances(instances []Instance) {
+	for _, rt := range instances {
+		rt.Stop()
+	}
+}
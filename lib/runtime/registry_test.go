@@ -0,0 +1,149 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockRegistryInstance is a runtime instance that records the code it was created with and whether it was stopped
+type mockRegistryInstance struct {
+	Instance
+	code    []byte
+	storage Storage
+	stopped bool
+}
+
+func (rt *mockRegistryInstance) SetContextStorage(s Storage) { rt.storage = s }
+func (rt *mockRegistryInstance) Stop()                       { rt.stopped = true }
+
+func newTestRegistry(capacity int) (*Registry, *[]*mockRegistryInstance) {
+	var (
+		lock      sync.Mutex
+		instances []*mockRegistryInstance
+	)
+
+	r := NewRegistry(capacity, func(code []byte, _ Storage) (Instance, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		rt := &mockRegistryInstance{code: code}
+		instances = append(instances, rt)
+		return rt, nil
+	})
+	return r, &instances
+}
+
+func newTestRegistryStorage(t *testing.T, code []byte) *storage.TrieState {
+	ts, err := storage.NewTrieState(nil)
+	require.NoError(t, err)
+	ts.Set(common.CodeKey, code)
+	return ts
+}
+
+func TestRegistry_Call(t *testing.T) {
+	r, instances := newTestRegistry(0)
+
+	ts := newTestRegistryStorage(t, []byte("code"))
+	for i := 0; i < 3; i++ {
+		err := r.Call(ts, func(rt Instance) error {
+			require.Equal(t, ts, rt.(*mockRegistryInstance).storage)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	// the instance is reused for calls with the same code
+	require.Len(t, *instances, 1)
+	require.Equal(t, []byte("code"), (*instances)[0].code)
+
+	err := r.Call(newTestRegistryStorage(t, []byte("other code")), func(_ Instance) error { return nil })
+	require.NoError(t, err)
+	require.Len(t, *instances, 2)
+	require.Equal(t, 2, r.Len())
+}
+
+func TestRegistry_Call_HeapPages(t *testing.T) {
+	r, instances := newTestRegistry(0)
+
+	ts := newTestRegistryStorage(t, []byte("code"))
+	err := r.Call(ts, func(_ Instance) error { return nil })
+	require.NoError(t, err)
+
+	// instances with the same code but a different number of heap pages aren't shared
+	ts = newTestRegistryStorage(t, []byte("code"))
+	ts.Set(common.HeapPagesKey, []byte{8, 0, 0, 0, 0, 0, 0, 0})
+	for i := 0; i < 2; i++ {
+		err = r.Call(ts, func(_ Instance) error { return nil })
+		require.NoError(t, err)
+	}
+
+	require.Len(t, *instances, 2)
+	require.Equal(t, 2, r.Len())
+}
+
+func TestRegistry_Call_Concurrent(t *testing.T) {
+	r, instances := newTestRegistry(0)
+	ts := newTestRegistryStorage(t, []byte("code"))
+
+	// calls made while the instance is in use get their own instance
+	err := r.Call(ts, func(outer Instance) error {
+		return r.Call(ts, func(inner Instance) error {
+			require.NotSame(t, outer, inner)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	require.Len(t, *instances, 2)
+	require.Equal(t, 1, r.Len())
+}
+
+func TestRegistry_Call_EmptyCode(t *testing.T) {
+	r, _ := newTestRegistry(0)
+
+	err := r.Call(newTestRegistryStorage(t, nil), func(_ Instance) error { return nil })
+	require.Equal(t, ErrEmptyRuntimeCode, err)
+	require.Equal(t, 0, r.Len())
+}
+
+func TestRegistry_Evict(t *testing.T) {
+	r, instances := newTestRegistry(2)
+
+	codes := [][]byte{[]byte("a"), []byte("b"), []byte("a"), []byte("c")}
+	for _, code := range codes {
+		err := r.Call(newTestRegistryStorage(t, code), func(_ Instance) error { return nil })
+		require.NoError(t, err)
+	}
+
+	// "b" is the least recently used code, so its instance is stopped when "c" is added
+	require.Len(t, *instances, 3)
+	require.False(t, (*instances)[0].stopped)
+	require.True(t, (*instances)[1].stopped)
+	require.False(t, (*instances)[2].stopped)
+	require.Equal(t, 2, r.Len())
+
+	r.Stop()
+	require.True(t, (*instances)[0].stopped)
+	require.True(t, (*instances)[2].stopped)
+	require.Equal(t, 0, r.Len())
+}
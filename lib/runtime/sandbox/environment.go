@@ -0,0 +1,89 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sandbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/ChainSafe/gossamer/lib/scale"
+)
+
+// externKind is the kind of an entity provided by the supervisor to a sandboxed instance
+type externKind byte
+
+// externKind variants, as encoded by sp_sandbox::ExternEntity
+const (
+	externFunction externKind = 1
+	externMemory   externKind = 2
+)
+
+// extern is an entity provided by the supervisor that can be imported by a sandboxed instance. For functions, the index
+// is the index of the supervisor function in the runtime's table, for memories it is the sandbox memory index.
+type extern struct {
+	kind  externKind
+	index uint32
+}
+
+// environment maps the module and field names of a sandboxed instance's imports to the entities provided for them
+type environment map[[2]string]extern
+
+// decodeEnvironment decodes a SCALE encoded sp_sandbox::EnvironmentDefinition
+func decodeEnvironment(in []byte) (environment, error) {
+	r := bytes.NewReader(in)
+	sd := &scale.Decoder{Reader: r}
+
+	n, err := sd.DecodeInteger()
+	if err != nil {
+		return nil, err
+	}
+
+	env := make(environment)
+	for i := int64(0); i < n; i++ {
+		module, err := sd.DecodeByteArray()
+		if err != nil {
+			return nil, err
+		}
+
+		field, err := sd.DecodeByteArray()
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, 5)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+
+		kind := externKind(buf[0])
+		if kind != externFunction && kind != externMemory {
+			return nil, fmt.Errorf("invalid extern entity: %d", buf[0])
+		}
+
+		env[[2]string{string(module), string(field)}] = extern{
+			kind:  kind,
+			index: binary.LittleEndian.Uint32(buf[1:]),
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("trailing bytes after environment definition")
+	}
+	return env, nil
+}
@@ -0,0 +1,490 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/perlin-network/life/exec"
+)
+
+// Return codes of the ext_sandbox host functions, as defined by sp_sandbox
+const (
+	ErrCodeOK          uint32 = 0
+	ErrCodeModule      uint32 = 0xFFFFFFFF
+	ErrCodeOutOfBounds uint32 = 0xFFFFFFFE
+	ErrCodeExecution   uint32 = 0xFFFFFFFD
+)
+
+const (
+	// pageSize is the size of a wasm memory page
+	pageSize = 65536
+	// maxPages is the maximum number of pages a wasm memory can have
+	maxPages = 65536
+	// noMaximum is the maximum number of pages of a memory that can grow without limit
+	noMaximum = 0xFFFFFFFF
+)
+
+var (
+	// ErrInvalidMemory is returned when a sandbox memory index doesn't exist or has been torn down
+	ErrInvalidMemory = errors.New("invalid sandbox memory index")
+	// ErrInvalidInstance is returned when a sandbox instance index doesn't exist or has been torn down
+	ErrInvalidInstance = errors.New("invalid sandbox instance index")
+	// ErrOutOfBounds is returned when accessing a sandbox memory out of its bounds
+	ErrOutOfBounds = errors.New("sandbox memory access out of bounds")
+	// ErrModule is returned when a sandbox module cannot be instantiated
+	ErrModule = errors.New("cannot instantiate sandbox module")
+	// ErrExecution is returned when a sandboxed instance traps or cannot be invoked
+	ErrExecution = errors.New("sandbox execution failed")
+)
+
+// Dispatcher calls the dispatch thunk of the supervisor (ie. the runtime), which invokes the supervisor function with
+// the given index using the SCALE encoded arguments and returns its SCALE encoded Result<ReturnValue, HostError>
+type Dispatcher interface {
+	Dispatch(thunk, funcIdx, state uint32, args []byte) ([]byte, error)
+}
+
+// memory is a sandbox linear memory. Once it's imported by an instance, the instance's linear memory is used as its
+// storage, so that the instance can grow it.
+type memory struct {
+	data    []byte
+	maximum uint32
+	vm      *exec.VirtualMachine
+}
+
+func (m *memory) bytes() []byte {
+	if m.vm != nil {
+		return m.vm.Memory
+	}
+	return m.data
+}
+
+// instance is a sandboxed module instance
+type instance struct {
+	vm      *exec.VirtualMachine
+	thunk   uint32
+	state   uint32
+	running bool
+}
+
+// supervisorFunc is a supervisor function imported by a sandboxed module
+type supervisorFunc struct {
+	index uint32
+	sig   *wasm.FunctionSig
+}
+
+// resolver resolves the function imports of a sandboxed module to supervisor functions
+type resolver struct {
+	sandbox *Sandbox
+	inst    *instance
+	funcs   map[[2]string]supervisorFunc
+}
+
+// ResolveFunc returns the function that calls the supervisor function imported as module.field
+func (r *resolver) ResolveFunc(module, field string) exec.FunctionImport {
+	f, ok := r.funcs[[2]string{module, field}]
+	if !ok {
+		panic(fmt.Errorf("missing import %s.%s", module, field))
+	}
+
+	return func(vm *exec.VirtualMachine) int64 {
+		ret, err := r.sandbox.callSupervisor(r.inst, f, vm.GetCurrentFrame().Locals)
+		if err != nil {
+			panic(err)
+		}
+		return ret
+	}
+}
+
+// ResolveGlobal panics, as sandboxed modules cannot import globals
+func (r *resolver) ResolveGlobal(module, field string) int64 {
+	panic(fmt.Errorf("cannot import global %s.%s", module, field))
+}
+
+// Sandbox holds the memories and module instances created by the runtime using the ext_sandbox host functions.
+// Sandboxed modules are executed by the life interpreter; calls from a sandboxed module to the functions provided by
+// the runtime are made through the Dispatcher.
+type Sandbox struct {
+	dispatcher Dispatcher
+	memories   []*memory
+	instances  []*instance
+}
+
+// NewSandbox returns a new Sandbox that calls into the supervisor using the given Dispatcher
+func NewSandbox(d Dispatcher) *Sandbox {
+	return &Sandbox{
+		dispatcher: d,
+	}
+}
+
+// Reset tears down all the sandbox memories and instances
+func (s *Sandbox) Reset() {
+	s.memories = nil
+	s.instances = nil
+}
+
+func (s *Sandbox) getMemory(idx uint32) (*memory, error) {
+	if int(idx) >= len(s.memories) || s.memories[idx] == nil {
+		return nil, ErrInvalidMemory
+	}
+	return s.memories[idx], nil
+}
+
+func (s *Sandbox) getInstance(idx uint32) (*instance, error) {
+	if int(idx) >= len(s.instances) || s.instances[idx] == nil {
+		return nil, ErrInvalidInstance
+	}
+	return s.instances[idx], nil
+}
+
+// NewMemory creates a new sandbox memory with the given initial and maximum number of pages and returns its index.
+// A maximum of math.MaxUint32 means the memory has no maximum.
+func (s *Sandbox) NewMemory(initial, maximum uint32) (uint32, error) {
+	if initial > maxPages || (maximum != noMaximum && (maximum > maxPages || maximum < initial)) {
+		return 0, fmt.Errorf("invalid memory limits: initial=%d maximum=%d", initial, maximum)
+	}
+
+	s.memories = append(s.memories, &memory{
+		data:    make([]byte, int(initial)*pageSize),
+		maximum: maximum,
+	})
+	return uint32(len(s.memories) - 1), nil
+}
+
+// MemoryGet copies len(buf) bytes starting at the given offset of the sandbox memory into buf
+func (s *Sandbox) MemoryGet(idx, offset uint32, buf []byte) error {
+	mem, err := s.getMemory(idx)
+	if err != nil {
+		return err
+	}
+
+	data := mem.bytes()
+	end := uint64(offset) + uint64(len(buf))
+	if end > uint64(len(data)) {
+		return ErrOutOfBounds
+	}
+
+	copy(buf, data[offset:end])
+	return nil
+}
+
+// MemorySet copies val into the sandbox memory at the given offset
+func (s *Sandbox) MemorySet(idx, offset uint32, val []byte) error {
+	mem, err := s.getMemory(idx)
+	if err != nil {
+		return err
+	}
+
+	data := mem.bytes()
+	end := uint64(offset) + uint64(len(val))
+	if end > uint64(len(data)) {
+		return ErrOutOfBounds
+	}
+
+	copy(data[offset:end], val)
+	return nil
+}
+
+// MemoryTeardown tears down the sandbox memory with the given index
+func (s *Sandbox) MemoryTeardown(idx uint32) error {
+	if _, err := s.getMemory(idx); err != nil {
+		return err
+	}
+
+	s.memories[idx] = nil
+	return nil
+}
+
+// Instantiate instantiates the given wasm code and returns the index of the new instance. The imports of the module
+// are resolved using the SCALE encoded environment definition, which provides either supervisor functions that are
+// called through the given dispatch thunk, or sandbox memories. The state is passed to the supervisor functions
+// called by the module's start function.
+func (s *Sandbox) Instantiate(thunk uint32, code, envDef []byte, state uint32) (uint32, error) {
+	env, err := decodeEnvironment(envDef)
+	if err != nil {
+		return 0, fmt.Errorf("%w: cannot decode environment definition: %s", ErrModule, err)
+	}
+
+	inst := &instance{
+		thunk: thunk,
+		state: state,
+	}
+
+	r := &resolver{
+		sandbox: s,
+		inst:    inst,
+		funcs:   make(map[[2]string]supervisorFunc),
+	}
+
+	mod, err := exec.NewModule(code, exec.VMConfig{MaxMemoryPages: maxPages}, r, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrModule, err)
+	}
+
+	module := mod.Module.Base
+
+	var mem *memory
+	if module.Import != nil {
+		for _, imp := range module.Import.Entries {
+			ext, ok := env[[2]string{imp.ModuleName, imp.FieldName}]
+			if !ok {
+				return 0, fmt.Errorf("%w: missing import %s.%s", ErrModule, imp.ModuleName, imp.FieldName)
+			}
+
+			switch ty := imp.Type.(type) {
+			case wasm.FuncImport:
+				if ext.kind != externFunction {
+					return 0, fmt.Errorf("%w: import %s.%s is not a function", ErrModule, imp.ModuleName, imp.FieldName)
+				}
+
+				r.funcs[[2]string{imp.ModuleName, imp.FieldName}] = supervisorFunc{
+					index: ext.index,
+					sig:   &module.Types.Entries[ty.Type],
+				}
+			case wasm.MemoryImport:
+				if ext.kind != externMemory {
+					return 0, fmt.Errorf("%w: import %s.%s is not a memory", ErrModule, imp.ModuleName, imp.FieldName)
+				}
+
+				mem, err = s.getMemory(ext.index)
+				if err != nil {
+					return 0, fmt.Errorf("%w: %s", ErrModule, err)
+				}
+
+				if err = mem.canImport(ty.Type.Limits); err != nil {
+					return 0, fmt.Errorf("%w: cannot import memory %s.%s: %s", ErrModule, imp.ModuleName, imp.FieldName, err)
+				}
+			default:
+				return 0, fmt.Errorf("%w: unsupported import %s.%s", ErrModule, imp.ModuleName, imp.FieldName)
+			}
+		}
+	}
+
+	if mem != nil {
+		// the interpreter replaces an imported memory by a memory of its own, so size it like the sandbox memory
+		module.Memory.Entries[0].Limits.Initial = uint32(len(mem.data) / pageSize)
+		if mem.maximum != noMaximum {
+			mod.Config.MaxMemoryPages = int(mem.maximum)
+		}
+	} else if module.Memory != nil && len(module.Memory.Entries) > 0 && module.Memory.Entries[0].Limits.Flags&1 == 1 {
+		mod.Config.MaxMemoryPages = int(module.Memory.Entries[0].Limits.Maximum)
+	}
+
+	inst.vm, err = newVirtualMachine(mod)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrModule, err)
+	}
+
+	if mem != nil {
+		if err = mem.bind(inst.vm); err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrModule, err)
+		}
+	}
+
+	if module.Start != nil {
+		if _, err = s.run(inst, int(module.Start.Index), nil, state); err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrExecution, err)
+		}
+	}
+
+	s.instances = append(s.instances, inst)
+	return uint32(len(s.instances) - 1), nil
+}
+
+// newVirtualMachine creates a new virtual machine for the module, recovering from the interpreter's panics
+func newVirtualMachine(mod *exec.Module) (vm *exec.VirtualMachine, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return mod.NewVirtualMachine(), nil
+}
+
+// canImport checks that the memory satisfies the limits of a memory import
+func (m *memory) canImport(limits wasm.ResizableLimits) error {
+	if m.vm != nil {
+		return errors.New("memory is already imported by another instance")
+	}
+
+	if uint32(len(m.data)/pageSize) < limits.Initial {
+		return errors.New("memory is smaller than the import's initial size")
+	}
+
+	if limits.Flags&1 == 1 && (m.maximum == noMaximum || m.maximum > limits.Maximum) {
+		return errors.New("memory maximum is larger than the import's maximum")
+	}
+	return nil
+}
+
+// bind makes the memory the linear memory of the given virtual machine, initialising it with the data segments of
+// the virtual machine's module
+func (m *memory) bind(vm *exec.VirtualMachine) error {
+	module := vm.Module.Base
+	if module.Data != nil {
+		for _, seg := range module.Data.Entries {
+			off, err := module.ExecInitExpr(seg.Offset)
+			if err != nil {
+				return err
+			}
+
+			offset, ok := off.(int32)
+			if !ok || int(uint32(offset))+len(seg.Data) > len(m.data) {
+				return errors.New("invalid data segment offset")
+			}
+
+			copy(m.data[uint32(offset):], seg.Data)
+		}
+	}
+
+	vm.Memory = m.data
+	m.data = nil
+	m.vm = vm
+	return nil
+}
+
+// callSupervisor calls the supervisor function with the given arguments, as stored by the interpreter, and returns its
+// result in the interpreter's representation
+func (s *Sandbox) callSupervisor(inst *instance, f supervisorFunc, locals []int64) (int64, error) {
+	args := make([]Value, len(f.sig.ParamTypes))
+	for i, ty := range f.sig.ParamTypes {
+		args[i] = valueFromInterpreter(ty, locals[i])
+	}
+
+	enc, err := EncodeValues(args)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.dispatcher.Dispatch(inst.thunk, f.index, inst.state, enc)
+	if err != nil {
+		return 0, err
+	}
+
+	ret, err := decodeHostResult(res)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case ret == nil && len(f.sig.ReturnTypes) == 0:
+		return 0, nil
+	case ret != nil && len(f.sig.ReturnTypes) == 1 && valueTypeOf(f.sig.ReturnTypes[0]) == ret.Type:
+		return int64(ret.Bits), nil
+	default:
+		return 0, errors.New("supervisor function returned an unexpected value")
+	}
+}
+
+// functionSig returns the signature of the function with the given index
+func functionSig(module *wasm.Module, idx int) (*wasm.FunctionSig, error) {
+	if module.Import != nil {
+		for _, imp := range module.Import.Entries {
+			ty, ok := imp.Type.(wasm.FuncImport)
+			if !ok {
+				continue
+			}
+
+			if idx == 0 {
+				return &module.Types.Entries[ty.Type], nil
+			}
+			idx--
+		}
+	}
+
+	f := module.GetFunction(idx)
+	if f == nil {
+		return nil, fmt.Errorf("invalid function index %d", idx)
+	}
+	return f.Sig, nil
+}
+
+// run calls the function with the given index in the instance
+func (s *Sandbox) run(inst *instance, idx int, args []Value, state uint32) (*Value, error) {
+	if inst.running {
+		return nil, errors.New("instance is already running")
+	}
+
+	sig, err := functionSig(inst.vm.Module.Base, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sig.ParamTypes) != len(args) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(sig.ParamTypes), len(args))
+	}
+
+	params := make([]int64, len(args))
+	for i, arg := range args {
+		if valueTypeOf(sig.ParamTypes[i]) != arg.Type {
+			return nil, fmt.Errorf("invalid type for argument %d", i)
+		}
+		params[i] = int64(arg.Bits)
+	}
+
+	// the interpreter refuses to run again after a trap unless its call stack is reset
+	inst.vm.CurrentFrame = -1
+	inst.vm.ExitError = nil
+
+	inst.state = state
+	inst.running = true
+	ret, err := inst.vm.Run(idx, params...)
+	inst.running = false
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sig.ReturnTypes) == 0 {
+		return nil, nil
+	}
+
+	v := valueFromInterpreter(sig.ReturnTypes[0], ret)
+	return &v, nil
+}
+
+// Invoke calls the exported function of the sandbox instance with the given arguments and returns its result, which
+// is nil if the function doesn't return a value. The state is passed to the supervisor functions called by the instance.
+func (s *Sandbox) Invoke(idx uint32, name string, args []Value, state uint32) (*Value, error) {
+	inst, err := s.getInstance(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, ok := inst.vm.GetFunctionExport(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot find exported function %s", ErrExecution, name)
+	}
+
+	ret, err := s.run(inst, fn, args, state)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExecution, err)
+	}
+	return ret, nil
+}
+
+// InstanceTeardown tears down the sandbox instance with the given index
+func (s *Sandbox) InstanceTeardown(idx uint32) error {
+	if _, err := s.getInstance(idx); err != nil {
+		return err
+	}
+
+	s.instances[idx] = nil
+	return nil
+}
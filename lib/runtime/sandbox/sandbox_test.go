@@ -0,0 +1,279 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sandbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDispatcher doubles its i32 argument and adds the state to it
+type mockDispatcher struct {
+	thunk, funcIdx uint32
+	fail           bool
+}
+
+func (d *mockDispatcher) Dispatch(thunk, funcIdx, state uint32, args []byte) ([]byte, error) {
+	d.thunk, d.funcIdx = thunk, funcIdx
+	if d.fail {
+		return nil, errors.New("dispatch failed")
+	}
+
+	vals, err := DecodeValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	res := NewI32(int32(vals[0].Bits)*2 + int32(state))
+	return append([]byte{0}, EncodeReturnValue(&res)...), nil
+}
+
+const testGuest = `(module
+	(import "env" "double" (func $double (param i32) (result i32)))
+	(import "env" "memory" (memory 1))
+	(func (export "call") (param i32) (result i32)
+		local.get 0
+		call $double
+		i32.const 1
+		i32.add)
+	(func (export "store") (param i32 i64)
+		local.get 0
+		local.get 1
+		i64.store)
+	(func (export "nothing"))
+	(func (export "trap")
+		unreachable))`
+
+func encodeEnvironment(entries ...[]byte) []byte {
+	enc := []byte{byte(len(entries) << 2)}
+	for _, e := range entries {
+		enc = append(enc, e...)
+	}
+	return enc
+}
+
+func encodeEntry(module, field string, kind externKind, idx uint32) []byte {
+	enc := append([]byte{byte(len(module) << 2)}, module...)
+	enc = append(append(enc, byte(len(field)<<2)), field...)
+	enc = append(enc, byte(kind))
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, idx)
+	return append(enc, buf...)
+}
+
+func newTestInstance(t *testing.T) (*Sandbox, *mockDispatcher, uint32, uint32) {
+	code, err := wasmtime.Wat2Wasm(testGuest)
+	require.NoError(t, err)
+
+	d := new(mockDispatcher)
+	s := NewSandbox(d)
+
+	mem, err := s.NewMemory(1, noMaximum)
+	require.NoError(t, err)
+
+	env := encodeEnvironment(
+		encodeEntry("env", "double", externFunction, 7),
+		encodeEntry("env", "memory", externMemory, mem),
+	)
+
+	idx, err := s.Instantiate(3, code, env, 0)
+	require.NoError(t, err)
+	return s, d, idx, mem
+}
+
+func TestErrorCodes(t *testing.T) {
+	// the codes are the two's complement of the sp_sandbox ERR_* constants
+	codes := []uint32{ErrCodeOK, ErrCodeModule, ErrCodeOutOfBounds, ErrCodeExecution}
+	expected := []int32{0, -1, -2, -3}
+	for i, code := range codes {
+		require.Equal(t, expected[i], int32(code))
+	}
+}
+
+func TestValues_EncodeDecode(t *testing.T) {
+	vals := []Value{NewI32(-1), NewI64(1 << 40), NewF32(1.5), NewF64(-2.25)}
+
+	enc, err := EncodeValues(vals)
+	require.NoError(t, err)
+	require.Equal(t, []byte{16, 0, 0xff, 0xff, 0xff, 0xff}, enc[:6])
+
+	res, err := DecodeValues(enc)
+	require.NoError(t, err)
+	require.Equal(t, vals, res)
+
+	_, err = DecodeValues([]byte{4, 9, 0, 0, 0, 0})
+	require.Error(t, err)
+
+	require.Equal(t, []byte{0}, EncodeReturnValue(nil))
+}
+
+func TestSandbox_Invoke(t *testing.T) {
+	s, d, idx, _ := newTestInstance(t)
+
+	res, err := s.Invoke(idx, "call", []Value{NewI32(5)}, 10)
+	require.NoError(t, err)
+	require.Equal(t, NewI32(21), *res)
+	require.Equal(t, uint32(3), d.thunk)
+	require.Equal(t, uint32(7), d.funcIdx)
+
+	res, err = s.Invoke(idx, "nothing", nil, 0)
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	_, err = s.Invoke(idx, "trap", nil, 0)
+	require.True(t, errors.Is(err, ErrExecution))
+
+	_, err = s.Invoke(idx, "missing", nil, 0)
+	require.True(t, errors.Is(err, ErrExecution))
+
+	_, err = s.Invoke(idx, "call", []Value{NewI64(5)}, 0)
+	require.True(t, errors.Is(err, ErrExecution))
+
+	d.fail = true
+	_, err = s.Invoke(idx, "call", []Value{NewI32(5)}, 0)
+	require.True(t, errors.Is(err, ErrExecution))
+
+	err = s.InstanceTeardown(idx)
+	require.NoError(t, err)
+
+	_, err = s.Invoke(idx, "nothing", nil, 0)
+	require.Equal(t, ErrInvalidInstance, err)
+}
+
+func TestSandbox_Memory(t *testing.T) {
+	s, _, idx, mem := newTestInstance(t)
+
+	_, err := s.Invoke(idx, "store", []Value{NewI32(8), NewI64(0x0102030405060708)}, 0)
+	require.NoError(t, err)
+
+	buf := make([]byte, 4)
+	err = s.MemoryGet(mem, 8, buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{8, 7, 6, 5}, buf)
+
+	err = s.MemorySet(mem, 9, []byte{0xff})
+	require.NoError(t, err)
+
+	err = s.MemoryGet(mem, 8, buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{8, 0xff, 6, 5}, buf)
+
+	err = s.MemoryGet(mem, 65535, buf)
+	require.Equal(t, ErrOutOfBounds, err)
+
+	err = s.MemorySet(mem, 65536, []byte{1})
+	require.Equal(t, ErrOutOfBounds, err)
+
+	err = s.MemoryTeardown(mem)
+	require.NoError(t, err)
+
+	err = s.MemoryGet(mem, 0, buf)
+	require.Equal(t, ErrInvalidMemory, err)
+
+	_, err = s.NewMemory(2, 1)
+	require.Error(t, err)
+}
+
+func TestSandbox_Instantiate_Errors(t *testing.T) {
+	code, err := wasmtime.Wat2Wasm(testGuest)
+	require.NoError(t, err)
+
+	s := NewSandbox(new(mockDispatcher))
+
+	// missing memory import
+	env := encodeEnvironment(encodeEntry("env", "double", externFunction, 0))
+	_, err = s.Instantiate(0, code, env, 0)
+	require.True(t, errors.Is(err, ErrModule))
+
+	// invalid environment definition
+	_, err = s.Instantiate(0, code, []byte{4, 0}, 0)
+	require.True(t, errors.Is(err, ErrModule))
+
+	// invalid code
+	_, err = s.Instantiate(0, []byte{1, 2, 3}, encodeEnvironment(), 0)
+	require.True(t, errors.Is(err, ErrModule))
+
+	// trapping start function
+	code, err = wasmtime.Wat2Wasm(`(module (func $start unreachable) (start $start))`)
+	require.NoError(t, err)
+	_, err = s.Instantiate(0, code, encodeEnvironment(), 0)
+	require.True(t, errors.Is(err, ErrExecution))
+}
+
+func TestSandbox_Reset(t *testing.T) {
+	s, _, idx, mem := newTestInstance(t)
+	s.Reset()
+
+	_, err := s.Invoke(idx, "nothing", nil, 0)
+	require.Equal(t, ErrInvalidInstance, err)
+
+	err = s.MemorySet(mem, 0, []byte{1})
+	require.Equal(t, ErrInvalidMemory, err)
+}
+
+func TestSandbox_MemoryImport(t *testing.T) {
+	code, err := wasmtime.Wat2Wasm(`(module
+		(import "env" "memory" (memory 1 3))
+		(data (i32.const 16) "\01\02")
+		(func (export "grow") (result i32)
+			i32.const 1
+			memory.grow))`)
+	require.NoError(t, err)
+
+	s := NewSandbox(new(mockDispatcher))
+
+	// the memory's maximum must not exceed the import's maximum
+	mem, err := s.NewMemory(1, noMaximum)
+	require.NoError(t, err)
+	_, err = s.Instantiate(0, code, encodeEnvironment(encodeEntry("env", "memory", externMemory, mem)), 0)
+	require.True(t, errors.Is(err, ErrModule))
+
+	mem, err = s.NewMemory(1, 2)
+	require.NoError(t, err)
+
+	err = s.MemorySet(mem, 15, []byte{9, 9, 9, 9})
+	require.NoError(t, err)
+
+	idx, err := s.Instantiate(0, code, encodeEnvironment(encodeEntry("env", "memory", externMemory, mem)), 0)
+	require.NoError(t, err)
+
+	// data segments are written over the existing contents of the memory
+	buf := make([]byte, 4)
+	err = s.MemoryGet(mem, 15, buf)
+	require.NoError(t, err)
+	require.Equal(t, []byte{9, 1, 2, 9}, buf)
+
+	res, err := s.Invoke(idx, "grow", nil, 0)
+	require.NoError(t, err)
+	require.Equal(t, NewI32(1), *res)
+
+	err = s.MemorySet(mem, 2*65536-1, []byte{1})
+	require.NoError(t, err)
+
+	// the memory cannot grow past its maximum
+	res, err = s.Invoke(idx, "grow", nil, 0)
+	require.NoError(t, err)
+	require.Equal(t, NewI32(-1), *res)
+
+	// a memory can only be imported by one instance
+	_, err = s.Instantiate(0, code, encodeEnvironment(encodeEntry("env", "memory", externMemory, mem)), 0)
+	require.True(t, errors.Is(err, ErrModule))
+}
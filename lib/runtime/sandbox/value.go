@@ -0,0 +1,199 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package sandbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/ChainSafe/gossamer/lib/scale"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ValueType is the type of a value passed between the supervisor and a sandboxed instance
+type ValueType byte
+
+// ValueType variants, in the order of the sp_wasm_interface::Value enum
+const (
+	I32 ValueType = iota
+	I64
+	F32
+	F64
+)
+
+// Value is a typed wasm value. Floats are stored as their IEEE 754 bit pattern, like the runtime does.
+type Value struct {
+	Type ValueType
+	Bits uint64
+}
+
+// NewI32 returns a new i32 Value
+func NewI32(v int32) Value {
+	return Value{Type: I32, Bits: uint64(uint32(v))}
+}
+
+// NewI64 returns a new i64 Value
+func NewI64(v int64) Value {
+	return Value{Type: I64, Bits: uint64(v)}
+}
+
+// NewF32 returns a new f32 Value
+func NewF32(v float32) Value {
+	return Value{Type: F32, Bits: uint64(math.Float32bits(v))}
+}
+
+// NewF64 returns a new f64 Value
+func NewF64(v float64) Value {
+	return Value{Type: F64, Bits: math.Float64bits(v)}
+}
+
+// Encode SCALE encodes the Value
+func (v Value) Encode() []byte {
+	if v.Type == I32 || v.Type == F32 {
+		enc := make([]byte, 5)
+		enc[0] = byte(v.Type)
+		binary.LittleEndian.PutUint32(enc[1:], uint32(v.Bits))
+		return enc
+	}
+
+	enc := make([]byte, 9)
+	enc[0] = byte(v.Type)
+	binary.LittleEndian.PutUint64(enc[1:], v.Bits)
+	return enc
+}
+
+// decodeValue decodes a SCALE encoded Value from the given reader
+func decodeValue(r io.Reader) (Value, error) {
+	var ty [1]byte
+	if _, err := io.ReadFull(r, ty[:]); err != nil {
+		return Value{}, err
+	}
+
+	switch ValueType(ty[0]) {
+	case I32, F32:
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return Value{}, err
+		}
+		return Value{Type: ValueType(ty[0]), Bits: uint64(binary.LittleEndian.Uint32(buf))}, nil
+	case I64, F64:
+		buf := make([]byte, 8)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return Value{}, err
+		}
+		return Value{Type: ValueType(ty[0]), Bits: binary.LittleEndian.Uint64(buf)}, nil
+	default:
+		return Value{}, fmt.Errorf("invalid value type: %d", ty[0])
+	}
+}
+
+// EncodeValues SCALE encodes the given values as a Vec<Value>
+func EncodeValues(vals []Value) ([]byte, error) {
+	enc, err := scale.Encode(big.NewInt(int64(len(vals))))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range vals {
+		enc = append(enc, v.Encode()...)
+	}
+	return enc, nil
+}
+
+// DecodeValues decodes a SCALE encoded Vec<Value>
+func DecodeValues(in []byte) ([]Value, error) {
+	r := bytes.NewReader(in)
+	sd := &scale.Decoder{Reader: r}
+
+	n, err := sd.DecodeInteger()
+	if err != nil {
+		return nil, err
+	}
+
+	vals := []Value{}
+	for i := int64(0); i < n; i++ {
+		v, err := decodeValue(r)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+
+	if r.Len() != 0 {
+		return nil, errors.New("trailing bytes after values")
+	}
+	return vals, nil
+}
+
+// EncodeReturnValue SCALE encodes the given value as a ReturnValue, where nil is ReturnValue::Unit
+func EncodeReturnValue(v *Value) []byte {
+	if v == nil {
+		return []byte{0}
+	}
+	return append([]byte{1}, v.Encode()...)
+}
+
+// decodeHostResult decodes the SCALE encoded Result<ReturnValue, HostError> returned by the dispatch thunk
+func decodeHostResult(in []byte) (*Value, error) {
+	if len(in) < 2 {
+		return nil, errors.New("invalid supervisor function result")
+	}
+
+	if in[0] != 0 {
+		return nil, errors.New("supervisor function returned an error")
+	}
+
+	if in[1] == 0 {
+		return nil, nil
+	}
+
+	r := bytes.NewReader(in[2:])
+	v, err := decodeValue(r)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// valueTypeOf returns the ValueType of the given wasm value type
+func valueTypeOf(t wasm.ValueType) ValueType {
+	switch t {
+	case wasm.ValueTypeI32:
+		return I32
+	case wasm.ValueTypeI64:
+		return I64
+	case wasm.ValueTypeF32:
+		return F32
+	default:
+		return F64
+	}
+}
+
+// valueFromInterpreter converts a value of the given wasm type, as stored by the interpreter, into a Value
+func valueFromInterpreter(t wasm.ValueType, v int64) Value {
+	ty := valueTypeOf(t)
+	if ty == I32 || ty == F32 {
+		return Value{Type: ty, Bits: uint64(uint32(v))}
+	}
+	return Value{Type: ty, Bits: uint64(v)}
+}
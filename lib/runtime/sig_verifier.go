@@ -2,8 +2,8 @@ package runtime
 
 import (
 	"fmt"
+	goruntime "runtime"
 	"sync"
-	"time"
 
 	"github.com/ChainSafe/gossamer/lib/crypto"
 	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
@@ -12,7 +12,14 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 )
 
-// Signature ...
+// sigBatchBufferSize is the number of signatures that can be added to a batch before Add blocks on the workers
+const sigBatchBufferSize = 256
+
+// Sr25519DeprecatedType is the key type of the sr25519 signatures added to a batch by
+// ext_crypto_sr25519_verify_version_1, which are verified with VerifyDeprecated
+const Sr25519DeprecatedType crypto.KeyType = "sr25519_deprecated"
+
+// Signature is a signature to be verified in a batch
 type Signature struct {
 	PubKey    []byte
 	Sign      []byte
@@ -20,133 +27,123 @@ type Signature struct {
 	KeyTypeID crypto.KeyType
 }
 
-// SignatureVerifier ...
+// SignatureVerifier verifies batches of signatures in background worker goroutines.
+// Start() is called to start a batch, Add() to add signatures to it, and Finish() to wait for the batch to be
+// verified. Add and Finish must not be called concurrently.
 type SignatureVerifier struct {
-	batch   []*Signature
-	init    bool // Indicates whether the batch processing is started.
+	lock    sync.RWMutex
+	started bool // Indicates whether the batch processing is started.
 	invalid bool // Set to true if any signature verification fails.
-	closeCh chan struct{}
-	sync.RWMutex
-	sync.Once
-	sync.WaitGroup
+	batch   chan *Signature
+	wg      sync.WaitGroup
+	workers int
 }
 
 // NewSignatureVerifier initialises SignatureVerifier which does background verification of signatures.
-// Start() is called to start the verification process.
-// Finish() is called to stop the verification process.
-// Signatures can be added to the batch using Add().
 func NewSignatureVerifier() *SignatureVerifier {
 	return &SignatureVerifier{
-		batch:   make([]*Signature, 0),
-		init:    false,
-		invalid: false,
-		RWMutex: sync.RWMutex{},
-		closeCh: make(chan struct{}),
+		workers: goruntime.NumCPU(),
 	}
 }
 
 // Start signature verification in batch.
 func (sv *SignatureVerifier) Start() {
-	// Update the init state.
-	sv.Lock()
-	sv.init = true
-	sv.Unlock()
-
-	sv.WaitGroup.Add(1)
-
-	go func() {
-		defer sv.Done()
-		for {
-			select {
-			case <-sv.closeCh:
-				return
-			default:
-				sign := sv.Remove()
-				if sign == nil {
-					continue
-				}
-				err := sign.verify()
-				if err != nil {
-					log.Error("[ext_crypto_start_batch_verify_version_1]", "error", err)
-					sv.Invalid()
-					return
-				}
-			}
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+
+	if sv.started {
+		return
+	}
+
+	sv.started = true
+	sv.invalid = false
+	sv.batch = make(chan *Signature, sigBatchBufferSize)
+
+	sv.wg.Add(sv.workers)
+	for i := 0; i < sv.workers; i++ {
+		go sv.verify(sv.batch)
+	}
+}
+
+// verify verifies the signatures in the batch until it's closed
+func (sv *SignatureVerifier) verify(batch <-chan *Signature) {
+	defer sv.wg.Done()
+
+	for sig := range batch {
+		// once a signature is invalid the whole batch is, so the remaining signatures are skipped
+		if sv.IsInvalid() {
+			continue
 		}
-	}()
+
+		if err := sig.verify(); err != nil {
+			log.Debug("[ext_crypto_finish_batch_verify_version_1] invalid signature in batch", "error", err)
+			sv.Invalid()
+		}
+	}
 }
 
-// IsStarted ...
+// IsStarted returns true if a batch has been started and not finished yet
 func (sv *SignatureVerifier) IsStarted() bool {
-	sv.RLock()
-	defer sv.RUnlock()
-	return sv.init
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.started
 }
 
-// IsInvalid ...
+// IsInvalid returns true if any of the signatures verified in the current batch is invalid
 func (sv *SignatureVerifier) IsInvalid() bool {
-	sv.RLock()
-	defer sv.RUnlock()
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
 	return sv.invalid
 }
 
-// Invalid ...
+// Invalid marks the current batch as invalid
 func (sv *SignatureVerifier) Invalid() {
-	sv.RLock()
-	defer sv.RUnlock()
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
 	sv.invalid = true
 }
 
-// Add ...
+// Add adds a signature to the current batch. The signature is ignored if no batch is started.
 func (sv *SignatureVerifier) Add(s *Signature) {
-	if sv.IsInvalid() {
+	sv.lock.RLock()
+	batch := sv.batch
+	skip := !sv.started || sv.invalid
+	sv.lock.RUnlock()
+
+	if skip {
 		return
 	}
 
-	sv.Lock()
-	defer sv.Unlock()
-	sv.batch = append(sv.batch, s)
-}
-
-// Remove returns the first signature from the batch. Returns nil if batch is empty.
-func (sv *SignatureVerifier) Remove() *Signature {
-	sv.Lock()
-	defer sv.Unlock()
-	if len(sv.batch) == 0 {
-		return nil
-	}
-	sign := sv.batch[0]
-	sv.batch = sv.batch[1:len(sv.batch)]
-	return sign
+	batch <- s
 }
 
-// Reset reset the signature verifier for reuse.
+// Reset stops the current batch, if any, discarding its result.
 func (sv *SignatureVerifier) Reset() {
-	sv.Lock()
-	defer sv.Unlock()
-	sv.init = false
-	sv.batch = make([]*Signature, 0)
-	sv.invalid = false
-	sv.closeCh = make(chan struct{})
+	sv.Finish()
 }
 
 // Finish waits till batch is finished. Returns true if all the signatures are valid, Otherwise returns false.
 func (sv *SignatureVerifier) Finish() bool {
-	for {
-		time.Sleep(100 * time.Millisecond)
-		sv.Lock()
-		if sv.invalid || len(sv.batch) == 0 {
-			close(sv.closeCh)
-			sv.Unlock()
-			break
-		}
-		sv.RUnlock()
+	sv.lock.Lock()
+	batch := sv.batch
+	started := sv.started
+	sv.started = false
+	sv.batch = nil
+	sv.lock.Unlock()
+
+	if !started {
+		return true
 	}
-	// Wait till start function to finish and then reset it.
-	sv.Wait()
-	isInvalid := sv.IsInvalid()
-	sv.Reset()
-	return !isInvalid
+
+	// wait for the workers to verify the remaining signatures
+	close(batch)
+	sv.wg.Wait()
+
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+	valid := !sv.invalid
+	sv.invalid = false
+	return valid
 }
 
 func (sig *Signature) verify() error {
@@ -169,6 +166,15 @@ func (sig *Signature) verify() error {
 		if err != nil || !ok {
 			return fmt.Errorf("failed to verify sr25519 signature: %s", err)
 		}
+	case Sr25519DeprecatedType:
+		pubKey, err := sr25519.NewPublicKey(sig.PubKey)
+		if err != nil {
+			return fmt.Errorf("failed to fetch sr25519 public key: %s", err)
+		}
+		ok, err := pubKey.VerifyDeprecated(sig.Msg, sig.Sign)
+		if err != nil || !ok {
+			return fmt.Errorf("failed to verify deprecated sr25519 signature: %s", err)
+		}
 	case crypto.Secp256k1Type:
 		ok := secp256k1.VerifySignature(sig.PubKey, sig.Msg, sig.Sign)
 		if !ok {
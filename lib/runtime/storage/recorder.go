@@ -0,0 +1,101 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ChainSafe/gossamer/lib/trie"
+)
+
+// TrieStateRecorder wraps a TrieState and records every key read from the main trie during a runtime call,
+// so that a proof of the storage accessed by the call can be generated afterwards.
+// Reads from a child trie are recorded as reads of the child trie's key in the main trie.
+type TrieStateRecorder struct {
+	*TrieState
+	keys     map[string]struct{}
+	keysLock sync.Mutex
+}
+
+// NewTrieStateRecorder returns a new TrieStateRecorder wrapping the given TrieState
+func NewTrieStateRecorder(ts *TrieState) *TrieStateRecorder {
+	return &TrieStateRecorder{
+		TrieState: ts,
+		keys:      make(map[string]struct{}),
+	}
+}
+
+func (r *TrieStateRecorder) record(key []byte) {
+	r.keysLock.Lock()
+	defer r.keysLock.Unlock()
+	r.keys[string(key)] = struct{}{}
+}
+
+func (r *TrieStateRecorder) recordChild(keyToChild []byte) {
+	r.record(append(append([]byte{}, trie.ChildStorageKeyPrefix...), keyToChild...))
+}
+
+// Keys returns the keys read so far in lexicographical order
+func (r *TrieStateRecorder) Keys() [][]byte {
+	r.keysLock.Lock()
+	defer r.keysLock.Unlock()
+
+	keys := make([][]byte, 0, len(r.keys))
+	for k := range r.keys {
+		keys = append(keys, []byte(k))
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+// Get gets a value from the trie and records the key
+func (r *TrieStateRecorder) Get(key []byte) []byte {
+	r.record(key)
+	return r.TrieState.Get(key)
+}
+
+// NextKey returns the next key in the trie in lexicographical order and records it
+func (r *TrieStateRecorder) NextKey(key []byte) []byte {
+	next := r.TrieState.NextKey(key)
+	if next != nil {
+		r.record(next)
+	}
+	return next
+}
+
+// GetChild returns the child trie at the given key and records the key
+func (r *TrieStateRecorder) GetChild(keyToChild []byte) (*trie.Trie, error) {
+	r.recordChild(keyToChild)
+	return r.TrieState.GetChild(keyToChild)
+}
+
+// GetChildStorage returns a value from a child trie and records the child trie's key
+func (r *TrieStateRecorder) GetChildStorage(keyToChild, key []byte) ([]byte, error) {
+	r.recordChild(keyToChild)
+	return r.TrieState.GetChildStorage(keyToChild, key)
+}
+
+// GetChildNextKey returns the next key from child storage and records the child trie's key
+func (r *TrieStateRecorder) GetChildNextKey(keyToChild, key []byte) ([]byte, error) {
+	r.recordChild(keyToChild)
+	return r.TrieState.GetChildNextKey(keyToChild, key)
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/trie"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrieStateRecorder(t *testing.T) {
+	ts := newTestTrieState(t)
+	for _, tc := range testCases {
+		ts.Set([]byte(tc), []byte(tc))
+	}
+
+	err := ts.SetChild([]byte("child"), trie.NewEmptyTrie())
+	require.NoError(t, err)
+
+	rec := NewTrieStateRecorder(ts)
+	require.Equal(t, []byte("asdf"), rec.Get([]byte("asdf")))
+	require.Nil(t, rec.Get([]byte("noot")))
+	require.Equal(t, []byte("qwerty"), rec.NextKey([]byte("p")))
+
+	// writes are not recorded
+	rec.Set([]byte("zxcv"), []byte("washere"))
+
+	_, err = rec.GetChildStorage([]byte("child"), []byte("key"))
+	require.NoError(t, err)
+
+	expected := [][]byte{
+		[]byte(":child_storage:default:child"),
+		[]byte("asdf"),
+		[]byte("noot"),
+		[]byte("qwerty"),
+	}
+	require.Equal(t, expected, rec.Keys())
+}
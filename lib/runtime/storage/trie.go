@@ -18,6 +18,7 @@ package storage
 
 import (
 	"encoding/binary"
+	"sort"
 	"sync"
 
 	"github.com/ChainSafe/gossamer/lib/common"
@@ -30,6 +31,15 @@ type TrieState struct {
 	t       *trie.Trie
 	oldTrie *trie.Trie // this is the trie before BeginStorageTransaction is called. set to nil if it isn't called
 	lock    sync.RWMutex
+
+	// keys that have been modified since the TrieState was created
+	changes    map[string]struct{}
+	oldChanges map[string]struct{} // changes before BeginStorageTransaction is called
+
+	// offchain indexing writes made since the TrieState was created. they are committed to the offchain database
+	// when the TrieState is stored, and are discarded along with the TrieState otherwise
+	offchainIndex    map[string][]byte
+	oldOffchainIndex map[string][]byte // offchain indexing writes before BeginStorageTransaction is called
 }
 
 // NewTrieState returns a new TrieState with the given trie
@@ -39,7 +49,9 @@ func NewTrieState(t *trie.Trie) (*TrieState, error) {
 	}
 
 	ts := &TrieState{
-		t: t,
+		t:             t,
+		changes:       make(map[string]struct{}),
+		offchainIndex: make(map[string][]byte),
 	}
 
 	return ts, nil
@@ -63,6 +75,16 @@ func (s *TrieState) BeginStorageTransaction() {
 	defer s.lock.Unlock()
 	s.oldTrie = s.t
 	s.t = s.t.Snapshot()
+
+	s.oldChanges = make(map[string]struct{}, len(s.changes))
+	for k := range s.changes {
+		s.oldChanges[k] = struct{}{}
+	}
+
+	s.oldOffchainIndex = make(map[string][]byte, len(s.offchainIndex))
+	for k, v := range s.offchainIndex {
+		s.oldOffchainIndex[k] = v
+	}
 }
 
 // CommitStorageTransaction commits all storage changes made since BeginStorageTransaction was called.
@@ -70,6 +92,8 @@ func (s *TrieState) CommitStorageTransaction() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.oldTrie = nil
+	s.oldChanges = nil
+	s.oldOffchainIndex = nil
 }
 
 // RollbackStorageTransaction rolls back all storage changes made since BeginStorageTransaction was called.
@@ -78,6 +102,56 @@ func (s *TrieState) RollbackStorageTransaction() {
 	defer s.lock.Unlock()
 	s.t = s.oldTrie
 	s.oldTrie = nil
+	s.changes = s.oldChanges
+	s.oldChanges = nil
+	s.offchainIndex = s.oldOffchainIndex
+	s.oldOffchainIndex = nil
+}
+
+// ChangedKeys returns the keys that have been modified since the TrieState was created, in lexicographical order.
+// Modifications to a child trie are reported as a change to the child trie's key in the main trie.
+func (s *TrieState) ChangedKeys() [][]byte {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	keys := make([][]byte, 0, len(s.changes))
+	for k := range s.changes {
+		keys = append(keys, []byte(k))
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return string(keys[i]) < string(keys[j])
+	})
+	return keys
+}
+
+// SetOffchainIndex buffers a write of the given key-value pair to the offchain database
+func (s *TrieState) SetOffchainIndex(key, value []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.offchainIndex[string(key)] = append([]byte{}, value...)
+}
+
+// OffchainIndex returns the offchain database writes made since the TrieState was created
+func (s *TrieState) OffchainIndex() map[string][]byte {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	index := make(map[string][]byte, len(s.offchainIndex))
+	for k, v := range s.offchainIndex {
+		index[k] = v
+	}
+	return index
+}
+
+// recordChange marks the given key as modified. It must be called with the lock held.
+func (s *TrieState) recordChange(key []byte) {
+	s.changes[string(key)] = struct{}{}
+}
+
+// recordChildChange marks the child trie at keyToChild as modified. It must be called with the lock held.
+func (s *TrieState) recordChildChange(keyToChild []byte) {
+	s.recordChange(append(append([]byte{}, trie.ChildStorageKeyPrefix...), keyToChild...))
 }
 
 // Set sets a key-value pair in the trie
@@ -85,6 +159,7 @@ func (s *TrieState) Set(key, value []byte) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.t.Put(key, value)
+	s.recordChange(key)
 }
 
 // Get gets a value from the trie
@@ -119,6 +194,7 @@ func (s *TrieState) Delete(key []byte) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.t.Delete(key)
+	s.recordChange(key)
 }
 
 // NextKey returns the next key in the trie in lexicographical order. If it does not exist, it returns nil.
@@ -132,6 +208,9 @@ func (s *TrieState) NextKey(key []byte) []byte {
 func (s *TrieState) ClearPrefix(prefix []byte) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	for _, key := range s.t.GetKeysWithPrefix(prefix) {
+		s.recordChange(key)
+	}
 	s.t.ClearPrefix(prefix)
 	return nil
 }
@@ -147,14 +226,24 @@ func (s *TrieState) TrieEntries() map[string][]byte {
 func (s *TrieState) SetChild(keyToChild []byte, child *trie.Trie) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.PutChild(keyToChild, child)
+	if err := s.t.PutChild(keyToChild, child); err != nil {
+		return err
+	}
+
+	s.recordChildChange(keyToChild)
+	return nil
 }
 
 // SetChildStorage sets a key-value pair in a child trie
 func (s *TrieState) SetChildStorage(keyToChild, key, value []byte) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.PutIntoChild(keyToChild, key, value)
+	if err := s.t.PutIntoChild(keyToChild, key, value); err != nil {
+		return err
+	}
+
+	s.recordChildChange(keyToChild)
+	return nil
 }
 
 // GetChild returns the child trie at the given key
@@ -176,13 +265,19 @@ func (s *TrieState) DeleteChild(key []byte) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.t.DeleteChild(key)
+	s.recordChildChange(key)
 }
 
 // ClearChildStorage removes the child storage entry from the trie
 func (s *TrieState) ClearChildStorage(keyToChild, key []byte) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.t.ClearFromChild(keyToChild, key)
+	if err := s.t.ClearFromChild(keyToChild, key); err != nil {
+		return err
+	}
+
+	s.recordChildChange(keyToChild)
+	return nil
 }
 
 // ClearPrefixInChild clears all the keys from the child trie that have the given prefix
@@ -198,6 +293,7 @@ func (s *TrieState) ClearPrefixInChild(keyToChild, prefix []byte) error {
 		return nil
 	}
 
+	s.recordChildChange(keyToChild)
 	child.ClearPrefix(prefix)
 	return nil
 }
@@ -197,3 +197,60 @@ func TestTrieState_RollbackStorageTransaction(t *testing.T) {
 	val := ts.Get([]byte(testCases[0]))
 	require.Equal(t, []byte(testCases[0]), val)
 }
+
+func TestTrieState_OffchainIndex(t *testing.T) {
+	ts := newTestTrieState(t)
+	require.Empty(t, ts.OffchainIndex())
+
+	ts.SetOffchainIndex([]byte("noot"), []byte("was here"))
+
+	// writes made in a rolled back transaction are discarded
+	ts.BeginStorageTransaction()
+	ts.SetOffchainIndex([]byte("noot"), []byte("is gone"))
+	ts.SetOffchainIndex([]byte("asdf"), []byte("ghjk"))
+	ts.RollbackStorageTransaction()
+	require.Equal(t, map[string][]byte{"noot": []byte("was here")}, ts.OffchainIndex())
+
+	ts.BeginStorageTransaction()
+	ts.SetOffchainIndex([]byte("asdf"), []byte("ghjk"))
+	ts.CommitStorageTransaction()
+	require.Equal(t, map[string][]byte{"noot": []byte("was here"), "asdf": []byte("ghjk")}, ts.OffchainIndex())
+}
+
+func TestTrieState_ChangedKeys(t *testing.T) {
+	tr := trie.NewEmptyTrie()
+	tr.Put([]byte("noot"), []byte("was here"))
+	tr.Put([]byte("prefix1"), []byte("a"))
+	tr.Put([]byte("prefix2"), []byte("b"))
+	err := tr.PutChild([]byte("child"), trie.NewEmptyTrie())
+	require.NoError(t, err)
+
+	ts, err := NewTrieState(tr)
+	require.NoError(t, err)
+	require.Empty(t, ts.ChangedKeys())
+
+	err = ts.SetChildStorage([]byte("nochild"), []byte("key"), []byte("value"))
+	require.Error(t, err)
+
+	ts.Set([]byte("asdf"), []byte("ghjk"))
+	ts.Delete([]byte("noot"))
+	err = ts.ClearPrefix([]byte("prefix"))
+	require.NoError(t, err)
+	err = ts.SetChildStorage([]byte("child"), []byte("key"), []byte("value"))
+	require.NoError(t, err)
+
+	childKey := append(append([]byte{}, trie.ChildStorageKeyPrefix...), []byte("child")...)
+	expected := [][]byte{childKey, []byte("asdf"), []byte("noot"), []byte("prefix1"), []byte("prefix2")}
+	require.Equal(t, expected, ts.ChangedKeys())
+
+	// changes made in a rolled back transaction are discarded
+	ts.BeginStorageTransaction()
+	ts.Set([]byte("qwerty"), []byte("uiop"))
+	ts.RollbackStorageTransaction()
+	require.Equal(t, expected, ts.ChangedKeys())
+
+	ts.BeginStorageTransaction()
+	ts.Set([]byte("qwerty"), []byte("uiop"))
+	ts.CommitStorageTransaction()
+	require.Len(t, ts.ChangedKeys(), len(expected)+1)
+}
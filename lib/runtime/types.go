@@ -17,7 +17,11 @@
 package runtime
 
 import (
+	"net/http"
+
 	"github.com/ChainSafe/gossamer/lib/keystore"
+	"github.com/ChainSafe/gossamer/lib/runtime/offchain"
+	"github.com/ChainSafe/gossamer/lib/runtime/sandbox"
 	log "github.com/ChainSafe/log15"
 )
 
@@ -45,18 +49,25 @@ type InstanceConfig struct {
 	NodeStorage NodeStorage
 	Network     BasicNetwork
 	Transaction TransactionState
+	// OffchainIndexing enables writes to the offchain database made with ext_offchain_index_set during block execution
+	OffchainIndexing bool
+	// HTTPTransport is used for the offchain worker's HTTP requests, defaults to http.DefaultTransport if nil
+	HTTPTransport http.RoundTripper
 }
 
 // Context is the context for the wasm interpreter's imported functions
 type Context struct {
-	Storage     Storage
-	Allocator   *FreeingBumpHeapAllocator
-	Keystore    *keystore.GlobalKeystore
-	Validator   bool
-	NodeStorage NodeStorage
-	Network     BasicNetwork
-	Transaction TransactionState
-	SigVerifier *SignatureVerifier
+	Storage          Storage
+	Allocator        *FreeingBumpHeapAllocator
+	Keystore         *keystore.GlobalKeystore
+	Validator        bool
+	NodeStorage      NodeStorage
+	Network          BasicNetwork
+	Transaction      TransactionState
+	SigVerifier      *SignatureVerifier
+	Sandbox          *sandbox.Sandbox
+	OffchainHTTP     *offchain.HTTPSet
+	OffchainIndexing bool
 }
 
 // NewValidateTransactionError returns an error based on a return value from TaggedTransactionQueueValidateTransaction
@@ -130,3 +130,75 @@ func TestAllCryptoTypeSignature(t *testing.T) {
 
 	require.True(t, signVerify.Finish())
 }
+
+func TestSignatureBatch_Sr25519Deprecated(t *testing.T) {
+	msg := []byte("sr25519")
+	kp, err := sr25519.GenerateKeypair()
+	require.NoError(t, err)
+
+	sig, err := kp.Private().Sign(msg)
+	require.NoError(t, err)
+
+	// without the schnorrkel marker, only the deprecated verification accepts the signature
+	sig[63] &= 0x7f
+
+	signVerify := NewSignatureVerifier()
+	for _, keyType := range []crypto.KeyType{crypto.Sr25519Type, Sr25519DeprecatedType} {
+		signVerify.Start()
+		signVerify.Add(&Signature{
+			PubKey:    kp.Public().Encode(),
+			Sign:      sig,
+			Msg:       msg,
+			KeyTypeID: keyType,
+		})
+		require.Equal(t, keyType == Sr25519DeprecatedType, signVerify.Finish(), keyType)
+	}
+}
+
+func TestSignatureBatch_Large(t *testing.T) {
+	signs := generateEd25519Signatures(t, sigBatchBufferSize*2)
+	signVerify := NewSignatureVerifier()
+
+	signVerify.Start()
+	for _, sig := range signs {
+		signVerify.Add(sig)
+	}
+	require.True(t, signVerify.Finish())
+
+	// an invalid signature at the end of the batch fails the whole batch
+	invalid := *signs[0]
+	invalid.Msg = []byte("not signed")
+
+	signVerify.Start()
+	for _, sig := range signs {
+		signVerify.Add(sig)
+	}
+	signVerify.Add(&invalid)
+	require.False(t, signVerify.Finish())
+}
+
+func TestSignatureBatch_NotStarted(t *testing.T) {
+	signVerify := NewSignatureVerifier()
+	require.True(t, signVerify.Finish())
+
+	// signatures added outside of a batch are ignored
+	signVerify.Add(&Signature{KeyTypeID: crypto.Ed25519Type})
+	require.True(t, signVerify.Finish())
+}
+
+func TestSignatureBatch_Reset(t *testing.T) {
+	signs := generateEd25519Signatures(t, 1)
+	invalid := *signs[0]
+	invalid.Msg = []byte("not signed")
+
+	signVerify := NewSignatureVerifier()
+	signVerify.Start()
+	signVerify.Add(&invalid)
+	signVerify.Reset()
+	require.False(t, signVerify.IsStarted())
+	require.False(t, signVerify.IsInvalid())
+
+	signVerify.Start()
+	signVerify.Add(signs[0])
+	require.True(t, signVerify.Finish())
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/crypto/ed25519"
+	"github.com/ChainSafe/gossamer/lib/crypto/sr25519"
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/stretchr/testify/require"
+)
+
+// testBatchVerifyRuntime is a runtime that verifies an ed25519 and an sr25519 signature over the same message.
+// "batch" verifies both in a batch and stores the result of finishing the batch at offset 2000, "single" verifies
+// the sr25519 signature outside of a batch and stores the result at offset 2000, and "finish" stores the result of
+// finishing a batch that was never started at offset 2000.
+const testBatchVerifyRuntime = `(module
+	(import "env" "memory" (memory 20))
+	(import "env" "ext_crypto_start_batch_verify_version_1" (func $start))
+	(import "env" "ext_crypto_finish_batch_verify_version_1" (func $finish (result i32)))
+	(import "env" "ext_crypto_ed25519_verify_version_1" (func $ed25519_verify (param i32 i64 i32) (result i32)))
+	(import "env" "ext_crypto_sr25519_verify_version_1" (func $sr25519_verify (param i32 i64 i32) (result i32)))
+	(data (i32.const 1024) "%s")
+	(data (i32.const 1100) "%s")
+	(data (i32.const 1200) "%s")
+	(data (i32.const 1300) "%s")
+	(data (i32.const 1400) "%s")
+	(func (export "batch") (param i32 i32) (result i64)
+		(call $start)
+		(drop (call $ed25519_verify (i32.const 1100) (i64.const %[6]d) (i32.const 1200)))
+		(drop (call $sr25519_verify (i32.const 1300) (i64.const %[6]d) (i32.const 1400)))
+		(i32.store (i32.const 2000) (call $finish))
+		(i64.const %[7]d))
+	(func (export "single") (param i32 i32) (result i64)
+		(i32.store (i32.const 2000) (call $sr25519_verify (i32.const 1300) (i64.const %[6]d) (i32.const 1400)))
+		(i64.const %[7]d))
+	(func (export "finish") (param i32 i32) (result i64)
+		(i32.store (i32.const 2000) (call $finish))
+		(i64.const %[7]d)))`
+
+// watBytes escapes the given bytes for use in a WAT data segment
+func watBytes(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		fmt.Fprintf(&sb, "\\%02x", c)
+	}
+	return sb.String()
+}
+
+func newBatchVerifyInstance(t *testing.T, edSig, srSig []byte, edPub, srPub []byte, msg []byte) *Instance {
+	wat := fmt.Sprintf(testBatchVerifyRuntime, watBytes(msg), watBytes(edSig), watBytes(edPub), watBytes(srSig),
+		watBytes(srPub), pointerAndSizeToInt64(1024, int32(len(msg))), pointerAndSizeToInt64(2000, 4))
+	code, err := wasmtime.Wat2Wasm(wat)
+	require.NoError(t, err)
+
+	s, err := storage.NewTrieState(nil)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		Imports: ImportsNodeRuntime,
+	}
+	cfg.Storage = s
+	cfg.LogLvl = log.LvlCrit
+
+	in, err := NewInstance(code, cfg)
+	require.NoError(t, err)
+	t.Cleanup(in.Stop)
+	return in
+}
+
+func TestInstance_BatchVerify(t *testing.T) {
+	msg := []byte("helloworld")
+
+	edKp, err := ed25519.GenerateKeypair()
+	require.NoError(t, err)
+	edSig, err := edKp.Sign(msg)
+	require.NoError(t, err)
+
+	srKp, err := sr25519.GenerateKeypair()
+	require.NoError(t, err)
+	srSig, err := srKp.Sign(msg)
+	require.NoError(t, err)
+
+	badEdSig := append([]byte{}, edSig...)
+	badEdSig[0] ^= 0xff
+	badSrSig := append([]byte{}, srSig...)
+	badSrSig[0] ^= 0xff
+	// the deprecated verification doesn't require the schnorrkel marker
+	unmarkedSrSig := append([]byte{}, srSig...)
+	unmarkedSrSig[63] &= 0x7f
+
+	testCases := []struct {
+		name   string
+		edSig  []byte
+		srSig  []byte
+		batch  []byte
+		single []byte
+	}{
+		{"valid", edSig, srSig, []byte{1, 0, 0, 0}, []byte{1, 0, 0, 0}},
+		{"invalid ed25519", badEdSig, srSig, []byte{0, 0, 0, 0}, []byte{1, 0, 0, 0}},
+		{"unmarked sr25519", edSig, unmarkedSrSig, []byte{1, 0, 0, 0}, []byte{1, 0, 0, 0}},
+		{"invalid sr25519", edSig, badSrSig, []byte{0, 0, 0, 0}, []byte{0, 0, 0, 0}},
+	}
+
+	for _, tc := range testCases {
+		in := newBatchVerifyInstance(t, tc.edSig, tc.srSig, edKp.Public().Encode(), srKp.Public().Encode(), msg)
+
+		// run the batch twice to make sure the verifier is reset between calls
+		for i := 0; i < 2; i++ {
+			ret, err := in.Exec("batch", []byte{})
+			require.NoError(t, err, tc.name)
+			require.Equal(t, tc.batch, ret, tc.name)
+		}
+
+		ret, err := in.Exec("single", []byte{})
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.single, ret, tc.name)
+	}
+}
+
+func TestInstance_BatchVerify_NotStarted(t *testing.T) {
+	msg := []byte("helloworld")
+
+	edKp, err := ed25519.GenerateKeypair()
+	require.NoError(t, err)
+	edSig, err := edKp.Sign(msg)
+	require.NoError(t, err)
+
+	srKp, err := sr25519.GenerateKeypair()
+	require.NoError(t, err)
+	srSig, err := srKp.Sign(msg)
+	require.NoError(t, err)
+
+	in := newBatchVerifyInstance(t, edSig, srSig, edKp.Public().Encode(), srKp.Public().Encode(), msg)
+
+	// finishing a batch that was never started fails instead of crashing the node
+	ret, err := in.Exec("finish", []byte{})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 0}, ret)
+
+	// the instance can still be used afterwards
+	ret, err = in.Exec("batch", []byte{})
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 0, 0, 0}, ret)
+}
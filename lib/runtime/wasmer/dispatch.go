@@ -0,0 +1,258 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// sandboxDispatchExport is the name of the function added to runtimes that use the sandbox. It calls the runtime's
+// dispatch thunk through the runtime's function table, which the host cannot access directly.
+const sandboxDispatchExport = "__gossamer_sandbox_dispatch"
+
+// wasm binary format constants used when adding the dispatch function
+const (
+	wasmHeaderLen = 8
+
+	sectionType     = 1
+	sectionImport   = 2
+	sectionFunction = 3
+	sectionTable    = 4
+	sectionExport   = 7
+	sectionCode     = 10
+
+	externalFunction = 0
+	externalTable    = 1
+	externalMemory   = 2
+	externalGlobal   = 3
+)
+
+// wasmSection is a section of a wasm module
+type wasmSection struct {
+	id      byte
+	content []byte
+}
+
+// injectSandboxDispatch adds an exported function to the wasm code that calls the dispatch thunk with the given table
+// index, ie. `(func (param $thunk i32) (param $ptr i32) (param $len i32) (param $state i32) (param $func i32)
+// (result i64) (call_indirect (type $thunk) (local.get $ptr) ... (local.get $thunk)))`. The code is returned
+// unchanged if it doesn't use the sandbox or doesn't have a function table.
+func injectSandboxDispatch(code []byte) ([]byte, error) {
+	if len(code) < wasmHeaderLen || !bytes.Equal(code[:4], []byte("\x00asm")) {
+		return nil, errors.New("invalid wasm module")
+	}
+
+	var sections []*wasmSection
+	found := make(map[byte]*wasmSection)
+	for off := wasmHeaderLen; off < len(code); {
+		id := code[off]
+		size, n, err := readULEB128(code[off+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		start := off + 1 + n
+		end := start + int(size)
+		if end > len(code) {
+			return nil, fmt.Errorf("section %d exceeds the module", id)
+		}
+
+		s := &wasmSection{id: id, content: code[start:end]}
+		sections = append(sections, s)
+		found[id] = s
+		off = end
+	}
+
+	imports, ok := found[sectionImport]
+	if !ok {
+		return code, nil
+	}
+
+	numImportedFuncs, usesSandbox, importsTable, err := parseImports(imports.content)
+	if err != nil {
+		return nil, err
+	}
+
+	if !usesSandbox {
+		return code, nil
+	}
+
+	if table, ok := found[sectionTable]; !importsTable && (!ok || len(table.content) == 0 || table.content[0] == 0) {
+		return code, nil
+	}
+
+	types, funcs, exports, codes := found[sectionType], found[sectionFunction], found[sectionExport], found[sectionCode]
+	if types == nil || funcs == nil || exports == nil || codes == nil {
+		return nil, errors.New("missing wasm module section")
+	}
+
+	// (i32, i32, i32, i32, i32) -> i64 is the type of the dispatch function,
+	// (i32, i32, i32, i32) -> i64 the type of the dispatch thunk
+	numTypes, err := appendEntries(types, []byte{0x60, 5, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 1, 0x7e}, []byte{0x60, 4, 0x7f, 0x7f, 0x7f, 0x7f, 1, 0x7e})
+	if err != nil {
+		return nil, err
+	}
+
+	numFuncs, err := appendEntries(funcs, encodeULEB128(numTypes))
+	if err != nil {
+		return nil, err
+	}
+
+	export := append(encodeULEB128(uint32(len(sandboxDispatchExport))), sandboxDispatchExport...)
+	export = append(append(export, externalFunction), encodeULEB128(numImportedFuncs+numFuncs)...)
+	if _, err = appendEntries(exports, export); err != nil {
+		return nil, err
+	}
+
+	body := []byte{0, 0x20, 1, 0x20, 2, 0x20, 3, 0x20, 4, 0x20, 0, 0x11}
+	body = append(append(body, encodeULEB128(numTypes+1)...), 0, 0x0b)
+	if _, err = appendEntries(codes, append(encodeULEB128(uint32(len(body))), body...)); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte{}, code[:wasmHeaderLen]...)
+	for _, s := range sections {
+		out = append(out, s.id)
+		out = append(out, encodeULEB128(uint32(len(s.content)))...)
+		out = append(out, s.content...)
+	}
+	return out, nil
+}
+
+// appendEntries appends the given entries to a vector section and returns the number of entries it had before
+func appendEntries(s *wasmSection, entries ...[]byte) (uint32, error) {
+	count, n, err := readULEB128(s.content)
+	if err != nil {
+		return 0, err
+	}
+
+	content := encodeULEB128(count + uint32(len(entries)))
+	content = append(content, s.content[n:]...)
+	for _, e := range entries {
+		content = append(content, e...)
+	}
+
+	s.content = content
+	return count, nil
+}
+
+// parseImports returns the number of functions imported by the import section, whether the sandbox is imported and
+// whether a table is imported
+func parseImports(content []byte) (numFuncs uint32, usesSandbox, importsTable bool, err error) {
+	r := &wasmReader{buf: content}
+
+	count := r.uleb()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		r.name()
+		field := r.name()
+
+		switch r.byte() {
+		case externalFunction:
+			r.uleb()
+			numFuncs++
+			if field == "ext_sandbox_instantiate_version_1" {
+				usesSandbox = true
+			}
+		case externalTable:
+			r.byte()
+			r.limits()
+			importsTable = true
+		case externalMemory:
+			r.limits()
+		case externalGlobal:
+			r.byte()
+			r.byte()
+		default:
+			return 0, false, false, errors.New("invalid import kind")
+		}
+	}
+
+	return numFuncs, usesSandbox, importsTable, r.err
+}
+
+// wasmReader reads values from a wasm section, recording the first error
+type wasmReader struct {
+	buf []byte
+	off int
+	err error
+}
+
+func (r *wasmReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+
+	if r.off >= len(r.buf) {
+		r.err = errors.New("unexpected end of section")
+		return 0
+	}
+
+	r.off++
+	return r.buf[r.off-1]
+}
+
+func (r *wasmReader) uleb() uint32 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n, err := readULEB128(r.buf[r.off:])
+	r.off += n
+	r.err = err
+	return v
+}
+
+func (r *wasmReader) name() string {
+	l := int(r.uleb())
+	if r.err != nil {
+		return ""
+	}
+
+	if r.off+l > len(r.buf) {
+		r.err = errors.New("unexpected end of section")
+		return ""
+	}
+
+	r.off += l
+	return string(r.buf[r.off-l : r.off])
+}
+
+func (r *wasmReader) limits() {
+	if r.byte() == 1 {
+		r.uleb()
+	}
+	r.uleb()
+}
+
+// readULEB128 decodes an unsigned LEB128 encoded uint32 and returns it with the number of bytes read
+func readULEB128(in []byte) (uint32, int, error) {
+	v, n := binary.Uvarint(in)
+	if n <= 0 || v > 0xFFFFFFFF {
+		return 0, 0, errors.New("invalid LEB128 integer")
+	}
+	return uint32(v), n, nil
+}
+
+// encodeULEB128 encodes a uint32 as an unsigned LEB128 integer
+func encodeULEB128(v uint32) []byte {
+	buf := make([]byte, binary.MaxVarintLen32)
+	n := binary.PutUvarint(buf, uint64(v))
+	return buf[:n]
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/stretchr/testify/require"
+	wasm "github.com/wasmerio/go-ext-wasm/wasmer"
+)
+
+// testSandboxGuest is a sandboxed module that calls the supervisor function it imports
+const testSandboxGuest = `(module
+	(import "env" "double" (func $double (param i32) (result i32)))
+	(func (export "call") (param i32) (result i32)
+		local.get 0
+		call $double
+		i32.const 1
+		i32.add))`
+
+// testSandboxRuntime is a runtime that instantiates the guest and invokes it with the argument 5 and the state 10.
+// Its dispatch thunk returns twice the argument plus the state and the supervisor function index.
+const testSandboxRuntime = `(module
+	(import "env" "memory" (memory 20))
+	(import "env" "ext_allocator_malloc_version_1" (func $malloc (param i32) (result i32)))
+	(import "env" "ext_sandbox_instantiate_version_1" (func $instantiate (param i32 i64 i64 i32) (result i32)))
+	(import "env" "ext_sandbox_invoke_version_1" (func $invoke (param i32 i64 i64 i32 i32 i32) (result i32)))
+	(table 2 funcref)
+	(elem (i32.const 1) $thunk)
+	(data (i32.const 1024) "%s")
+	(data (i32.const 4096) "%s")
+	(data (i32.const 4200) "call")
+	(data (i32.const 4300) "\04\00\05\00\00\00")
+	(func $thunk (param $args i32) (param $len i32) (param $state i32) (param $func i32) (result i64)
+		(local $res i32)
+		(local.set $res (call $malloc (i32.const 7)))
+		(i32.store8 (local.get $res) (i32.const 0))
+		(i32.store8 offset=1 (local.get $res) (i32.const 1))
+		(i32.store8 offset=2 (local.get $res) (i32.const 0))
+		(i32.store offset=3 (local.get $res)
+			(i32.add
+				(i32.add (i32.mul (i32.load offset=2 (local.get $args)) (i32.const 2)) (local.get $state))
+				(local.get $func)))
+		(i64.or (i64.shl (i64.extend_i32_u (local.get $res)) (i64.const 32)) (i64.const 7)))
+	(func (export "run") (param i32 i32) (result i64)
+		(local $inst i32)
+		(local.set $inst (call $instantiate (i32.const 1) (i64.const %d) (i64.const %d) (i32.const 0)))
+		(i32.store (i32.const 5000)
+			(call $invoke (local.get $inst) (i64.const %d) (i64.const %d) (i32.const 5004) (i32.const 16) (i32.const 10)))
+		(i64.const %d)))`
+
+func escapeWat(in []byte) string {
+	var sb strings.Builder
+	for _, b := range in {
+		fmt.Fprintf(&sb, "\\%02x", b)
+	}
+	return sb.String()
+}
+
+func newSandboxTestInstance(t *testing.T) *Instance {
+	guest, err := wasmtime.Wat2Wasm(testSandboxGuest)
+	require.NoError(t, err)
+
+	// vec![Entry { module_name: "env", field_name: "double", entity: Function(42) }]
+	envDef := []byte{4, 12, 'e', 'n', 'v', 24, 'd', 'o', 'u', 'b', 'l', 'e', 1, 42, 0, 0, 0}
+
+	wat := fmt.Sprintf(testSandboxRuntime, escapeWat(guest), escapeWat(envDef),
+		pointerAndSizeToInt64(1024, int32(len(guest))),
+		pointerAndSizeToInt64(4096, int32(len(envDef))),
+		pointerAndSizeToInt64(4200, 4),
+		pointerAndSizeToInt64(4300, 6),
+		pointerAndSizeToInt64(5000, 10),
+	)
+
+	code, err := wasmtime.Wat2Wasm(wat)
+	require.NoError(t, err)
+
+	s, err := storage.NewTrieState(nil)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		Imports: ImportsNodeRuntime,
+	}
+	cfg.Storage = s
+	cfg.LogLvl = log.LvlCrit
+
+	in, err := NewInstance(code, cfg)
+	require.NoError(t, err)
+	return in
+}
+
+func TestInjectSandboxDispatch(t *testing.T) {
+	code, err := wasmtime.Wat2Wasm(`(module
+		(import "env" "ext_sandbox_instantiate_version_1" (func (param i32 i64 i64 i32) (result i32)))
+		(table 1 funcref)
+		(func (export "f")))`)
+	require.NoError(t, err)
+
+	res, err := injectSandboxDispatch(code)
+	require.NoError(t, err)
+	require.NotEqual(t, code, res)
+	require.True(t, wasm.Validate(res))
+
+	// modules that don't use the sandbox or don't have a table are left unchanged
+	code, err = wasmtime.Wat2Wasm(`(module (table 1 funcref) (func (export "f")))`)
+	require.NoError(t, err)
+
+	res, err = injectSandboxDispatch(code)
+	require.NoError(t, err)
+	require.Equal(t, code, res)
+
+	code, err = wasmtime.Wat2Wasm(`(module
+		(import "env" "ext_sandbox_instantiate_version_1" (func (param i32 i64 i64 i32) (result i32)))
+		(func (export "f")))`)
+	require.NoError(t, err)
+
+	res, err = injectSandboxDispatch(code)
+	require.NoError(t, err)
+	require.Equal(t, code, res)
+
+	_, err = injectSandboxDispatch([]byte{1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestInstance_Sandbox(t *testing.T) {
+	in := newSandboxTestInstance(t)
+
+	res, err := in.Exec("run", []byte{})
+	require.NoError(t, err)
+
+	// ERR_OK and ReturnValue::Value(I32(5 * 2 + 10 + 42 + 1))
+	require.Equal(t, []byte{0, 0, 0, 0, 1, 0, 63, 0, 0, 0}, res)
+}
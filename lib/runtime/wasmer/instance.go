@@ -25,6 +25,9 @@ import (
 	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/lib/genesis"
 	"github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/hostapi"
+	"github.com/ChainSafe/gossamer/lib/runtime/offchain"
+	"github.com/ChainSafe/gossamer/lib/runtime/sandbox"
 	"github.com/ChainSafe/gossamer/lib/trie"
 
 	log "github.com/ChainSafe/log15"
@@ -107,6 +110,7 @@ func newInstance(code []byte, cfg *Config) (*Instance, error) {
 		h := log.StreamHandler(os.Stdout, log.TerminalFormat())
 		h = log.CallerFileHandler(h)
 		logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
+		hostapi.SetLogHandler(log.LvlFilterHandler(cfg.LogLvl, h))
 	}
 
 	imports, err := cfg.Imports()
@@ -114,45 +118,22 @@ func newInstance(code []byte, cfg *Config) (*Instance, error) {
 		return nil, err
 	}
 
-	// Provide importable memory for newer runtimes
-	// TODO: determine memory descriptor size that the runtime wants from the wasm.
-	// should be doable w/ wasmer 1.0.0.
-	memory, err := wasm.NewMemory(23, 0)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = imports.AppendMemory("memory", memory)
-	if err != nil {
-		return nil, err
-	}
-
-	// Instantiates the WebAssembly module.
-	instance, err := wasm.NewInstanceWithImports(code, imports)
+	instance, allocator, err := instantiate(code, imports, cfg.Storage)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: get __heap_base exported value from runtime.
-	// wasmer 0.3.x does not support this, but wasmer 1.0.0 does
-	heapBase := runtime.DefaultHeapBase
-
-	// Assume imported memory is used if runtime does not export any
-	if !instance.HasMemory() {
-		instance.Memory = memory
-	}
-
-	allocator := runtime.NewAllocator(instance.Memory, heapBase)
-
 	runtimeCtx := &runtime.Context{
-		Storage:     cfg.Storage,
-		Allocator:   allocator,
-		Keystore:    cfg.Keystore,
-		Validator:   cfg.Role == byte(4),
-		NodeStorage: cfg.NodeStorage,
-		Network:     cfg.Network,
-		Transaction: cfg.Transaction,
-		SigVerifier: runtime.NewSignatureVerifier(),
+		Storage:          cfg.Storage,
+		Allocator:        allocator,
+		Keystore:         cfg.Keystore,
+		Validator:        cfg.Role == byte(4),
+		NodeStorage:      cfg.NodeStorage,
+		Network:          cfg.Network,
+		Transaction:      cfg.Transaction,
+		SigVerifier:      runtime.NewSignatureVerifier(),
+		OffchainHTTP:     offchain.NewHTTPSet(cfg.HTTPTransport),
+		OffchainIndexing: cfg.OffchainIndexing,
 	}
 
 	logger.Debug("NewInstance", "runtimeCtx", runtimeCtx)
@@ -163,8 +144,14 @@ func newInstance(code []byte, cfg *Config) (*Instance, error) {
 		ctx:     runtimeCtx,
 		imports: cfg.Imports,
 	}
+	runtimeCtx.Sandbox = sandbox.NewSandbox(inst)
+
+	// the version is loaded again when it's needed, as runtimes that don't implement Core_version can still be used
+	inst.version, err = inst.Version()
+	if err != nil {
+		logger.Warn("failed to get runtime version", "error", err)
+	}
 
-	inst.version, _ = inst.Version()
 	return inst, nil
 }
 
@@ -177,43 +164,75 @@ func (in *Instance) UpdateRuntimeCode(code []byte) error {
 		return err
 	}
 
-	// TODO: determine memory descriptor size that the runtime wants from the wasm.
-	// should be doable w/ wasmer 1.0.0.
-	memory, err := wasm.NewMemory(23, 0)
+	instance, allocator, err := instantiate(code, imports, in.ctx.Storage)
 	if err != nil {
 		return err
 	}
 
-	_, err = imports.AppendMemory("memory", memory)
+	in.ctx.Allocator = allocator
+	instance.SetContextData(in.ctx)
+
+	in.vm = instance
+	in.version, err = in.Version()
 	if err != nil {
 		return err
 	}
 
+	return nil
+}
+
+// instantiate adds the sandbox dispatch function to the code and instantiates it with the given imports. The memory
+// is sized from the limits declared by the module and the heap pages set in the given storage, and the returned
+// allocator's heap starts at the module's __heap_base.
+func instantiate(code []byte, imports *wasm.Imports, s runtime.Storage) (wasm.Instance, *runtime.FreeingBumpHeapAllocator, error) {
+	info, err := runtime.GetModuleInfo(code)
+	if err != nil {
+		return wasm.Instance{}, nil, err
+	}
+
+	heapPages, err := runtime.GetHeapPages(s)
+	if err != nil {
+		return wasm.Instance{}, nil, err
+	}
+
+	pages, err := info.MemoryPages(heapPages)
+	if err != nil {
+		return wasm.Instance{}, nil, err
+	}
+
+	code, err = injectSandboxDispatch(code)
+	if err != nil {
+		return wasm.Instance{}, nil, err
+	}
+
+	// Provide importable memory for newer runtimes
+	memory, err := wasm.NewMemory(pages, info.MaxPages)
+	if err != nil {
+		return wasm.Instance{}, nil, err
+	}
+
+	_, err = imports.AppendMemory("memory", memory)
+	if err != nil {
+		return wasm.Instance{}, nil, err
+	}
+
 	// Instantiates the WebAssembly module.
 	instance, err := wasm.NewInstanceWithImports(code, imports)
 	if err != nil {
-		return err
+		return wasm.Instance{}, nil, err
 	}
 
-	// TODO: get __heap_base exported value from runtime.
-	// wasmer 0.3.x does not support this, but wasmer 1.0.0 does
-	heapBase := runtime.DefaultHeapBase
-
 	// Assume imported memory is used if runtime does not export any
 	if !instance.HasMemory() {
 		instance.Memory = memory
 	}
 
-	in.ctx.Allocator = runtime.NewAllocator(instance.Memory, heapBase)
-	instance.SetContextData(in.ctx)
-
-	in.vm = instance
-	in.version, err = in.Version()
-	if err != nil {
-		return err
+	if err = runtime.GrowMemory(instance.Memory, pages); err != nil {
+		instance.Close()
+		return wasm.Instance{}, nil, err
 	}
 
-	return nil
+	return instance, runtime.NewAllocator(instance.Memory, info.HeapBase), nil
 }
 
 // SetContextStorage sets the runtime's storage. It should be set before calls to the below functions.
@@ -259,6 +278,10 @@ func (in *Instance) exec(function string, data []byte) ([]byte, error) {
 	}
 
 	defer in.clear()
+	defer in.ctx.Sandbox.Reset()
+	defer in.ctx.OffchainHTTP.Reset()
+	// a batch left unfinished by a failed call must not carry over to the next call
+	defer in.ctx.SigVerifier.Reset()
 
 	// Store the data into memory
 	in.store(data, int32(ptr))
@@ -278,6 +301,50 @@ func (in *Instance) exec(function string, data []byte) ([]byte, error) {
 	return in.load(offset, length), nil
 }
 
+// Dispatch calls the runtime's sandbox dispatch thunk, which invokes the runtime function with the given table index.
+// It implements sandbox.Dispatcher.
+func (in *Instance) Dispatch(thunk, funcIdx, state uint32, args []byte) ([]byte, error) {
+	dispatch, ok := in.vm.Exports[sandboxDispatchExport]
+	if !ok {
+		return nil, errors.New("runtime does not have a function table to dispatch sandbox calls")
+	}
+
+	ptr, err := in.malloc(uint32(len(args)))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := in.ctx.Allocator.Deallocate(ptr); err != nil {
+			logger.Error("failed to free sandbox dispatch arguments", "error", err)
+		}
+	}()
+
+	in.store(args, int32(ptr))
+
+	res, err := dispatch(int32(thunk), int32(ptr), int32(len(args)), int32(state), int32(funcIdx))
+	if err != nil {
+		return nil, err
+	}
+
+	// unlike other runtime functions, the dispatch thunk returns the pointer in the upper 32 bits
+	ret := uint64(res.ToI64())
+	retPtr, retLen := uint32(ret>>32), uint32(ret)
+
+	mem := in.vm.Memory.Data()
+	if uint64(retPtr)+uint64(retLen) > uint64(len(mem)) {
+		return nil, errors.New("sandbox dispatch result is out of bounds")
+	}
+
+	out := make([]byte, retLen)
+	copy(out, mem[retPtr:retPtr+retLen])
+
+	if err = in.ctx.Allocator.Deallocate(retPtr); err != nil {
+		logger.Error("failed to free sandbox dispatch result", "error", err)
+	}
+	return out, nil
+}
+
 func (in *Instance) malloc(size uint32) (uint32, error) {
 	return in.ctx.Allocator.Allocate(size)
 }
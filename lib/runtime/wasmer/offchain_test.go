@@ -0,0 +1,126 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasmer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChainSafe/gossamer/lib/runtime/storage"
+
+	log "github.com/ChainSafe/log15"
+	"github.com/bytecodealliance/wasmtime-go"
+	"github.com/stretchr/testify/require"
+)
+
+// testOffchainHTTPRuntime is a runtime that sends a GET request to the given uri, waits for the response and reads
+// its body into memory at offset 6000
+const testOffchainHTTPRuntime = `(module
+	(import "env" "memory" (memory 20))
+	(import "env" "ext_offchain_http_request_start_version_1" (func $start (param i64 i64 i64) (result i64)))
+	(import "env" "ext_offchain_http_response_wait_version_1" (func $wait (param i64 i64) (result i64)))
+	(import "env" "ext_offchain_http_response_read_body_version_1" (func $read (param i32 i64 i64) (result i64)))
+	(data (i32.const 1024) "GET")
+	(data (i32.const 1100) "%s")
+	(data (i32.const 1300) "\04\00\00")
+	(data (i32.const 1400) "\00")
+	(func (export "run") (param i32 i32) (result i64)
+		(drop (call $start (i64.const %d) (i64.const %d) (i64.const 0)))
+		(drop (call $wait (i64.const %d) (i64.const %d)))
+		(call $read (i32.const 0) (i64.const %d) (i64.const %d))))`
+
+// testOffchainIndexRuntime is a runtime that writes the key "noot" with the value "was here" to the offchain database
+const testOffchainIndexRuntime = `(module
+	(import "env" "memory" (memory 20))
+	(import "env" "ext_offchain_index_set_version_1" (func $index_set (param i64 i64)))
+	(data (i32.const 1024) "noot")
+	(data (i32.const 1100) "was here")
+	(func (export "run") (param i32 i32) (result i64)
+		(call $index_set (i64.const %d) (i64.const %d))
+		(i64.const 0)))`
+
+func TestInstance_OffchainIndexSet(t *testing.T) {
+	wat := fmt.Sprintf(testOffchainIndexRuntime, pointerAndSizeToInt64(1024, 4), pointerAndSizeToInt64(1100, 8))
+	code, err := wasmtime.Wat2Wasm(wat)
+	require.NoError(t, err)
+
+	for _, enabled := range []bool{false, true} {
+		s, err := storage.NewTrieState(nil)
+		require.NoError(t, err)
+
+		cfg := &Config{
+			Imports: ImportsNodeRuntime,
+		}
+		cfg.Storage = s
+		cfg.LogLvl = log.LvlCrit
+		cfg.OffchainIndexing = enabled
+
+		in, err := NewInstance(code, cfg)
+		require.NoError(t, err)
+
+		_, err = in.Exec("run", []byte{})
+		require.NoError(t, err)
+
+		if enabled {
+			require.Equal(t, map[string][]byte{"noot": []byte("was here")}, s.OffchainIndex())
+		} else {
+			require.Empty(t, s.OffchainIndex())
+		}
+	}
+}
+
+func TestInstance_OffchainHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("noot"))
+	}))
+	defer srv.Close()
+
+	wat := fmt.Sprintf(testOffchainHTTPRuntime, srv.URL,
+		pointerAndSizeToInt64(1024, 3),
+		pointerAndSizeToInt64(1100, int32(len(srv.URL))),
+		pointerAndSizeToInt64(1300, 3),
+		pointerAndSizeToInt64(1400, 1),
+		pointerAndSizeToInt64(6000, 100),
+		pointerAndSizeToInt64(1400, 1),
+	)
+
+	code, err := wasmtime.Wat2Wasm(wat)
+	require.NoError(t, err)
+
+	s, err := storage.NewTrieState(nil)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		Imports: ImportsNodeRuntime,
+	}
+	cfg.Storage = s
+	cfg.LogLvl = log.LvlCrit
+	cfg.HTTPTransport = srv.Client().Transport
+
+	in, err := NewInstance(code, cfg)
+	require.NoError(t, err)
+
+	res, err := in.Exec("run", []byte{})
+	require.NoError(t, err)
+	require.Equal(t, byte(0), res[0])
+
+	n := binary.LittleEndian.Uint32(res[1:])
+	require.Equal(t, "noot", string(in.vm.Memory.Data()[6000:6000+n]))
+}
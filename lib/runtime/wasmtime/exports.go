@@ -17,6 +17,7 @@
 package wasmtime
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
@@ -26,21 +27,39 @@ import (
 	"github.com/ChainSafe/gossamer/lib/transaction"
 )
 
-// Metadata calls runtime function Metadata_metadata
-func (in *Instance) Metadata() ([]byte, error) {
-	return in.exec(runtime.Metadata, []byte{})
+// ValidateTransaction runs the extrinsic through runtime function TaggedTransactionQueue_validate_transaction and returns *Validity
+func (in *Instance) ValidateTransaction(e types.Extrinsic) (*transaction.Validity, error) {
+	ret, err := in.exec(runtime.TaggedTransactionQueueValidateTransaction, e)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret[0] != 0 {
+		return nil, runtime.NewValidateTransactionError(ret)
+	}
+
+	v := transaction.NewValidity(0, [][]byte{{}}, [][]byte{{}}, 0, false)
+	_, err = scale.Decode(ret[1:], v)
+
+	return v, err
 }
 
 // Version calls runtime function Core_Version
 func (in *Instance) Version() (runtime.Version, error) {
+	// kusama seems to use the legacy version format
+	if in.version != nil && bytes.Equal(in.version.SpecName(), []byte("kusama")) {
+		return in.version, nil
+	}
+
+	version := new(runtime.VersionData)
 	res, err := in.exec(runtime.CoreVersion, []byte{})
 	if err != nil {
 		return nil, err
 	}
 
-	version := new(runtime.VersionData)
+	err = version.Decode(res)
 	if err == io.EOF {
-		// TODO: kusama seems to use the legacy version format
+		// kusama seems to use the legacy version format
 		lversion := &runtime.LegacyVersionData{}
 		err = lversion.Decode(res)
 		return lversion, err
@@ -51,19 +70,25 @@ func (in *Instance) Version() (runtime.Version, error) {
 	return version, nil
 }
 
+// Metadata calls runtime function Metadata_metadata
+func (in *Instance) Metadata() ([]byte, error) {
+	return in.exec(runtime.Metadata, []byte{})
+}
+
 // BabeConfiguration gets the configuration data for BABE from the runtime
 func (in *Instance) BabeConfiguration() (*types.BabeConfiguration, error) {
-	ret, err := in.exec(runtime.BabeAPIConfiguration, []byte{})
+	data, err := in.exec(runtime.BabeAPIConfiguration, []byte{})
 	if err != nil {
 		return nil, err
 	}
 
-	cfg, err := scale.Decode(ret, new(types.BabeConfiguration))
+	bc := new(types.BabeConfiguration)
+	_, err = scale.Decode(data, bc)
 	if err != nil {
 		return nil, err
 	}
 
-	return cfg.(*types.BabeConfiguration), nil
+	return bc, nil
 }
 
 // GrandpaAuthorities returns the genesis authorities from the runtime
@@ -81,29 +106,11 @@ func (in *Instance) GrandpaAuthorities() ([]*types.Authority, error) {
 	return types.GrandpaAuthoritiesRawToAuthorities(adr.([]*types.GrandpaAuthoritiesRaw))
 }
 
-// ValidateTransaction runs the extrinsic through runtime function TaggedTransactionQueue_validate_transaction and returns *Validity
-func (in *Instance) ValidateTransaction(e types.Extrinsic) (*transaction.Validity, error) {
-	ret, err := in.exec(runtime.TaggedTransactionQueueValidateTransaction, e)
-	if err != nil {
-		return nil, err
-	}
-
-	if ret[0] != 0 {
-		return nil, runtime.NewValidateTransactionError(ret)
-	}
-
-	v := transaction.NewValidity(0, [][]byte{{}}, [][]byte{{}}, 0, false)
-	_, err = scale.Decode(ret[1:], v)
-
-	return v, err
-}
-
-//nolint
-// InitializeBlock calls runtime API function Core_initialize_block
+// InitializeBlock calls runtime API function Core_initialise_block
 func (in *Instance) InitializeBlock(header *types.Header) error {
 	encodedHeader, err := scale.Encode(header)
 	if err != nil {
-		return fmt.Errorf("cannot encode header: %s", err)
+		return fmt.Errorf("cannot encode header: %w", err)
 	}
 
 	_, err = in.exec(runtime.CoreInitializeBlock, encodedHeader)
@@ -139,9 +146,27 @@ func (in *Instance) FinalizeBlock() (*types.Header, error) {
 
 // ExecuteBlock calls runtime function Core_execute_block
 func (in *Instance) ExecuteBlock(block *types.Block) ([]byte, error) {
+	// copy block since we're going to modify it
 	b := block.DeepCopy()
 
-	b.Header.Digest = types.Digest{}
+	if in.version == nil {
+		var err error
+		in.version, err = in.Version()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// remove seal digest only
+	b.Header.Digest = types.NewEmptyDigest()
+	for _, d := range block.Header.Digest {
+		if d.Type() == types.SealDigestType {
+			continue
+		}
+
+		b.Header.Digest = append(b.Header.Digest, d)
+	}
+
 	bdEnc, err := b.Encode()
 	if err != nil {
 		return nil, err
@@ -150,7 +175,17 @@ func (in *Instance) ExecuteBlock(block *types.Block) ([]byte, error) {
 	return in.exec(runtime.CoreExecuteBlock, bdEnc)
 }
 
+// OffchainWorker calls runtime API function OffchainWorkerApi_offchain_worker for the given block
+func (in *Instance) OffchainWorker(header *types.Header) error {
+	encodedHeader, err := scale.Encode(header)
+	if err != nil {
+		return fmt.Errorf("cannot encode header: %w", err)
+	}
+
+	_, err = in.exec(runtime.OffchainWorkerAPIOffchainWorker, encodedHeader)
+	return err
+}
+
 func (in *Instance) CheckInherents()      {} //nolint
 func (in *Instance) RandomSeed()          {} //nolint
-func (in *Instance) OffchainWorker()      {} //nolint
 func (in *Instance) GenerateSessionKeys() {} //nolint
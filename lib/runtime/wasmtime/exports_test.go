@@ -53,8 +53,6 @@ func TestConcurrentRuntimeCalls(t *testing.T) {
 }
 
 func TestInstance_Version_NodeRuntime(t *testing.T) {
-	t.Skip() // TODO: currently fails, returns all 0
-
 	expected := runtime.NewVersionData(
 		[]byte("node"),
 		[]byte("substrate-node"),
@@ -18,11 +18,17 @@ package wasmtime
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"sync"
 
 	gssmrruntime "github.com/ChainSafe/gossamer/lib/runtime"
+	"github.com/ChainSafe/gossamer/lib/runtime/hostapi"
+	"github.com/ChainSafe/gossamer/lib/runtime/offchain"
+	"github.com/ChainSafe/gossamer/lib/runtime/sandbox"
+	rtstorage "github.com/ChainSafe/gossamer/lib/runtime/storage"
 
 	log "github.com/ChainSafe/log15"
 	"github.com/bytecodealliance/wasmtime-go"
@@ -31,17 +37,25 @@ import (
 // Name represents the name of the interpreter
 const Name = "wasmtime"
 
+const (
+	// functionTableExport is the name of the runtime's exported function table, which is used to dispatch sandbox
+	// calls
+	functionTableExport = "__indirect_function_table"
+)
+
 var (
 	_ gssmrruntime.Instance = (*Instance)(nil)
+	_ gssmrruntime.Memory   = Memory{}
+	_ hostapi.Env           = (*Instance)(nil)
+	_ sandbox.Dispatcher    = (*Instance)(nil)
 
-	ctx    gssmrruntime.Context
 	logger = log.New("pkg", "runtime", "module", "go-wasmtime")
 )
 
-// ImportsFunc returns a linker with the module imports
-type ImportsFunc func(*wasmtime.Store, *wasmtime.Memory) (*wasmtime.Linker, error)
+// ImportsFunc returns a linker with the module imports, which are called with the given environment
+type ImportsFunc func(*wasmtime.Store, *wasmtime.Memory, hostapi.Env) (*wasmtime.Linker, error)
 
-// Config represents a wasmer configuration
+// Config represents a wasmtime configuration
 type Config struct {
 	gssmrruntime.InstanceConfig
 	Imports ImportsFunc
@@ -49,97 +63,195 @@ type Config struct {
 
 // Instance represents a v0.8 runtime go-wasmtime instance
 type Instance struct {
-	vm  *wasmtime.Instance
-	mu  sync.Mutex
-	mem *wasmtime.Memory
+	vm      *wasmtime.Instance
+	mu      sync.Mutex
+	mem     *wasmtime.Memory
+	ctx     *gssmrruntime.Context
+	version gssmrruntime.Version
+	imports ImportsFunc
 }
 
 // NewInstanceFromFile instantiates a runtime from a .wasm file
 func NewInstanceFromFile(fp string, cfg *Config) (*Instance, error) {
-	engine := wasmtime.NewEngine()
-	module, err := wasmtime.NewModuleFromFile(engine, fp)
+	code, err := ioutil.ReadFile(fp)
 	if err != nil {
 		return nil, err
 	}
 
-	return newInstanceFromModule(module, engine, cfg)
+	return NewInstance(code, cfg)
 }
 
 // NewInstance instantiates a runtime from the given wasm bytecode
 func NewInstance(code []byte, cfg *Config) (*Instance, error) {
-	engine := wasmtime.NewEngine()
-	module, err := wasmtime.NewModule(engine, code)
-	if err != nil {
-		return nil, err
+	if len(code) == 0 {
+		return nil, errors.New("code is empty")
 	}
 
-	return newInstanceFromModule(module, engine, cfg)
-}
-
-func newInstanceFromModule(module *wasmtime.Module, engine *wasmtime.Engine, cfg *Config) (*Instance, error) {
 	// if cfg.LogLvl set to < 0, then don't change package log level
 	if cfg.LogLvl >= 0 {
 		h := log.StreamHandler(os.Stdout, log.TerminalFormat())
 		h = log.CallerFileHandler(h)
 		logger.SetHandler(log.LvlFilterHandler(cfg.LogLvl, h))
+		hostapi.SetLogHandler(log.LvlFilterHandler(cfg.LogLvl, h))
+	}
+
+	in := &Instance{
+		ctx: &gssmrruntime.Context{
+			Storage:          cfg.Storage,
+			Keystore:         cfg.Keystore,
+			Validator:        cfg.Role == byte(4),
+			NodeStorage:      cfg.NodeStorage,
+			Network:          cfg.Network,
+			Transaction:      cfg.Transaction,
+			SigVerifier:      gssmrruntime.NewSignatureVerifier(),
+			OffchainHTTP:     offchain.NewHTTPSet(cfg.HTTPTransport),
+			OffchainIndexing: cfg.OffchainIndexing,
+		},
+		imports: cfg.Imports,
+	}
+	in.ctx.Sandbox = sandbox.NewSandbox(in)
+
+	if err := in.instantiate(code); err != nil {
+		return nil, err
+	}
+
+	in.version, _ = in.Version()
+	return in, nil
+}
+
+// instantiate compiles the given code and sets it as the instance's module, resetting its memory and allocator
+func (in *Instance) instantiate(code []byte) error {
+	info, err := gssmrruntime.GetModuleInfo(code)
+	if err != nil {
+		return err
+	}
+
+	heapPages, err := gssmrruntime.GetHeapPages(in.ctx.Storage)
+	if err != nil {
+		return err
+	}
+
+	pages, err := info.MemoryPages(heapPages)
+	if err != nil {
+		return err
+	}
+
+	engine := wasmtime.NewEngine()
+	module, err := wasmtime.NewModule(engine, code)
+	if err != nil {
+		return err
 	}
+
 	store := wasmtime.NewStore(engine)
 
+	// provide importable memory for newer runtimes
 	lim := wasmtime.Limits{
-		Min: 20,
+		Min: pages,
 		Max: wasmtime.LimitsMaxNone,
 	}
+	if info.MaxPages != 0 {
+		lim.Max = info.MaxPages
+	}
 	mem := wasmtime.NewMemory(store, wasmtime.NewMemoryType(lim))
 
-	linker, err := cfg.Imports(store, mem)
+	linker, err := in.imports(store, mem, in)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	instance, err := linker.Instantiate(module)
+	vm, err := linker.Instantiate(module)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// TODO: use __heap_base
-	allocator := gssmrruntime.NewAllocator(Memory{mem}, 0)
+	// assume imported memory is used if runtime does not export any
+	if export := vm.GetExport("memory"); export != nil && export.Memory() != nil {
+		mem = export.Memory()
+	}
 
-	ctx = gssmrruntime.Context{
-		Storage:     cfg.Storage,
-		Allocator:   allocator,
-		Keystore:    cfg.Keystore,
-		Validator:   cfg.Role == byte(4),
-		NodeStorage: cfg.NodeStorage,
-		Network:     cfg.Network,
+	if err = gssmrruntime.GrowMemory(Memory{mem}, pages); err != nil {
+		return err
 	}
 
-	return &Instance{
-		vm:  instance,
-		mem: mem,
-	}, nil
+	in.vm = vm
+	in.mem = mem
+	in.ctx.Allocator = gssmrruntime.NewAllocator(Memory{mem}, info.HeapBase)
+	return nil
 }
 
-// UpdateRuntimeCode ...
-func (in *Instance) UpdateRuntimeCode(_ []byte) error {
-	return errors.New("unimplemented")
+// UpdateRuntimeCode updates the runtime instance to run the given code
+func (in *Instance) UpdateRuntimeCode(code []byte) error {
+	in.mu.Lock()
+	err := in.instantiate(code)
+	in.version = nil
+	in.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	version, err := in.Version()
+	if err != nil {
+		return err
+	}
+
+	in.version = version
+	return nil
 }
 
 // SetContextStorage sets the runtime context's Storage
 func (in *Instance) SetContextStorage(s gssmrruntime.Storage) {
-	ctx.Storage = s
+	in.ctx.Storage = s
 }
 
-// Stop ...
-func (in *Instance) Stop() {}
+// Stop releases the instance's wasm module. wasmtime frees the module's resources when they are garbage collected.
+func (in *Instance) Stop() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.vm = nil
+	in.mem = nil
+}
 
 // NodeStorage returns the context's NodeStorage
 func (in *Instance) NodeStorage() gssmrruntime.NodeStorage {
-	return ctx.NodeStorage
+	return in.ctx.NodeStorage
 }
 
 // NetworkService returns the context's NetworkService
 func (in *Instance) NetworkService() gssmrruntime.BasicNetwork {
-	return ctx.Network
+	return in.ctx.Network
+}
+
+// Memory returns the instance's memory. It implements hostapi.Env.
+func (in *Instance) Memory() []byte {
+	return in.mem.UnsafeData()
+}
+
+// Context returns the instance's runtime context. It implements hostapi.Env.
+func (in *Instance) Context() *gssmrruntime.Context {
+	return in.ctx
+}
+
+// RuntimeVersion returns the version of the given runtime code. It implements hostapi.Env.
+func (in *Instance) RuntimeVersion(code []byte) (gssmrruntime.Version, error) {
+	cfg := &Config{
+		Imports: in.imports,
+	}
+	cfg.LogLvl = -1 // don't change log level
+	cfg.Storage, _ = rtstorage.NewTrieState(nil)
+
+	instance, err := NewInstance(code, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer instance.Stop()
+
+	// instance version is set and cached in NewInstance
+	if instance.version == nil {
+		return nil, errors.New("failed to get runtime version")
+	}
+
+	return instance.version, nil
 }
 
 // Exec calls the given function with the given data
@@ -148,32 +260,115 @@ func (in *Instance) Exec(function string, data []byte) ([]byte, error) {
 }
 
 func (in *Instance) exec(function string, data []byte) ([]byte, error) {
+	if in.ctx.Storage == nil {
+		return nil, gssmrruntime.ErrNilStorage
+	}
+
 	in.mu.Lock()
 	defer in.mu.Unlock()
 
-	ptr, err := ctx.Allocator.Allocate(uint32(len(data)))
+	if in.vm == nil {
+		return nil, errors.New("instance is stopped")
+	}
+
+	ptr, err := in.ctx.Allocator.Allocate(uint32(len(data)))
 	if err != nil {
 		return nil, err
 	}
-	defer ctx.Allocator.Clear()
 
-	memdata := in.mem.UnsafeData()
-	copy(memdata[ptr:ptr+uint32(len(data))], data)
+	defer in.ctx.Allocator.Clear()
+	defer in.ctx.Sandbox.Reset()
+	defer in.ctx.OffchainHTTP.Reset()
+	// a batch left unfinished by a failed call must not carry over to the next call
+	defer in.ctx.SigVerifier.Reset()
+
+	copy(in.Memory()[ptr:ptr+uint32(len(data))], data)
+
+	export := in.vm.GetExport(function)
+	if export == nil || export.Func() == nil {
+		return nil, fmt.Errorf("could not find exported function %s", function)
+	}
 
-	run := in.vm.GetExport(function).Func()
-	resi, err := run.Call(int32(ptr), int32(len(data)))
+	res, err := export.Func().Call(int32(ptr), int32(len(data)))
 	if err != nil {
 		return nil, err
 	}
 
-	if resi == nil {
-		return []byte{}, err
+	ret, ok := res.(int64)
+	if !ok {
+		return []byte{}, nil
 	}
 
-	ret := resi.(int64)
-	length := int32(ret >> 32)
-	offset := int32(ret)
+	// the memory may have been grown by the call, so it's only accessed afterwards. The result is copied, since
+	// the memory isn't managed by the garbage collector.
+	offset, length := int64ToPointerAndSize(ret)
+	out := make([]byte, length)
+	copy(out, in.Memory()[offset:offset+length])
 
 	runtime.KeepAlive(in.mem)
-	return memdata[offset : offset+length], nil
+	return out, nil
+}
+
+// Dispatch calls the runtime's sandbox dispatch thunk, which invokes the runtime function with the given table index.
+// It implements sandbox.Dispatcher.
+func (in *Instance) Dispatch(thunk, funcIdx, state uint32, args []byte) ([]byte, error) {
+	export := in.vm.GetExport(functionTableExport)
+	if export == nil || export.Table() == nil {
+		return nil, errors.New("runtime does not export a function table to dispatch sandbox calls")
+	}
+
+	val, err := export.Table().Get(thunk)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatch := val.Funcref()
+	if dispatch == nil {
+		return nil, fmt.Errorf("dispatch thunk %d is not a function", thunk)
+	}
+
+	ptr, err := in.ctx.Allocator.Allocate(uint32(len(args)))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := in.ctx.Allocator.Deallocate(ptr); err != nil {
+			logger.Error("failed to free sandbox dispatch arguments", "error", err)
+		}
+	}()
+
+	copy(in.Memory()[ptr:ptr+uint32(len(args))], args)
+
+	res, err := dispatch.Call(int32(ptr), int32(len(args)), int32(state), int32(funcIdx))
+	if err != nil {
+		return nil, err
+	}
+
+	ret64, ok := res.(int64)
+	if !ok {
+		return nil, errors.New("invalid sandbox dispatch result")
+	}
+
+	// unlike other runtime functions, the dispatch thunk returns the pointer in the upper 32 bits
+	ret := uint64(ret64)
+	retPtr, retLen := uint32(ret>>32), uint32(ret)
+
+	mem := in.Memory()
+	if uint64(retPtr)+uint64(retLen) > uint64(len(mem)) {
+		return nil, errors.New("sandbox dispatch result is out of bounds")
+	}
+
+	out := make([]byte, retLen)
+	copy(out, mem[retPtr:retPtr+retLen])
+
+	if err = in.ctx.Allocator.Deallocate(retPtr); err != nil {
+		logger.Error("failed to free sandbox dispatch result", "error", err)
+	}
+	return out, nil
+}
+
+// int64ToPointerAndSize converts an int64 into a int32 pointer and a int32 length
+func int64ToPointerAndSize(in int64) (ptr, length int32) {
+	return int32(in), int32(in >> 32)
 }
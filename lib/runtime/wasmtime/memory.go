@@ -17,7 +17,7 @@
 package wasmtime
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/bytecodealliance/wasmtime-go"
 )
@@ -38,7 +38,10 @@ func (m Memory) Length() uint32 {
 	return uint32(m.memory.DataSize())
 }
 
-// Grow ...
+// Grow grows the memory by the given number of pages
 func (m Memory) Grow(numPages uint32) error {
-	return errors.New("wasmtime Memory.Grow unimplemented")
+	if !m.memory.Grow(uint(numPages)) {
+		return fmt.Errorf("failed to grow memory by %d pages", numPages)
+	}
+	return nil
 }
@@ -43,9 +43,19 @@ func (p *Pool) Insert(tx *ValidTransaction) common.Hash {
 	return hash
 }
 
-// Remove removes a transaction from the pool
-func (p *Pool) Remove(hash common.Hash) {
+// Has returns true if the transaction with the given hash is in the pool
+func (p *Pool) Has(hash common.Hash) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.transactions[hash]
+	return ok
+}
+
+// Remove removes a transaction from the pool. It returns false if the transaction isn't in the pool.
+func (p *Pool) Remove(hash common.Hash) bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	_, ok := p.transactions[hash]
 	delete(p.transactions, hash)
+	return ok
 }
@@ -19,14 +19,41 @@ package transaction
 import (
 	"container/heap"
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/ChainSafe/gossamer/dot/types"
 	"github.com/ChainSafe/gossamer/lib/common"
 )
 
-// ErrTransactionExists is returned when trying to add a transaction to the queue that already exists
-var ErrTransactionExists = errors.New("transaction is already in queue")
+var (
+	// ErrTransactionExists is returned when trying to add a transaction to the queue that already exists
+	ErrTransactionExists = errors.New("transaction is already in queue")
+	// ErrTooLowPriority is returned when trying to add a transaction that provides the same tag as a ready
+	// transaction with a higher or equal priority
+	ErrTooLowPriority = errors.New("transaction priority is too low to replace a ready transaction")
+	// ErrQueueFull is returned when a transaction is evicted right away because the queue is full
+	ErrQueueFull = errors.New("transaction queue is full")
+	// ErrTransactionNotFound is returned when updating a transaction that isn't in the queue
+	ErrTransactionNotFound = errors.New("transaction is not in queue")
+)
+
+// DefaultLimits are the limits of the transaction queue used when none are configured
+var DefaultLimits = Limits{
+	ReadyCount:  8192,
+	ReadyBytes:  20 * 1024 * 1024,
+	FutureCount: 819,
+	FutureBytes: 2 * 1024 * 1024,
+}
+
+// Limits holds the maximum number of transactions and their total size in bytes in the ready and future queues. A
+// limit of 0 means no limit.
+type Limits struct {
+	ReadyCount  int
+	ReadyBytes  int
+	FutureCount int
+	FutureBytes int
+}
 
 // An Item is something we manage in a priority queue.
 type Item struct {
@@ -41,6 +68,20 @@ type Item struct {
 
 	// The index is needed by update and is maintained by the heap.Interface methods.
 	index int // The index of the item in the heap.
+
+	// missing holds the required tags that no transaction provides yet. The item is in the future queue while it's
+	// not empty.
+	missing map[string]struct{}
+
+	// blockers holds the ready transactions that provide a required tag and haven't been popped yet. The item is
+	// only in the heap once it's empty.
+	blockers map[common.Hash]struct{}
+
+	// unlocks holds the ready transactions that require a tag provided by this transaction
+	unlocks []common.Hash
+
+	// validTill is the last block number at which the transaction is valid, or 0 if it doesn't expire
+	validTill uint64
 }
 
 // A PriorityQueue implements heap.Interface and holds Items.
@@ -80,64 +121,222 @@ func (pq *priorityQueue) Pop() interface{} {
 	return item
 }
 
-// PriorityQueue is a thread safe wrapper over `priorityQueue`
+// PriorityQueue is a thread safe transaction queue. Transactions are ready once all the tags they require are
+// provided by the chain or by other ready transactions, and are popped by priority once the transactions providing
+// their required tags have been popped. Transactions that require a tag nothing provides yet wait in the future
+// queue until a provider is pushed or included in a block.
 type PriorityQueue struct {
 	pq        priorityQueue
 	currOrder uint64
 	txs       map[common.Hash]*Item
+	future    map[common.Hash]*Item
+
+	// provided maps the tags provided by ready transactions to the transaction providing them
+	provided map[string]common.Hash
+	// wanted maps the tags future transactions are missing to the transactions that require them
+	wanted map[string]map[common.Hash]struct{}
+	// pruned holds the tags provided by transactions that were popped or included in the last blocks
+	pruned [2]map[string]struct{}
+
+	limits      Limits
+	readyBytes  int
+	futureBytes int
+
+	// blockNumber is the number of the block transactions are validated at, which their longevity starts from
+	blockNumber uint64
+
+	// onStatus is called with the new status of the transactions whose status changes in the queue
+	onStatus func(*StatusNotification)
+
 	sync.Mutex
 }
 
 // NewPriorityQueue creates new instance of PriorityQueue
 func NewPriorityQueue() *PriorityQueue {
 	spq := &PriorityQueue{
-		pq:  make(priorityQueue, 0),
-		txs: make(map[common.Hash]*Item),
+		pq:       make(priorityQueue, 0),
+		txs:      make(map[common.Hash]*Item),
+		future:   make(map[common.Hash]*Item),
+		provided: make(map[string]common.Hash),
+		wanted:   make(map[string]map[common.Hash]struct{}),
+		pruned:   [2]map[string]struct{}{make(map[string]struct{}), make(map[string]struct{})},
 	}
 	heap.Init(&spq.pq)
 	return spq
 }
 
-// RemoveExtrinsic removes an extrinsic from the queue
-func (spq *PriorityQueue) RemoveExtrinsic(ext types.Extrinsic) {
+// SetLimits sets the limits of the ready and future queues, evicting the lowest priority transactions if they are
+// exceeded
+func (spq *PriorityQueue) SetLimits(limits Limits) {
 	spq.Lock()
 	defer spq.Unlock()
 
-	hash := ext.Hash()
-	item, ok := spq.txs[hash]
-	if !ok {
-		return
+	spq.limits = limits
+	spq.enforceLimits()
+}
+
+// SetStatusHandler sets the function called when the status of a transaction changes in the queue, ie. when it
+// becomes future or ready, expires, is replaced or is evicted. It is called with the queue locked, so it must not call the queue.
+func (spq *PriorityQueue) SetStatusHandler(fn func(*StatusNotification)) {
+	spq.Lock()
+	defer spq.Unlock()
+
+	spq.onStatus = fn
+}
+
+// RemoveExtrinsic removes an extrinsic from the queue. Ready transactions that require a tag it provided are moved to
+// the future queue unless the tag is provided by another transaction. It returns false if the extrinsic isn't in the
+// queue.
+func (spq *PriorityQueue) RemoveExtrinsic(ext types.Extrinsic) bool {
+	spq.Lock()
+	defer spq.Unlock()
+
+	return spq.remove(ext.Hash())
+}
+
+// RemoveExpired removes the transactions whose longevity has run out at the given block number. Transactions pushed
+// afterwards are considered validated at this block number.
+func (spq *PriorityQueue) RemoveExpired(number uint64) []*ValidTransaction {
+	spq.Lock()
+	defer spq.Unlock()
+
+	spq.blockNumber = number
+
+	var expired []*Item
+	for _, item := range spq.txs {
+		if item.validTill != 0 && item.validTill < number {
+			expired = append(expired, item)
+		}
+	}
+	for _, item := range spq.future {
+		if item.validTill != 0 && item.validTill < number {
+			expired = append(expired, item)
+		}
 	}
 
-	heap.Remove(&spq.pq, item.index)
-	delete(spq.txs, hash)
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].order < expired[j].order
+	})
+
+	var txs []*ValidTransaction
+	for _, item := range expired {
+		if spq.remove(item.hash) {
+			txs = append(txs, item.data)
+			spq.notify(item.hash, Invalid)
+		}
+	}
+	return txs
 }
 
-// Push inserts a valid transaction with priority p into the queue
+// Prune removes the given extrinsics, which have been included in a block, from the queue. The tags they provide are
+// now provided by the chain, so the transactions that require them are promoted.
+func (spq *PriorityQueue) Prune(exts []types.Extrinsic) {
+	spq.Lock()
+	defer spq.Unlock()
+
+	spq.pruned[1] = spq.pruned[0]
+	spq.pruned[0] = make(map[string]struct{})
+
+	for _, ext := range exts {
+		hash := ext.Hash()
+		if item, ok := spq.txs[hash]; ok {
+			spq.deleteReady(item)
+			spq.release(item)
+			continue
+		}
+
+		if item, ok := spq.future[hash]; ok {
+			spq.removeFuture(item)
+			spq.release(item)
+		}
+	}
+}
+
+// PruneTags marks tags provided by the chain as pruned, promoting the transactions that require them. It is used for
+// the tags provided by extrinsics that were included in a block without being in the queue, so it must be called
+// after Prune.
+func (spq *PriorityQueue) PruneTags(tags [][]byte) {
+	spq.Lock()
+	defer spq.Unlock()
+
+	for _, tag := range tags {
+		spq.pruned[0][string(tag)] = struct{}{}
+	}
+	spq.promote(tags)
+}
+
+// Has returns true if the transaction with the given hash is in the ready or future queue
+func (spq *PriorityQueue) Has(hash common.Hash) bool {
+	spq.Lock()
+	defer spq.Unlock()
+	return spq.txs[hash] != nil || spq.future[hash] != nil
+}
+
+// Push inserts a valid transaction with priority p into the queue. If it provides a tag that is already provided by
+// ready transactions, it replaces them if its priority is higher, otherwise ErrTooLowPriority is returned. If the
+// queue is full, the lowest priority transactions are evicted, and ErrQueueFull is returned if that includes the
+// pushed transaction.
 func (spq *PriorityQueue) Push(txn *ValidTransaction) (common.Hash, error) {
 	spq.Lock()
 	defer spq.Unlock()
 
 	hash := txn.Extrinsic.Hash()
-	if spq.txs[hash] != nil {
+	if spq.txs[hash] != nil || spq.future[hash] != nil {
 		return hash, ErrTransactionExists
 	}
 
+	return hash, spq.push(txn)
+}
+
+// Update replaces a queued transaction with the same transaction with its new validity, after it has been
+// revalidated. The transaction is inserted again, so that it's ordered by its new tags, which can promote or demote
+// the transactions that depend on it. The errors are the ones of Push, in which case the transaction has been
+// removed. It returns ErrTransactionNotFound if the transaction isn't in the queue.
+func (spq *PriorityQueue) Update(txn *ValidTransaction) error {
+	spq.Lock()
+	defer spq.Unlock()
+
+	if !spq.remove(txn.Extrinsic.Hash()) {
+		return ErrTransactionNotFound
+	}
+
+	return spq.push(txn)
+}
+
+// push inserts a transaction that isn't in the queue, and enforces the limits of the queue
+func (spq *PriorityQueue) push(txn *ValidTransaction) error {
+	hash := txn.Extrinsic.Hash()
 	item := &Item{
 		data:     txn,
 		hash:     hash,
 		order:    spq.currOrder,
 		priority: txn.Validity.Priority,
+		index:    -1,
+	}
+	if txn.Validity.Longevity != 0 {
+		item.validTill = spq.blockNumber + txn.Validity.Longevity
+		if item.validTill < spq.blockNumber {
+			// the longevity overflows, so the transaction never expires
+			item.validTill = 0
+		}
 	}
 	spq.currOrder++
-	heap.Push(&spq.pq, item)
-	spq.txs[hash] = item
 
-	return hash, nil
+	if err := spq.insert(item); err != nil {
+		return err
+	}
+
+	spq.enforceLimits()
+	if spq.txs[hash] == nil && spq.future[hash] == nil {
+		return ErrQueueFull
+	}
+
+	return nil
 }
 
 // Pop removes the transaction with has the highest priority value from the queue and returns it.
 // If there are multiple transaction with same priority value then it return them in FIFO order.
+// Only transactions whose required tags are provided by the chain or by popped transactions are returned.
 func (spq *PriorityQueue) Pop() *ValidTransaction {
 	spq.Lock()
 	defer spq.Unlock()
@@ -145,8 +344,9 @@ func (spq *PriorityQueue) Pop() *ValidTransaction {
 		return nil
 	}
 
-	item := heap.Pop(&spq.pq).(*Item)
-	delete(spq.txs, item.hash)
+	item := spq.pq[0]
+	spq.deleteReady(item)
+	spq.release(item)
 	return item.data
 }
 
@@ -160,7 +360,7 @@ func (spq *PriorityQueue) Peek() *ValidTransaction {
 	return spq.pq[0].data
 }
 
-// Pending returns all the transactions currently in the queue
+// Pending returns all the transactions currently in the queue, starting with the ones that can be popped
 func (spq *PriorityQueue) Pending() []*ValidTransaction {
 	spq.Lock()
 	defer spq.Unlock()
@@ -169,5 +369,291 @@ func (spq *PriorityQueue) Pending() []*ValidTransaction {
 	for idx := 0; idx < spq.pq.Len(); idx++ {
 		txns = append(txns, spq.pq[idx].data)
 	}
+
+	var waiting []*Item
+	for _, item := range spq.txs {
+		if item.index < 0 {
+			waiting = append(waiting, item)
+		}
+	}
+	for _, item := range spq.future {
+		waiting = append(waiting, item)
+	}
+
+	sort.Slice(waiting, func(i, j int) bool {
+		return waiting[i].order < waiting[j].order
+	})
+
+	for _, item := range waiting {
+		txns = append(txns, item.data)
+	}
 	return txns
 }
+
+// isPruned returns true if the tag was provided by a transaction that was popped or included in a recent block
+func (spq *PriorityQueue) isPruned(tag string) bool {
+	_, ok := spq.pruned[0][tag]
+	if !ok {
+		_, ok = spq.pruned[1][tag]
+	}
+	return ok
+}
+
+// insert adds the item to the ready queue if all its required tags are provided, or to the future queue otherwise
+func (spq *PriorityQueue) insert(item *Item) error {
+	missing := make(map[string]struct{})
+	for _, req := range item.data.Validity.Requires {
+		tag := string(req)
+		if _, ok := spq.provided[tag]; ok || spq.isPruned(tag) {
+			continue
+		}
+		missing[tag] = struct{}{}
+	}
+
+	if len(missing) > 0 {
+		spq.addFuture(item, missing)
+		return nil
+	}
+
+	// replace the ready transactions that provide the same tags if the item has a higher priority
+	var replaced []*Item
+	for _, p := range item.data.Validity.Provides {
+		hash, ok := spq.provided[string(p)]
+		if !ok {
+			continue
+		}
+
+		if spq.txs[hash].priority >= item.priority {
+			return ErrTooLowPriority
+		}
+		replaced = append(replaced, spq.txs[hash])
+	}
+
+	var removed []*Item
+	for _, r := range replaced {
+		if _, ok := spq.txs[r.hash]; ok {
+			removed = append(removed, spq.removeReady(r)[1:]...)
+			spq.onStatusChange(&StatusNotification{
+				Hash:    r.hash,
+				Status:  Usurped,
+				Usurper: item.hash,
+			})
+		}
+	}
+
+	spq.addReady(item)
+
+	// the transactions that depended on the replaced ones now depend on the item
+	spq.reinsert(removed)
+	return nil
+}
+
+// reinsert inserts removed items again, so that they are checked against the current providers
+func (spq *PriorityQueue) reinsert(items []*Item) {
+	for _, item := range items {
+		item.missing = nil
+		item.blockers = nil
+		item.unlocks = nil
+		_ = spq.insert(item)
+	}
+}
+
+// remove removes the item with the given hash from the queue, moving the ready items that depend on it back to the
+// future queue if their required tags aren't provided anymore. It returns false if the item isn't in the queue.
+func (spq *PriorityQueue) remove(hash common.Hash) bool {
+	if item, ok := spq.future[hash]; ok {
+		spq.removeFuture(item)
+		return true
+	}
+
+	item, ok := spq.txs[hash]
+	if !ok {
+		return false
+	}
+
+	removed := spq.removeReady(item)
+	spq.reinsert(removed[1:])
+	return true
+}
+
+// addReady adds an item whose required tags are all provided to the ready queue, then promotes the future
+// transactions that were waiting for the tags it provides
+func (spq *PriorityQueue) addReady(item *Item) {
+	item.missing = nil
+	item.blockers = make(map[common.Hash]struct{})
+	for _, req := range item.data.Validity.Requires {
+		tag := string(req)
+		if spq.isPruned(tag) {
+			continue
+		}
+
+		hash, ok := spq.provided[tag]
+		if !ok {
+			continue
+		}
+
+		spq.txs[hash].unlocks = append(spq.txs[hash].unlocks, item.hash)
+		item.blockers[hash] = struct{}{}
+	}
+
+	spq.txs[item.hash] = item
+	spq.readyBytes += len(item.data.Extrinsic)
+	for _, p := range item.data.Validity.Provides {
+		if _, ok := spq.provided[string(p)]; !ok {
+			spq.provided[string(p)] = item.hash
+		}
+	}
+
+	if len(item.blockers) == 0 {
+		heap.Push(&spq.pq, item)
+	}
+
+	spq.notify(item.hash, Ready)
+	spq.promote(item.data.Validity.Provides)
+}
+
+// addFuture adds an item that misses the given required tags to the future queue
+func (spq *PriorityQueue) addFuture(item *Item, missing map[string]struct{}) {
+	item.missing = missing
+	spq.future[item.hash] = item
+	spq.futureBytes += len(item.data.Extrinsic)
+	for tag := range missing {
+		if spq.wanted[tag] == nil {
+			spq.wanted[tag] = make(map[common.Hash]struct{})
+		}
+		spq.wanted[tag][item.hash] = struct{}{}
+	}
+
+	spq.notify(item.hash, Future)
+}
+
+// promote moves the future transactions that only miss the given tags to the ready queue
+func (spq *PriorityQueue) promote(tags [][]byte) {
+	for _, p := range tags {
+		tag := string(p)
+		for hash := range spq.wanted[tag] {
+			item, ok := spq.future[hash]
+			if !ok {
+				continue
+			}
+
+			delete(item.missing, tag)
+			if len(item.missing) > 0 {
+				continue
+			}
+
+			spq.removeFuture(item)
+			spq.addReady(item)
+		}
+		delete(spq.wanted, tag)
+	}
+}
+
+// release marks the tags provided by a transaction that left the queue by being popped or included in a block as
+// pruned, and unblocks the ready transactions that were waiting for it
+func (spq *PriorityQueue) release(item *Item) {
+	for _, p := range item.data.Validity.Provides {
+		spq.pruned[0][string(p)] = struct{}{}
+	}
+
+	for _, hash := range item.unlocks {
+		dep, ok := spq.txs[hash]
+		if !ok || dep.index >= 0 {
+			continue
+		}
+
+		delete(dep.blockers, item.hash)
+		if len(dep.blockers) == 0 {
+			heap.Push(&spq.pq, dep)
+		}
+	}
+
+	spq.promote(item.data.Validity.Provides)
+}
+
+// deleteReady removes a ready item from the heap and the ready transactions
+func (spq *PriorityQueue) deleteReady(item *Item) {
+	if item.index >= 0 {
+		heap.Remove(&spq.pq, item.index)
+	}
+	delete(spq.txs, item.hash)
+	spq.readyBytes -= len(item.data.Extrinsic)
+
+	for _, p := range item.data.Validity.Provides {
+		if spq.provided[string(p)] == item.hash {
+			delete(spq.provided, string(p))
+		}
+	}
+}
+
+// removeReady removes a ready item and, recursively, the ready items that require a tag it provides. It returns the
+// removed items, starting with the given one.
+func (spq *PriorityQueue) removeReady(item *Item) []*Item {
+	spq.deleteReady(item)
+
+	removed := []*Item{item}
+	for _, hash := range item.unlocks {
+		if dep, ok := spq.txs[hash]; ok {
+			removed = append(removed, spq.removeReady(dep)...)
+		}
+	}
+	return removed
+}
+
+// removeFuture removes an item from the future queue
+func (spq *PriorityQueue) removeFuture(item *Item) {
+	delete(spq.future, item.hash)
+	spq.futureBytes -= len(item.data.Extrinsic)
+	for tag := range item.missing {
+		delete(spq.wanted[tag], item.hash)
+		if len(spq.wanted[tag]) == 0 {
+			delete(spq.wanted, tag)
+		}
+	}
+}
+
+// enforceLimits evicts the lowest priority transactions, starting with the most recent ones, until the ready and
+// future queues are within their limits. Evicting a ready transaction also evicts the transactions that depend on it.
+func (spq *PriorityQueue) enforceLimits() {
+	for exceeds(spq.limits.ReadyCount, len(spq.txs)) || exceeds(spq.limits.ReadyBytes, spq.readyBytes) {
+		for _, item := range spq.removeReady(worst(spq.txs)) {
+			spq.notify(item.hash, Dropped)
+		}
+	}
+
+	for exceeds(spq.limits.FutureCount, len(spq.future)) || exceeds(spq.limits.FutureBytes, spq.futureBytes) {
+		item := worst(spq.future)
+		spq.removeFuture(item)
+		spq.notify(item.hash, Dropped)
+	}
+}
+
+// notify calls the status handler, if it is set, with the new status of the transaction
+func (spq *PriorityQueue) notify(hash common.Hash, status Status) {
+	spq.onStatusChange(&StatusNotification{
+		Hash:   hash,
+		Status: status,
+	})
+}
+
+// onStatusChange calls the status handler with the notification if it is set
+func (spq *PriorityQueue) onStatusChange(n *StatusNotification) {
+	if spq.onStatus != nil {
+		spq.onStatus(n)
+	}
+}
+
+func exceeds(limit, value int) bool {
+	return limit != 0 && value > limit
+}
+
+// worst returns the item with the lowest priority, or the most recent one if several have the lowest priority
+func worst(items map[common.Hash]*Item) *Item {
+	var res *Item
+	for _, item := range items {
+		if res == nil || item.priority < res.priority || (item.priority == res.priority && item.order > res.order) {
+			res = item
+		}
+	}
+	return res
+}
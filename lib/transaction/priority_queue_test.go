@@ -19,6 +19,10 @@ package transaction
 import (
 	"reflect"
 	"testing"
+
+	"github.com/ChainSafe/gossamer/dot/types"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestPriorityQueue(t *testing.T) {
@@ -259,3 +263,409 @@ func TestRemoveExtrinsic(t *testing.T) {
 		t.Fatalf("Fail: got %v expected %v", res, tests[1])
 	}
 }
+
+func TestPriorityQueue_Requires(t *testing.T) {
+	nonce4 := &ValidTransaction{
+		Extrinsic: []byte("nonce4"),
+		Validity:  &Validity{Priority: 1, Provides: [][]byte{[]byte("alice4")}},
+	}
+	nonce5 := &ValidTransaction{
+		Extrinsic: []byte("nonce5"),
+		Validity:  &Validity{Priority: 10, Requires: [][]byte{[]byte("alice4")}, Provides: [][]byte{[]byte("alice5")}},
+	}
+	other := &ValidTransaction{
+		Extrinsic: []byte("other"),
+		Validity:  &Validity{Priority: 5},
+	}
+
+	pq := NewPriorityQueue()
+	for _, tx := range []*ValidTransaction{nonce5, nonce4, other} {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+
+	// nonce5 has the highest priority, but it can't be popped before nonce4
+	require.Equal(t, other, pq.Pop())
+	require.Equal(t, nonce4, pq.Pop())
+	require.Equal(t, nonce5, pq.Pop())
+	require.Nil(t, pq.Pop())
+}
+
+func TestPriorityQueue_Future(t *testing.T) {
+	txs := []*ValidTransaction{
+		{
+			Extrinsic: []byte("nonce6"),
+			Validity:  &Validity{Priority: 3, Requires: [][]byte{[]byte("alice5")}, Provides: [][]byte{[]byte("alice6")}},
+		},
+		{
+			Extrinsic: []byte("nonce5"),
+			Validity:  &Validity{Priority: 2, Requires: [][]byte{[]byte("alice4")}, Provides: [][]byte{[]byte("alice5")}},
+		},
+		{
+			Extrinsic: []byte("nonce4"),
+			Validity:  &Validity{Priority: 1, Provides: [][]byte{[]byte("alice4")}},
+		},
+	}
+
+	pq := NewPriorityQueue()
+	_, err := pq.Push(txs[0])
+	require.NoError(t, err)
+	_, err = pq.Push(txs[1])
+	require.NoError(t, err)
+
+	// the transactions wait in the future queue until nonce4 is pushed
+	require.Nil(t, pq.Peek())
+	require.Equal(t, txs[:2], pq.Pending())
+
+	_, err = pq.Push(txs[1])
+	require.Equal(t, ErrTransactionExists, err)
+
+	_, err = pq.Push(txs[2])
+	require.NoError(t, err)
+
+	require.Equal(t, txs[2], pq.Pop())
+	require.Equal(t, txs[1], pq.Pop())
+	require.Equal(t, txs[0], pq.Pop())
+	require.Nil(t, pq.Pop())
+}
+
+func TestPriorityQueue_Prune(t *testing.T) {
+	nonce4 := &ValidTransaction{
+		Extrinsic: []byte("nonce4"),
+		Validity:  &Validity{Priority: 1, Provides: [][]byte{[]byte("alice4")}},
+	}
+	nonce5 := &ValidTransaction{
+		Extrinsic: []byte("nonce5"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice4")}, Provides: [][]byte{[]byte("alice5")}},
+	}
+	nonce6 := &ValidTransaction{
+		Extrinsic: []byte("nonce6"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice5")}},
+	}
+
+	pq := NewPriorityQueue()
+	for _, tx := range []*ValidTransaction{nonce4, nonce5, nonce6} {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+
+	// a block includes nonce4 and nonce5, so nonce6 can be popped
+	pq.Prune([]types.Extrinsic{nonce4.Extrinsic, nonce5.Extrinsic})
+	require.Equal(t, []*ValidTransaction{nonce6}, pq.Pending())
+	require.Equal(t, nonce6, pq.Pop())
+
+	// transactions that require tags provided by the included transactions are ready
+	nonce7 := &ValidTransaction{
+		Extrinsic: []byte("nonce7"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice5")}},
+	}
+	_, err := pq.Push(nonce7)
+	require.NoError(t, err)
+	require.Equal(t, nonce7, pq.Pop())
+}
+
+func TestPriorityQueue_PruneFuture(t *testing.T) {
+	nonce4 := &ValidTransaction{
+		Extrinsic: []byte("nonce4"),
+		Validity:  &Validity{Priority: 1, Provides: [][]byte{[]byte("alice4")}},
+	}
+	nonce5 := &ValidTransaction{
+		Extrinsic: []byte("nonce5"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice4")}},
+	}
+
+	pq := NewPriorityQueue()
+	_, err := pq.Push(nonce5)
+	require.NoError(t, err)
+	require.Nil(t, pq.Peek())
+
+	// nonce4 was included in a block without being in the queue, its tags are unknown until they are pruned
+	pq.Prune([]types.Extrinsic{nonce4.Extrinsic})
+	require.Nil(t, pq.Peek())
+
+	pq.PruneTags(nonce4.Validity.Provides)
+	require.Equal(t, nonce5, pq.Pop())
+}
+
+func TestPriorityQueue_PruneFutureProvider(t *testing.T) {
+	nonce4 := &ValidTransaction{
+		Extrinsic: []byte("nonce4"),
+		Validity:  &Validity{Priority: 1, Provides: [][]byte{[]byte("alice4")}},
+	}
+	nonce5 := &ValidTransaction{
+		Extrinsic: []byte("nonce5"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice4")}},
+	}
+
+	pq := NewPriorityQueue()
+	_, err := pq.Push(nonce5)
+	require.NoError(t, err)
+	require.True(t, pq.Has(nonce5.Extrinsic.Hash()))
+	require.False(t, pq.Has(nonce4.Extrinsic.Hash()))
+
+	_, err = pq.Push(nonce4)
+	require.NoError(t, err)
+	pq.Prune([]types.Extrinsic{nonce4.Extrinsic})
+	require.Equal(t, nonce5, pq.Pop())
+}
+
+func TestPriorityQueue_RemoveExtrinsicDemotes(t *testing.T) {
+	nonce4 := &ValidTransaction{
+		Extrinsic: []byte("nonce4"),
+		Validity:  &Validity{Priority: 1, Provides: [][]byte{[]byte("alice4")}},
+	}
+	nonce5 := &ValidTransaction{
+		Extrinsic: []byte("nonce5"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice4")}},
+	}
+
+	pq := NewPriorityQueue()
+	for _, tx := range []*ValidTransaction{nonce4, nonce5} {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+
+	pq.RemoveExtrinsic(nonce4.Extrinsic)
+	require.Nil(t, pq.Pop())
+	require.Equal(t, []*ValidTransaction{nonce5}, pq.Pending())
+
+	_, err := pq.Push(nonce4)
+	require.NoError(t, err)
+	require.Equal(t, nonce4, pq.Pop())
+	require.Equal(t, nonce5, pq.Pop())
+}
+
+func TestPriorityQueue_Replace(t *testing.T) {
+	low := &ValidTransaction{
+		Extrinsic: []byte("low"),
+		Validity:  &Validity{Priority: 1, Provides: [][]byte{[]byte("alice4")}},
+	}
+	dep := &ValidTransaction{
+		Extrinsic: []byte("dep"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice4")}},
+	}
+	high := &ValidTransaction{
+		Extrinsic: []byte("high"),
+		Validity:  &Validity{Priority: 2, Provides: [][]byte{[]byte("alice4")}},
+	}
+	same := &ValidTransaction{
+		Extrinsic: []byte("same"),
+		Validity:  &Validity{Priority: 2, Provides: [][]byte{[]byte("alice4")}},
+	}
+
+	pq := NewPriorityQueue()
+	for _, tx := range []*ValidTransaction{low, dep, high} {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+
+	_, err := pq.Push(same)
+	require.Equal(t, ErrTooLowPriority, err)
+
+	// high replaced low, and dep now depends on high
+	require.Equal(t, []*ValidTransaction{high, dep}, pq.Pending())
+	require.Equal(t, high, pq.Pop())
+	require.Equal(t, dep, pq.Pop())
+	require.Nil(t, pq.Pop())
+}
+
+func TestPriorityQueue_Limits(t *testing.T) {
+	pq := NewPriorityQueue()
+	pq.SetLimits(Limits{ReadyCount: 2, FutureCount: 1})
+
+	txs := []*ValidTransaction{
+		{
+			Extrinsic: []byte("a"),
+			Validity:  &Validity{Priority: 2},
+		},
+		{
+			Extrinsic: []byte("b"),
+			Validity:  &Validity{Priority: 1},
+		},
+		{
+			Extrinsic: []byte("c"),
+			Validity:  &Validity{Priority: 3},
+		},
+	}
+
+	for _, tx := range txs {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+
+	// the lowest priority transaction was evicted
+	require.Equal(t, []*ValidTransaction{txs[2], txs[0]}, pq.Pending())
+
+	_, err := pq.Push(&ValidTransaction{
+		Extrinsic: []byte("d"),
+		Validity:  &Validity{Priority: 1},
+	})
+	require.Equal(t, ErrQueueFull, err)
+
+	future := []*ValidTransaction{
+		{
+			Extrinsic: []byte("e"),
+			Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("e")}},
+		},
+		{
+			Extrinsic: []byte("f"),
+			Validity:  &Validity{Priority: 2, Requires: [][]byte{[]byte("f")}},
+		},
+	}
+
+	for _, tx := range future {
+		_, err = pq.Push(tx)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []*ValidTransaction{txs[2], txs[0], future[1]}, pq.Pending())
+
+	// the ready queue is limited in bytes too
+	pq.SetLimits(Limits{ReadyBytes: 1})
+	require.Equal(t, []*ValidTransaction{txs[2], future[1]}, pq.Pending())
+}
+
+func TestPriorityQueue_RemoveExpired(t *testing.T) {
+	txs := []*ValidTransaction{
+		{
+			Extrinsic: []byte("a"),
+			Validity:  &Validity{Priority: 1, Longevity: 2},
+		},
+		{
+			Extrinsic: []byte("b"),
+			Validity:  &Validity{Priority: 1, Longevity: 4},
+		},
+		{
+			Extrinsic: []byte("c"),
+			Validity:  &Validity{Priority: 1},
+		},
+	}
+
+	pq := NewPriorityQueue()
+	require.Nil(t, pq.RemoveExpired(10))
+
+	for _, tx := range txs {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+
+	require.Nil(t, pq.RemoveExpired(12))
+	require.Equal(t, []*ValidTransaction{txs[0]}, pq.RemoveExpired(13))
+	require.Equal(t, []*ValidTransaction{txs[1]}, pq.RemoveExpired(15))
+	require.Equal(t, []*ValidTransaction{txs[2]}, pq.Pending())
+}
+
+func TestPriorityQueue_StatusHandler(t *testing.T) {
+	txs := []*ValidTransaction{
+		{
+			Extrinsic: []byte("a"),
+			Validity:  &Validity{Priority: 1, Longevity: 2},
+		},
+		{
+			Extrinsic: []byte("b"),
+			Validity:  &Validity{Priority: 2},
+		},
+	}
+
+	var notifications []*StatusNotification
+	pq := NewPriorityQueue()
+	pq.SetStatusHandler(func(n *StatusNotification) {
+		notifications = append(notifications, n)
+	})
+
+	for _, tx := range txs {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []*StatusNotification{
+		{Hash: txs[0].Extrinsic.Hash(), Status: Ready},
+		{Hash: txs[1].Extrinsic.Hash(), Status: Ready},
+	}, notifications)
+
+	notifications = nil
+	pq.SetLimits(Limits{ReadyCount: 1})
+	require.Equal(t, []*StatusNotification{{Hash: txs[0].Extrinsic.Hash(), Status: Dropped}}, notifications)
+
+	notifications = nil
+	_, err := pq.Push(&ValidTransaction{
+		Extrinsic: []byte("c"),
+		Validity:  &Validity{Priority: 1, Longevity: 2},
+	})
+	require.Equal(t, ErrQueueFull, err)
+	require.Equal(t, []*StatusNotification{
+		{Hash: types.Extrinsic("c").Hash(), Status: Ready},
+		{Hash: types.Extrinsic("c").Hash(), Status: Dropped},
+	}, notifications)
+
+	notifications = nil
+	pq.SetLimits(Limits{})
+	_, err = pq.Push(txs[0])
+	require.NoError(t, err)
+	pq.RemoveExpired(3)
+	require.Equal(t, []*StatusNotification{
+		{Hash: txs[0].Extrinsic.Hash(), Status: Ready},
+		{Hash: txs[0].Extrinsic.Hash(), Status: Invalid},
+	}, notifications)
+}
+
+func TestPriorityQueue_StatusHandler_Tags(t *testing.T) {
+	txs := []*ValidTransaction{
+		{
+			Extrinsic: []byte("a"),
+			Validity:  &Validity{Priority: 1, Requires: [][]byte{{'x'}}},
+		},
+		{
+			Extrinsic: []byte("b"),
+			Validity:  &Validity{Priority: 1, Provides: [][]byte{{'x'}}},
+		},
+		{
+			Extrinsic: []byte("c"),
+			Validity:  &Validity{Priority: 2, Provides: [][]byte{{'x'}}},
+		},
+	}
+
+	var notifications []*StatusNotification
+	pq := NewPriorityQueue()
+	pq.SetStatusHandler(func(n *StatusNotification) {
+		notifications = append(notifications, n)
+	})
+
+	for _, tx := range txs {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []*StatusNotification{
+		{Hash: txs[0].Extrinsic.Hash(), Status: Future},
+		{Hash: txs[1].Extrinsic.Hash(), Status: Ready},
+		{Hash: txs[0].Extrinsic.Hash(), Status: Ready},
+		{Hash: txs[1].Extrinsic.Hash(), Status: Usurped, Usurper: txs[2].Extrinsic.Hash()},
+		{Hash: txs[2].Extrinsic.Hash(), Status: Ready},
+		{Hash: txs[0].Extrinsic.Hash(), Status: Ready},
+	}, notifications)
+}
+
+func TestPriorityQueue_Update(t *testing.T) {
+	nonce5 := &ValidTransaction{
+		Extrinsic: []byte("nonce5"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice4")}, Provides: [][]byte{[]byte("alice5")}},
+	}
+	nonce6 := &ValidTransaction{
+		Extrinsic: []byte("nonce6"),
+		Validity:  &Validity{Priority: 1, Requires: [][]byte{[]byte("alice5")}},
+	}
+
+	pq := NewPriorityQueue()
+	for _, tx := range []*ValidTransaction{nonce5, nonce6} {
+		_, err := pq.Push(tx)
+		require.NoError(t, err)
+	}
+	require.Nil(t, pq.Peek())
+
+	// once revalidated, nonce5 doesn't require the tag provided by the chain anymore
+	updated := NewValidTransaction(nonce5.Extrinsic, &Validity{Priority: 2, Provides: [][]byte{[]byte("alice5")}})
+	require.NoError(t, pq.Update(updated))
+	require.Equal(t, updated, pq.Pop())
+	require.Equal(t, nonce6, pq.Pop())
+
+	require.Equal(t, ErrTransactionNotFound, pq.Update(updated))
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package transaction
+
+import (
+	"github.com/ChainSafe/gossamer/lib/common"
+)
+
+// Status is the status of a transaction in the transaction pool. The statuses match Substrate's TransactionStatus.
+type Status byte
+
+const (
+	// Future means the transaction is in the future queue, waiting for the tags it requires to be provided
+	Future Status = iota
+	// Ready means the transaction is in the ready queue and can be included in a block
+	Ready
+	// Broadcast means the transaction was broadcast to the given peers
+	Broadcast
+	// InBlock means the transaction was included in the given block
+	InBlock
+	// Retracted means the block the transaction was included in was retracted by a re-org
+	Retracted
+	// FinalityTimeout means the block the transaction was included in wasn't finalised in time, so it isn't watched
+	// anymore
+	FinalityTimeout
+	// Finalized means the block the transaction was included in was finalised
+	Finalized
+	// Usurped means the transaction was replaced by the given transaction, which provides the same tags
+	Usurped
+	// Dropped means the transaction was evicted from the pool because it was full
+	Dropped
+	// Invalid means the transaction is no longer valid and was removed from the pool
+	Invalid
+)
+
+// String returns the name of the status as used by the author_extrinsicUpdate subscription
+func (s Status) String() string {
+	switch s {
+	case Future:
+		return "future"
+	case Ready:
+		return "ready"
+	case Broadcast:
+		return "broadcast"
+	case InBlock:
+		return "inBlock"
+	case Retracted:
+		return "retracted"
+	case FinalityTimeout:
+		return "finalityTimeout"
+	case Finalized:
+		return "finalized"
+	case Usurped:
+		return "usurped"
+	case Dropped:
+		return "dropped"
+	case Invalid:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
+
+// IsFinal returns true if the transaction isn't watched anymore after this status
+func (s Status) IsFinal() bool {
+	switch s {
+	case FinalityTimeout, Finalized, Usurped, Dropped, Invalid:
+		return true
+	default:
+		return false
+	}
+}
+
+// StatusNotification is sent when the status of a transaction in the pool changes
+type StatusNotification struct {
+	// Hash is the hash of the extrinsic
+	Hash   common.Hash
+	Status Status
+	// Block is the hash of the block for the InBlock, Retracted, FinalityTimeout and Finalized statuses
+	Block common.Hash
+	// Usurper is the hash of the extrinsic that replaced the transaction for the Usurped status
+	Usurper common.Hash
+	// Peers are the IDs of the peers the transaction was broadcast to for the Broadcast status
+	Peers []string
+}
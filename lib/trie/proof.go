@@ -0,0 +1,226 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/gossamer/lib/common"
+
+	"github.com/ChainSafe/chaindb"
+)
+
+var (
+	// ErrEmptyProof is returned when attempting to verify an empty proof against a non-empty root
+	ErrEmptyProof = errors.New("proof slice empty")
+
+	// ErrInvalidProof is returned when a proof does not contain the nodes needed to reach a key from the root
+	ErrInvalidProof = errors.New("invalid proof")
+)
+
+// GenerateProof returns the encoded nodes on the paths from the given root to each of the given keys.
+// The nodes are read from the database, which is expected to hold the trie in the node-by-node layout written by Store.
+// Nodes that are inlined in their parent's encoding are not included separately, and each node appears only once.
+func GenerateProof(db chaindb.Database, root common.Hash, keys [][]byte) ([][]byte, error) {
+	proof := [][]byte{}
+	if root == EmptyHash {
+		return proof, nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, key := range keys {
+		nodes, err := proofFromDB(db, root, keyToNibbles(key))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, enc := range nodes {
+			if _, has := seen[string(enc)]; has {
+				continue
+			}
+
+			seen[string(enc)] = struct{}{}
+			proof = append(proof, enc)
+		}
+	}
+
+	return proof, nil
+}
+
+func proofFromDB(db chaindb.Database, root common.Hash, key []byte) ([][]byte, error) {
+	enc, err := db.Get(root[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to find root key=%s: %w", root, err)
+	}
+
+	nodes := [][]byte{enc}
+	for {
+		n, err := decodeBytes(enc)
+		if err != nil {
+			return nil, err
+		}
+
+		b, ok := n.(*branch)
+		if !ok {
+			// the path ends at a leaf, whether or not it holds the key
+			return nodes, nil
+		}
+
+		length := lenCommonPrefix(b.key, key)
+		if length < len(b.key) || len(key) == len(b.key) {
+			// either the value is at this branch or the key diverges from it
+			return nodes, nil
+		}
+
+		child := b.children[key[length]]
+		if child == nil {
+			return nodes, nil
+		}
+
+		key = key[length+1:]
+		hash := child.getHash()
+
+		// nodes with an encoding shorter than 32 bytes are inlined in their parent
+		if len(hash) < 32 {
+			enc = hash
+			continue
+		}
+
+		enc, err = db.Get(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find node in database: %w", err)
+		}
+
+		nodes = append(nodes, enc)
+	}
+}
+
+// GenerateProof returns the encoded nodes on the paths from the root of the trie to each of the given keys.
+// Unlike the package-level GenerateProof, the nodes are read from memory, so it can be used for tries that
+// have not been written to a database, such as child tries.
+func (t *Trie) GenerateProof(keys [][]byte) ([][]byte, error) {
+	proof := [][]byte{}
+	if t.root == nil {
+		return proof, nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, key := range keys {
+		k := keyToNibbles(key)
+		curr := t.root
+
+		for curr != nil {
+			enc, _, err := curr.encodeAndHash()
+			if err != nil {
+				return nil, err
+			}
+
+			// the root is always included, other nodes only if they are not inlined in their parent
+			if _, has := seen[string(enc)]; !has && (curr == t.root || len(enc) >= 32) {
+				seen[string(enc)] = struct{}{}
+				proof = append(proof, enc)
+			}
+
+			b, ok := curr.(*branch)
+			if !ok {
+				break
+			}
+
+			length := lenCommonPrefix(b.key, k)
+			if length < len(b.key) || len(k) == len(b.key) {
+				break
+			}
+
+			curr = b.children[k[length]]
+			k = k[length+1:]
+		}
+	}
+
+	return proof, nil
+}
+
+// VerifyProof checks the given proof against the given root and returns the value stored at key.
+// A nil value and nil error means that the proof shows the key is not in the trie.
+// It returns an error if the proof does not contain the nodes needed to reach the key.
+func VerifyProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	if root == EmptyHash {
+		return nil, nil
+	}
+
+	if len(proof) == 0 {
+		return nil, ErrEmptyProof
+	}
+
+	nodes := make(map[common.Hash][]byte, len(proof))
+	for _, enc := range proof {
+		h, err := common.Blake2bHash(enc)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes[h] = enc
+	}
+
+	enc, ok := nodes[root]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing root node %s", ErrInvalidProof, root)
+	}
+
+	k := keyToNibbles(key)
+	for {
+		n, err := decodeBytes(enc)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c := n.(type) {
+		case *leaf:
+			if bytes.Equal(c.key, k) {
+				return c.value, nil
+			}
+			return nil, nil
+		case *branch:
+			length := lenCommonPrefix(c.key, k)
+			if length < len(c.key) {
+				return nil, nil
+			}
+
+			if len(k) == len(c.key) {
+				return c.value, nil
+			}
+
+			child := c.children[k[length]]
+			if child == nil {
+				return nil, nil
+			}
+
+			k = k[length+1:]
+			ref := child.getHash()
+			if len(ref) < 32 {
+				enc = ref
+				continue
+			}
+
+			enc, ok = nodes[common.BytesToHash(ref)]
+			if !ok {
+				return nil, fmt.Errorf("%w: missing node %x", ErrInvalidProof, ref)
+			}
+		}
+	}
+}
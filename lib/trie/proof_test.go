@@ -0,0 +1,161 @@
+// Copyright 2019 ChainSafe Systems (ON) Corp.
+// This file is part of gossamer.
+//
+// The gossamer library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gossamer library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gossamer library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndVerifyProof(t *testing.T) {
+	tests := []Test{
+		{key: []byte{0x01, 0x35}, value: []byte("pen")},
+		{key: []byte{0x01, 0x35, 0x79}, value: []byte("penguin")},
+		{key: []byte{0x01, 0x35, 0x7}, value: []byte("g")},
+		{key: []byte{0xf2}, value: []byte("feather")},
+		{key: []byte{0xf2, 0x3}, value: []byte("f")},
+		{key: []byte{0x09, 0xd3}, value: []byte("noot")},
+		{key: []byte{0x07}, value: []byte("ramen")},
+		{key: []byte("asdf"), value: []byte("a value that is long enough to not be inlined in its parent")},
+	}
+
+	trie := NewEmptyTrie()
+	for _, test := range tests {
+		trie.Put(test.key, test.value)
+	}
+
+	db := newTestDB(t)
+	err := trie.Store(db)
+	require.NoError(t, err)
+
+	root := trie.MustHash()
+	for _, test := range tests {
+		proof, err := GenerateProof(db, root, [][]byte{test.key})
+		require.NoError(t, err)
+		require.NotEmpty(t, proof)
+
+		val, err := VerifyProof(root, test.key, proof)
+		require.NoError(t, err)
+		require.Equal(t, test.value, val)
+	}
+
+	// proof of absence
+	absent := []byte{0x01, 0x36}
+	proof, err := GenerateProof(db, root, [][]byte{absent})
+	require.NoError(t, err)
+
+	val, err := VerifyProof(root, absent, proof)
+	require.NoError(t, err)
+	require.Nil(t, val)
+}
+
+func TestGenerateProof_MultipleKeys(t *testing.T) {
+	trie := NewEmptyTrie()
+	rt := GenerateRandomTests(t, 100)
+	for _, test := range rt {
+		trie.Put(test.key, test.value)
+	}
+
+	db := newTestDB(t)
+	err := trie.Store(db)
+	require.NoError(t, err)
+
+	keys := [][]byte{}
+	for _, test := range rt[:10] {
+		keys = append(keys, test.key)
+	}
+
+	root := trie.MustHash()
+	proof, err := GenerateProof(db, root, keys)
+	require.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for _, enc := range proof {
+		_, has := seen[string(enc)]
+		require.False(t, has)
+		seen[string(enc)] = struct{}{}
+	}
+
+	for _, test := range rt[:10] {
+		val, err := VerifyProof(root, test.key, proof)
+		require.NoError(t, err)
+		require.Equal(t, test.value, val)
+	}
+}
+
+func TestVerifyProof_Invalid(t *testing.T) {
+	trie := NewEmptyTrie()
+	rt := GenerateRandomTests(t, 100)
+	for _, test := range rt {
+		trie.Put(test.key, test.value)
+	}
+
+	db := newTestDB(t)
+	err := trie.Store(db)
+	require.NoError(t, err)
+
+	root := trie.MustHash()
+	_, err = VerifyProof(root, rt[0].key, [][]byte{})
+	require.Equal(t, ErrEmptyProof, err)
+
+	proof, err := GenerateProof(db, root, [][]byte{rt[0].key})
+	require.NoError(t, err)
+	require.Greater(t, len(proof), 1)
+
+	// drop the last node on the path to the key
+	_, err = VerifyProof(root, rt[0].key, proof[:len(proof)-1])
+	require.True(t, errors.Is(err, ErrInvalidProof))
+
+	// proof against the wrong root
+	other := NewEmptyTrie()
+	other.Put([]byte("noot"), []byte("washere"))
+	_, err = VerifyProof(other.MustHash(), rt[0].key, proof)
+	require.True(t, errors.Is(err, ErrInvalidProof))
+}
+
+func TestTrie_GenerateProof(t *testing.T) {
+	trie := NewEmptyTrie()
+	rt := GenerateRandomTests(t, 100)
+	for _, test := range rt {
+		trie.Put(test.key, test.value)
+	}
+
+	db := newTestDB(t)
+	err := trie.Store(db)
+	require.NoError(t, err)
+
+	keys := [][]byte{}
+	for _, test := range rt[:10] {
+		keys = append(keys, test.key)
+	}
+
+	root := trie.MustHash()
+	proof, err := trie.GenerateProof(keys)
+	require.NoError(t, err)
+
+	expected, err := GenerateProof(db, root, keys)
+	require.NoError(t, err)
+	require.ElementsMatch(t, expected, proof)
+
+	for _, test := range rt[:10] {
+		val, err := VerifyProof(root, test.key, proof)
+		require.NoError(t, err)
+		require.Equal(t, test.value, val)
+	}
+}
@@ -38,6 +38,9 @@ func TestMain(m *testing.M) {
 	utils.CreateDefaultConfig()
 	defer os.Remove(utils.ConfigDefault)
 
+	utils.CreateConfigUnsafeRPC()
+	defer os.Remove(utils.ConfigUnsafeRPC)
+
 	// Start all tests
 	code := m.Run()
 	os.Exit(code)
@@ -32,33 +32,37 @@ func TestOffchainRPC(t *testing.T) {
 		return
 	}
 
+	t.Log("starting gossamer...")
+	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigUnsafeRPC)
+	require.Nil(t, err)
+
+	time.Sleep(time.Second) // give server a second to start
+
 	testCases := []*testCase{
-		{ //TODO
+		{
 			description: "test offchain_localStorageSet",
 			method:      "offchain_localStorageSet",
-			skip:        true,
+			params:      `["PERSISTENT", "0x11111111", "0x22222222"]`,
+			expected:    "",
 		},
-		{ //TODO
+		{
 			description: "test offchain_localStorageGet",
 			method:      "offchain_localStorageGet",
-			skip:        true,
+			params:      `["PERSISTENT", "0x11111111"]`,
+			expected:    "0x22222222",
 		},
-		{ //TODO
-			description: "test offchain_localStorageGet",
+		{
+			description: "test offchain_localStorageGet with a missing key",
 			method:      "offchain_localStorageGet",
-			skip:        true,
+			params:      `["LOCAL", "0x11111111"]`,
+			expected:    "",
 		},
 	}
 
-	t.Log("starting gossamer...")
-	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigDefault)
-	require.Nil(t, err)
-
-	time.Sleep(time.Second) // give server a second to start
-
 	for _, test := range testCases {
 		t.Run(test.description, func(t *testing.T) {
-			_ = getResponse(t, test)
+			res := getResponse(t, test)
+			require.Equal(t, test.expected, *res.(*string))
 		})
 	}
 
@@ -53,8 +53,8 @@ func TestStateRPCResponseValidation(t *testing.T) {
 		{
 			description: "Test state_call",
 			method:      "state_call",
-			params:      `["", "","0x580d77a9136035a0bc3c3cd86286172f7f81291164c5914266073a30466fba21"]`,
-			expected:    modules.StateCallResponse{},
+			params:      fmt.Sprintf(`["Core_version", "0x", "%s"]`, blockHash.String()),
+			expected:    modules.StateCallResponse(""),
 		},
 		{ //TODO disable skip when implemented
 			description: "Test state_getKeysPaged",
@@ -88,8 +88,14 @@ func TestStateRPCResponseValidation(t *testing.T) {
 		{
 			description: "Test state_queryStorage",
 			method:      "state_queryStorage",
-			params:      `[["0xf2794c22e353e9a839f12faab03a911bf68967d635641a7087e53f2bff1ecad3c6756fee45ec79ead60347fffb770bcdf0ec74da701ab3d6495986fe1ecc3027"], "0xa32c60dee8647b07435ae7583eb35cee606209a595718562dd4a486a07b6de15", null]`,
-			expected:    modules.StorageChangeSetResponse{},
+			params:      fmt.Sprintf(`[["0xf2794c22e353e9a839f12faab03a911bf68967d635641a7087e53f2bff1ecad3c6756fee45ec79ead60347fffb770bcdf0ec74da701ab3d6495986fe1ecc3027"], "%s", null]`, blockHash.String()),
+			expected:    []modules.StorageChangeSetResponse{},
+		},
+		{
+			description: "Test state_queryStorageAt",
+			method:      "state_queryStorageAt",
+			params:      fmt.Sprintf(`[["0xf2794c22e353e9a839f12faab03a911bf68967d635641a7087e53f2bff1ecad3c6756fee45ec79ead60347fffb770bcdf0ec74da701ab3d6495986fe1ecc3027"], "%s"]`, blockHash.String()),
+			expected:    []modules.StorageChangeSetResponse{},
 		},
 		{
 			description: "Test valid block hash state_getRuntimeVersion",
@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/tests/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -32,25 +33,30 @@ func TestEngineRPC(t *testing.T) {
 		return
 	}
 
+	t.Log("starting gossamer...")
+	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigUnsafeRPC)
+	require.Nil(t, err)
+
+	time.Sleep(time.Second) // give server a second to start
+
+	blockHash, err := utils.GetBlockHash(t, nodes[0], "")
+	require.NoError(t, err)
+
 	testCases := []*testCase{
-		{ //TODO
+		{
 			description: "test engine_createBlock",
 			method:      "engine_createBlock",
-			skip:        true,
+			params:      `[true, true, null]`,
+			expected:    modules.EngineCreateBlockResponse{},
 		},
-		{ //TODO
+		{
 			description: "test engine_finalizeBlock",
 			method:      "engine_finalizeBlock",
-			skip:        true,
+			params:      fmt.Sprintf(`["%s", null]`, blockHash.String()),
+			expected:    true,
 		},
 	}
 
-	t.Log("starting gossamer...")
-	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigDefault)
-	require.Nil(t, err)
-
-	time.Sleep(time.Second) // give server a second to start
-
 	for _, test := range testCases {
 		t.Run(test.description, func(t *testing.T) {
 			_ = getResponse(t, test)
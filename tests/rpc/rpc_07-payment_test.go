@@ -22,30 +22,38 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/tests/utils"
 	"github.com/stretchr/testify/require"
 )
 
+// testTransferExtrinsic is a signed balances transfer from Alice to Bob
+const testTransferExtrinsic = "0x410284ffd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d01f8efbe48487e57a22abf7e3acd491b7f3528a33a111b1298601554863d27eb129eaa4e718e1365414ff3d028b62bebc651194c6b5001e5c2839b982757e08a8c0000000600ff8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a480b00c465f14670"
+
 func TestPaymentRPC(t *testing.T) {
 	if utils.MODE != rpcSuite {
 		_, _ = fmt.Fprintln(os.Stdout, "Going to skip RPC suite tests")
 		return
 	}
 
-	testCases := []*testCase{
-		{ //TODO
-			description: "test payment_queryInfo",
-			method:      "payment_queryInfo",
-			skip:        true,
-		},
-	}
-
 	t.Log("starting gossamer...")
 	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigDefault)
 	require.Nil(t, err)
 
 	time.Sleep(time.Second) // give server a second to start
 
+	blockHash, err := utils.GetBlockHash(t, nodes[0], "")
+	require.NoError(t, err)
+
+	testCases := []*testCase{
+		{
+			description: "test payment_queryInfo",
+			method:      "payment_queryInfo",
+			params:      fmt.Sprintf(`["%s", "%s"]`, testTransferExtrinsic, blockHash.String()),
+			expected:    modules.PaymentQueryInfoResponse{},
+		},
+	}
+
 	for _, test := range testCases {
 		t.Run(test.description, func(t *testing.T) {
 			_ = getResponse(t, test)
@@ -22,38 +22,52 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/rpc/modules"
+	"github.com/ChainSafe/gossamer/lib/common"
 	"github.com/ChainSafe/gossamer/tests/utils"
 	"github.com/stretchr/testify/require"
 )
 
+// aliceAddress is the ss58 address of Alice's account, which isn't a contract
+const aliceAddress = "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"
+
 func TestContractsRPC(t *testing.T) {
 	if utils.MODE != rpcSuite {
 		_, _ = fmt.Fprintln(os.Stdout, "Going to skip RPC suite tests")
 		return
 	}
 
+	t.Log("starting gossamer...")
+	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigDefault)
+	require.Nil(t, err)
+
+	time.Sleep(time.Second) // give server a second to start
+
+	blockHash, err := utils.GetBlockHash(t, nodes[0], "")
+	require.NoError(t, err)
+
+	// there are no contracts in the genesis state, so querying an account that isn't a contract must fail
 	testCases := []*testCase{
-		{ //TODO
+		{
 			description: "test contracts_getStorage",
 			method:      "contracts_getStorage",
-			skip:        true,
+			params:      fmt.Sprintf(`["%s", "%s"]`, aliceAddress, common.Hash{}.String()),
 		},
-		{ //TODO
-			description: "test contracts_getStorage",
+		{
+			description: "test contracts_getStorage at block",
 			method:      "contracts_getStorage",
-			skip:        true,
+			params:      fmt.Sprintf(`["%s", "%s", "%s"]`, aliceAddress, common.Hash{}.String(), blockHash.String()),
 		},
 	}
 
-	t.Log("starting gossamer...")
-	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigDefault)
-	require.Nil(t, err)
-
-	time.Sleep(time.Second) // give server a second to start
-
 	for _, test := range testCases {
 		t.Run(test.description, func(t *testing.T) {
-			_ = getResponse(t, test)
+			respBody, err := utils.PostRPC(test.method, utils.NewEndpoint(currentPort), test.params)
+			require.NoError(t, err)
+
+			var res modules.ContractsGetStorageResponse
+			err = utils.DecodeRPC(t, respBody, &res)
+			require.Error(t, err)
 		})
 	}
 
@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/gossamer/dot/rpc/modules"
 	"github.com/ChainSafe/gossamer/tests/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -33,15 +34,15 @@ func TestBabeRPC(t *testing.T) {
 	}
 
 	testCases := []*testCase{
-		{ //TODO
+		{
 			description: "test babe_epochAuthorship",
 			method:      "babe_epochAuthorship",
-			skip:        true,
+			expected:    modules.EpochAuthorshipResponse{},
 		},
 	}
 
 	t.Log("starting gossamer...")
-	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigDefault)
+	nodes, err := utils.InitializeAndStartNodes(t, 1, utils.GenesisDefault, utils.ConfigUnsafeRPC)
 	require.Nil(t, err)
 
 	time.Sleep(time.Second) // give server a second to start
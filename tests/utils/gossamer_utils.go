@@ -80,6 +80,8 @@ var (
 	ConfigNoGrandpa string = filepath.Join(currentDir, "../utils/config_nograndpa.toml")
 	// ConfigNotAuthority is a config file with no authority functionality
 	ConfigNotAuthority string = filepath.Join(currentDir, "../utils/config_notauthority.toml")
+	// ConfigUnsafeRPC is a config file with the unsafe RPC methods enabled
+	ConfigUnsafeRPC string = filepath.Join(currentDir, "../utils/config_unsafe_rpc.toml")
 )
 
 // Node represents a gossamer process
@@ -131,7 +133,8 @@ func StartGossamer(t *testing.T, node *Node, websocket bool) error {
 		"--basepath", node.basePath,
 		"--rpchost", HOSTNAME,
 		"--rpcport", node.RPCPort,
-		"--rpcmods", "system,author,chain,state,dev,rpc",
+		// the unsafe modules are only enabled by the configs that allow unsafe RPC methods
+		"--rpcmods", "system,author,chain,state,dev,rpc,babe,engine,offchain",
 		"--rpc",
 		"--log", "info"}
 
@@ -449,7 +452,7 @@ func generateDefaultConfig() *ctoml.Config {
 		RPC: ctoml.RPCConfig{
 			Enabled: false,
 			Host:    "localhost",
-			Modules: []string{"system", "author", "chain", "state"},
+			Modules: []string{"system", "author", "chain", "state", "babe", "contracts", "payment"},
 			WS:      false,
 		},
 	}
@@ -522,3 +525,15 @@ func CreateConfigNotAuthority() {
 	cfg := generateConfigNotAuthority()
 	_ = dot.ExportTomlConfig(cfg, ConfigNotAuthority)
 }
+
+func generateConfigUnsafeRPC() *ctoml.Config {
+	cfg := generateDefaultConfig()
+	cfg.RPC.Unsafe = true
+	return cfg
+}
+
+// CreateConfigUnsafeRPC generates and creates unsafe RPC config file.
+func CreateConfigUnsafeRPC() {
+	cfg := generateConfigUnsafeRPC()
+	_ = dot.ExportTomlConfig(cfg, ConfigUnsafeRPC)
+}